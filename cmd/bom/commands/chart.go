@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/terem/bom/internal/bom"
+)
+
+func NewChartCmd(verbose *bool) *cobra.Command {
+	var inputFile string
+	var outputFile string
+	var chartType string
+	var asOf string
+	opts := bom.DefaultChartOptions()
+
+	cmd := &cobra.Command{
+		Use:   "chart",
+		Short: "Write an SVG chart of rainfall",
+		Long: `Write an SVG chart of a Bureau of Meteorology (BOM) CSV file.
+
+The --type flag selects the chart:
+  annual      a bar chart of annual totals with the mean of complete years
+              as a reference line; incomplete and unfinished years are muted
+  boxplot     a box plot of each calendar month's total across the years in
+              which that month was complete
+  cumulative  the rainfall accumulated through a year against the mean
+              accumulation of complete earlier and later years
+  heatmap     a calendar of a year's days shaded by their rainfall
+
+Cumulative and heatmap charts show the latest year with a reading unless
+--year is given. Years and months with fewer than --min-completeness percent
+of days recorded are left out of averages and box plots. --y-max fixes the
+top of the y axis, or the rainfall shaded at full colour in a heatmap, so
+that charts of different stations can be compared.
+
+The title, axis labels, size, colours and font can all be set with flags.
+Colours are any SVG colour, such as "#2b6cb0" or "steelblue".
+
+Example:
+  bom chart -i weather.csv -o annual.svg
+  bom chart -i weather.csv -o months.svg --type boxplot --y-max 400
+  bom chart -i weather.csv -o 2019.svg --type cumulative --year 2019 --colour darkgreen
+  bom chart -i weather.csv -o heatmap.svg --type heatmap --width 1000 --title "Daily rainfall"`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			t, err := bom.ParseChartType(chartType)
+			if err != nil {
+				return err
+			}
+			opts.Type = t
+			if opts.MinCompleteness < 0 || opts.MinCompleteness > 100 {
+				return fmt.Errorf("--min-completeness must be between 0 and 100, got %g", opts.MinCompleteness)
+			}
+			if opts.YMax < 0 {
+				return fmt.Errorf("--y-max must not be negative, got %g", opts.YMax)
+			}
+			if opts.Style.Width <= 0 || opts.Style.Height <= 0 {
+				return fmt.Errorf("--width and --height must be positive, got %dx%d", opts.Style.Width, opts.Style.Height)
+			}
+			if opts.Style.FontSize <= 0 {
+				return fmt.Errorf("--font-size must be positive, got %d", opts.Style.FontSize)
+			}
+			if asOf != "" {
+				clock, err := bom.ParseAsOfDate(asOf)
+				if err != nil {
+					return err
+				}
+				opts.Clock = clock
+			}
+
+			processor := bom.NewProcessorWithVerbose(*verbose)
+
+			inFile, err := os.Open(inputFile)
+			if err != nil {
+				return fmt.Errorf("failed to open input file %s: %w", inputFile, err)
+			}
+			defer inFile.Close()
+
+			output, outputName, closeOutput, err := openOutput(cmd, outputFile)
+			if err != nil {
+				return err
+			}
+			defer closeOutput()
+
+			if err := processor.ProcessChart(inFile, output, opts); err != nil {
+				return fmt.Errorf("chart failed: %w", err)
+			}
+
+			if *verbose {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Successfully wrote %s chart for %s to %s\n", opts.Type, inputFile, outputName)
+			}
+			return nil
+		},
+	}
+
+	style := &opts.Style
+	cmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input CSV file path (required)")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output SVG file path (defaults to stdout)")
+	cmd.Flags().StringVar(&chartType, "type", string(opts.Type), "Chart type: annual, boxplot, cumulative or heatmap")
+	cmd.Flags().IntVar(&opts.Year, "year", 0, "Year of a cumulative or heatmap chart (defaults to the latest year)")
+	cmd.Flags().StringVar(&opts.Labels.Title, "title", "", "Chart title (defaults to a description of the chart)")
+	cmd.Flags().StringVar(&opts.Labels.XLabel, "x-label", "", "X axis label")
+	cmd.Flags().StringVar(&opts.Labels.YLabel, "y-label", "", "Y axis label (defaults to \"Rainfall (mm)\")")
+	cmd.Flags().Float64Var(&opts.YMax, "y-max", 0, "Top of the y axis, or full colour of a heatmap, in mm (defaults to fit the data)")
+	cmd.Flags().Float64Var(&opts.MinCompleteness, "min-completeness", opts.MinCompleteness, "Minimum percentage of days recorded for a year or month to be averaged")
+	cmd.Flags().StringVar(&asOf, "as-of", "", "Treat this date (YYYY-MM-DD) as today when excluding unfinished months and years")
+	cmd.Flags().IntVar(&style.Width, "width", style.Width, "Chart width in pixels")
+	cmd.Flags().IntVar(&style.Height, "height", style.Height, "Chart height in pixels")
+	cmd.Flags().StringVar(&style.BarColour, "colour", style.BarColour, "Colour of bars, boxes, lines and heatmap days")
+	cmd.Flags().StringVar(&style.MutedColour, "muted-colour", style.MutedColour, "Colour of incomplete years and days without a reading")
+	cmd.Flags().StringVar(&style.ReferenceColour, "reference-colour", style.ReferenceColour, "Colour of mean lines")
+	cmd.Flags().StringVar(&style.AxisColour, "axis-colour", style.AxisColour, "Colour of axes")
+	cmd.Flags().StringVar(&style.GridColour, "grid-colour", style.GridColour, "Colour of grid lines")
+	cmd.Flags().StringVar(&style.TextColour, "text-colour", style.TextColour, "Colour of titles and labels")
+	cmd.Flags().StringVar(&style.FontFamily, "font-family", style.FontFamily, "Font family of titles and labels")
+	cmd.Flags().IntVar(&style.FontSize, "font-size", style.FontSize, "Font size of labels in pixels")
+	cmd.MarkFlagRequired("input")
+
+	return cmd
+}
@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChartCommandHelp(t *testing.T) {
+	verbose := false
+	cmd := NewChartCmd(&verbose)
+	cmd.SetArgs([]string{"--help"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Chart command help failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{"SVG chart", "--type", "boxplot", "cumulative", "heatmap", "--y-max", "--colour", "--font-family"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain '%s', got: %s", expected, output)
+		}
+	}
+}
+
+func TestChartCommandTypes(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+`IDCJAC0009,066062,2019,12,31,4.0,1,Y
+IDCJAC0009,066062,2020,1,1,5.2,1,Y
+IDCJAC0009,066062,2020,1,2,0.0,1,Y
+IDCJAC0009,066062,2020,1,3,12.5,1,Y`)
+
+	for _, chartType := range []string{"annual", "boxplot", "cumulative", "heatmap"} {
+		output := filepath.Join(t.TempDir(), chartType+".svg")
+
+		verbose := false
+		cmd := NewChartCmd(&verbose)
+		cmd.SetArgs([]string{"--input", input, "--output", output, "--type", chartType, "--as-of", "2020-02-01",
+			"--min-completeness", "0", "--title", "Test <station>", "--colour", "darkgreen", "--width", "640"})
+
+		var buf bytes.Buffer
+		cmd.SetOut(&buf)
+		cmd.SetErr(&buf)
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("Chart command failed for %s: %v", chartType, err)
+		}
+
+		data, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatalf("Expected the %s chart to be written: %v", chartType, err)
+		}
+		var doc struct {
+			XMLName xml.Name `xml:"svg"`
+			Width   string   `xml:"width,attr"`
+			Texts   []string `xml:"text"`
+		}
+		if err := xml.Unmarshal(data, &doc); err != nil {
+			t.Fatalf("%s chart is not valid XML: %v\n%s", chartType, err, data)
+		}
+		if doc.Width != "640" || doc.Texts[0] != "Test <station>" {
+			t.Errorf("%s: expected a 640px chart titled 'Test <station>', got %q and %q", chartType, doc.Width, doc.Texts[0])
+		}
+		if !strings.Contains(string(data), `"darkgreen"`) {
+			t.Errorf("%s: expected the chart to use the given colour, got: %s", chartType, data)
+		}
+	}
+}
+
+func TestChartCommandInvalidFlags(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+`IDCJAC0009,066062,2020,1,1,5.2,1,Y`)
+
+	for _, args := range [][]string{
+		{"--input", "nonexistent_file.csv"},
+		{"--input", input, "--type", "pie"},
+		{"--input", input, "--min-completeness", "101"},
+		{"--input", input, "--y-max", "-1"},
+		{"--input", input, "--width", "0"},
+		{"--input", input, "--font-size", "0"},
+		{"--input", input, "--as-of", "2020-13-01"},
+		{"--input", input, "--type", "heatmap", "--year", "1999"},
+	} {
+		verbose := false
+		cmd := NewChartCmd(&verbose)
+		cmd.SetArgs(args)
+
+		var buf bytes.Buffer
+		cmd.SetOut(&buf)
+		cmd.SetErr(&buf)
+
+		if err := cmd.Execute(); err == nil {
+			t.Errorf("Expected error for %v", args)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/terem/bom/internal/bom"
+)
+
+func NewCheckOutputCmd(verbose *bool) *cobra.Command {
+	var inputFile string
+	var schema string
+
+	cmd := &cobra.Command{
+		Use:   "check-output",
+		Short: "Validate a JSON output file against its JSON Schema",
+		Long: `Validate a JSON file written by bom convert.
+
+The check-output command validates the file against the JSON Schema printed
+by bom schema, reporting each missing, unexpected or mistyped field with a
+JSON Pointer to it. The schema version is detected from the file: v2 when
+it has a schemaVersion, v1 otherwise; use --schema to require one.
+
+A file that matches the schema is then checked for consistency:
+  - DaysWithRainfall + DaysWithNoRainfall equals RecordedDays for every
+    year and month
+  - the TotalRainfall of a year's months add up to the year's
+    TotalRainfall, allowing for rounding
+Checks are skipped for values that are unavailable.
+
+The command fails if any problem is found.
+
+Example:
+  bom check-output -i output.json
+  bom check-output -i output.json --schema v2`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var schemaVersion bom.SchemaVersion
+			if cmd.Flags().Changed("schema") {
+				var err error
+				if schemaVersion, err = bom.ParseSchemaVersion(schema); err != nil {
+					return err
+				}
+			}
+
+			inFile, err := os.Open(inputFile)
+			if err != nil {
+				return fmt.Errorf("failed to open input file %s: %w", inputFile, err)
+			}
+			defer inFile.Close()
+
+			checked, problems, err := bom.CheckOutput(inFile, schemaVersion)
+			if err != nil {
+				return fmt.Errorf("check failed for %s: %w", inputFile, err)
+			}
+			if *verbose {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Checked %s against the %s JSON Schema\n", inputFile, checked)
+			}
+
+			for _, p := range problems {
+				fmt.Fprintln(cmd.OutOrStdout(), p)
+			}
+			if len(problems) == 1 {
+				return fmt.Errorf("%s has 1 problem", inputFile)
+			}
+			if len(problems) > 1 {
+				return fmt.Errorf("%s has %d problems", inputFile, len(problems))
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "✓ %s is valid %s output\n", inputFile, checked)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input JSON file path (required)")
+	cmd.Flags().StringVar(&schema, "schema", "", "Schema version to check against: v1 or v2 (defaults to the file's)")
+	cmd.MarkFlagRequired("input")
+
+	return cmd
+}
@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// convertTestOutput converts a small CSV file to JSON in the given schema
+// and returns the path of the JSON file
+func convertTestOutput(t *testing.T, schema string) string {
+	t.Helper()
+	input := writeTempCSV(t, testCSVHeader+`IDCJAC0009,066062,2019,12,31,4.0,1,Y
+IDCJAC0009,066062,2020,1,1,5.2,1,Y
+IDCJAC0009,066062,2020,1,2,0.0,1,Y
+IDCJAC0009,066062,2020,1,3,12.5,1,Y`)
+	output := filepath.Join(t.TempDir(), "output.json")
+
+	verbose := false
+	cmd := NewConvertCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input, "--output", output, "--schema", schema})
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Convert command failed: %v", err)
+	}
+	return output
+}
+
+func runCheckOutput(args ...string) (string, error) {
+	verbose := false
+	cmd := NewCheckOutputCmd(&verbose)
+	cmd.SetArgs(args)
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	err := cmd.Execute()
+	return buf.String(), err
+}
+
+func TestCheckOutputCommandHelp(t *testing.T) {
+	output, err := runCheckOutput("--help")
+	if err != nil {
+		t.Fatalf("Check-output command help failed: %v", err)
+	}
+	for _, expected := range []string{"JSON Schema", "--input", "--schema", "DaysWithRainfall"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain '%s', got: %s", expected, output)
+		}
+	}
+}
+
+func TestCheckOutputCommandValid(t *testing.T) {
+	for _, schema := range []string{"v1", "v2"} {
+		file := convertTestOutput(t, schema)
+		output, err := runCheckOutput("--input", file)
+		if err != nil {
+			t.Fatalf("Expected %s output to be valid: %v\n%s", schema, err, output)
+		}
+		if !strings.Contains(output, "is valid "+schema+" output") {
+			t.Errorf("Expected a confirmation, got: %s", output)
+		}
+	}
+}
+
+func TestCheckOutputCommandProblems(t *testing.T) {
+	file := convertTestOutput(t, "v1")
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	tampered := strings.Replace(string(data), `"RecordedDays": "3"`, `"RecordedDays": "4"`, 1)
+	if tampered == string(data) {
+		t.Fatal("Expected the test output to have 3 recorded days in a year")
+	}
+	if err := os.WriteFile(file, []byte(tampered), 0o644); err != nil {
+		t.Fatalf("Failed to write output: %v", err)
+	}
+
+	output, err := runCheckOutput("--input", file)
+	if err == nil || !strings.Contains(err.Error(), "has 1 problem") {
+		t.Errorf("Expected the check to fail with 1 problem, got %v", err)
+	}
+	if !strings.Contains(output, "/WeatherData/1: DaysWithRainfall (2) + DaysWithNoRainfall (1) is 3, but RecordedDays is 4") {
+		t.Errorf("Expected the days problem to be printed, got: %s", output)
+	}
+
+	// Requiring a schema version checks against it
+	if _, err := runCheckOutput("--input", convertTestOutput(t, "v2"), "--schema", "v1"); err == nil {
+		t.Error("Expected v2 output to fail the v1 schema")
+	}
+}
+
+func TestCheckOutputCommandErrors(t *testing.T) {
+	if _, err := runCheckOutput(); err == nil {
+		t.Error("Expected an error without --input")
+	}
+	if _, err := runCheckOutput("--input", filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+	if _, err := runCheckOutput("--input", writeTempCSV(t, "not json")); err == nil {
+		t.Error("Expected an error for a file that is not JSON")
+	}
+	if _, err := runCheckOutput("--input", convertTestOutput(t, "v1"), "--schema", "v9"); err == nil {
+		t.Error("Expected an error for an unknown schema version")
+	}
+}
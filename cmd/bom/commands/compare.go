@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/terem/bom/internal/bom"
+)
+
+func NewCompareCmd(verbose *bool) *cobra.Command {
+	var inputA string
+	var inputB string
+	var outputFile string
+	var inputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "compare",
+		Short: "Compare the rainfall records of two stations",
+		Long: `Compare the rainfall records of two Bureau of Meteorology (BOM) CSV files.
+
+The compare command aligns the two daily series on date and, over the days
+both stations recorded, reports the overlap period, the daily and monthly
+correlations, the mean daily bias and the ratio of totals of station B to
+station A. A joint monthly table gives each station's total over the common
+days of every month, and a summary per calendar month gives the mean
+difference and ratio across years.
+
+Use --input-format json to compare the v1 JSON written by convert
+--include-daily instead of the CSV files; the daily records nested in it
+are read back and compared, leaving out days filled by imputation.
+
+Example:
+  bom compare -a station1.csv -b station2.csv -o comparison.json
+  bom compare -a station1.json -b station2.json --input-format json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			processor := bom.NewProcessorWithOptions(bom.ProcessorOptions{
+				Verbose:     *verbose,
+				Aggregator:  bom.DefaultAggregatorOptions(),
+				InputFormat: inputFormat,
+			})
+
+			fileA, err := os.Open(inputA)
+			if err != nil {
+				return fmt.Errorf("failed to open input file %s: %w", inputA, err)
+			}
+			defer fileA.Close()
+
+			fileB, err := os.Open(inputB)
+			if err != nil {
+				return fmt.Errorf("failed to open input file %s: %w", inputB, err)
+			}
+			defer fileB.Close()
+
+			output, outputName, closeOutput, err := openOutput(cmd, outputFile)
+			if err != nil {
+				return err
+			}
+			defer closeOutput()
+
+			if err := processor.ProcessComparison(fileA, fileB, output, inputA, inputB); err != nil {
+				return fmt.Errorf("comparison failed: %w", err)
+			}
+
+			if *verbose {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Successfully wrote comparison of %s and %s to %s\n", inputA, inputB, outputName)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputA, "station-a", "a", "", "CSV or JSON file path of the reference station (required)")
+	cmd.Flags().StringVarP(&inputB, "station-b", "b", "", "CSV or JSON file path of the station compared with it (required)")
+	cmd.Flags().StringVar(&inputFormat, "input-format", bom.InputCSV, "Input format: csv, or json for the v1 output of convert --include-daily")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output JSON file path (defaults to stdout)")
+	cmd.MarkFlagRequired("station-a")
+	cmd.MarkFlagRequired("station-b")
+
+	return cmd
+}
@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCompareCommandHelp(t *testing.T) {
+	verbose := false
+	cmd := NewCompareCmd(&verbose)
+	cmd.SetArgs([]string{"--help"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Compare command help failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{"Compare the rainfall records", "--station-a", "--station-b", "--input-format"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain '%s', got: %s", expected, output)
+		}
+	}
+}
+
+func TestCompareCommand(t *testing.T) {
+	inputA := writeTempCSV(t, testCSVHeader+
+		"IDCJAC0009,066062,2020,1,31,2.0,1,Y\n"+
+		"IDCJAC0009,066062,2020,2,1,4.0,1,Y\n"+
+		"IDCJAC0009,066062,2020,2,2,0,1,Y\n")
+	inputB := writeTempCSV(t, testCSVHeader+
+		"IDCJAC0009,066037,2020,1,30,9.0,1,Y\n"+
+		"IDCJAC0009,066037,2020,1,31,3.0,1,Y\n"+
+		"IDCJAC0009,066037,2020,2,1,5.0,1,Y\n"+
+		"IDCJAC0009,066037,2020,2,2,1.0,1,Y\n")
+
+	verbose := false
+	cmd := NewCompareCmd(&verbose)
+	cmd.SetArgs([]string{"-a", inputA, "-b", inputB})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Compare command failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{
+		`"FirstDate": "2020-01-31"`,
+		`"CommonDays": "3"`,
+		`"OnlyB": "1"`,
+		`"MeanDailyBias": "1.0000"`,
+		`"TotalRainfallA": "6.0"`,
+		`"TotalRainfallB": "9.0"`,
+		`"RatioOfTotals": "1.5000"`,
+		`"Month": "February"`,
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %s, got: %s", expected, output)
+		}
+	}
+}
+
+func TestCompareCommandNoOverlap(t *testing.T) {
+	inputA := writeTempCSV(t, testCSVHeader+"IDCJAC0009,066062,2020,1,1,5.2,1,Y\n")
+	inputB := writeTempCSV(t, testCSVHeader+"IDCJAC0009,066037,2020,1,2,5.2,1,Y\n")
+
+	verbose := false
+	cmd := NewCompareCmd(&verbose)
+	cmd.SetArgs([]string{"-a", inputA, "-b", inputB})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err == nil {
+		t.Error("Expected error for stations without common days")
+	}
+}
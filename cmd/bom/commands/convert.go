@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/terem/bom/internal/bom"
@@ -12,6 +15,21 @@ import (
 func NewConvertCmd(verbose *bool) *cobra.Command {
 	var inputFile string
 	var outputFile string
+	var minCompleteness float64
+	var incompleteAction string
+	var asOf string
+	var xsdFile string
+	var filterFlags filterFlags
+	var statistics []string
+	var precision string
+	var medianMode string
+	var rainDayThreshold string
+	var imputeNeighbour string
+	var imputeClimatology bool
+	var schema string
+	var includeDaily bool
+	var format string
+	var width int
 
 	cmd := &cobra.Command{
 		Use:   "convert",
@@ -21,10 +39,111 @@ func NewConvertCmd(verbose *bool) *cobra.Command {
 The convert command reads a BOM weather CSV file and outputs aggregated weather data
 in JSON format with detailed yearly and monthly rainfall statistics.
 
+Each year and month reports its completeness alongside the statistics.
+Flags choose the output format and schema, the statistics and their
+precision, the part of the record to process and how missing days are
+filled; see the flag descriptions below and the README for details.
+
 Example:
-  bom convert -i weather.csv -o output.json`,
+  bom convert -i weather.csv -o output.json
+  bom convert -i weather.csv --format csv-months -o months.csv
+  bom convert -i weather.csv --format table --years 2010-2019
+  bom convert -i weather.csv --schema v2 --statistics TotalRainfall,StdDevDailyRainfall`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			processor := bom.NewProcessorWithVerbose(*verbose)
+			action, err := bom.ParseIncompleteAction(incompleteAction)
+			if err != nil {
+				return err
+			}
+
+			aggregatorOptions := bom.DefaultAggregatorOptions()
+			aggregatorOptions.MinCompleteness = minCompleteness
+			aggregatorOptions.IncompleteAction = action
+			if asOf != "" {
+				clock, err := bom.ParseAsOfDate(asOf)
+				if err != nil {
+					return err
+				}
+				aggregatorOptions.Clock = clock
+			}
+			if len(statistics) > 0 {
+				selected, err := bom.AllStatistics().Select(statistics)
+				if err != nil {
+					return err
+				}
+				aggregatorOptions.Statistics = selected
+			} else if format == bom.FormatTable || format == bom.FormatMarkdown {
+				// Tables have columns for the dispersion statistics
+				aggregatorOptions.Statistics = bom.AllStatistics()
+			}
+			// Tables are read rather than parsed, so they default to BOM's
+			// published precision
+			if !cmd.Flags().Changed("precision") && (format == bom.FormatTable || format == bom.FormatMarkdown) {
+				precision = "bom"
+			}
+			outputPrecision, err := bom.ParsePrecision(precision)
+			if err != nil {
+				return err
+			}
+			aggregatorOptions.Precision = &outputPrecision
+			aggregatorOptions.IncludeDaily = includeDaily
+			if cmd.Flags().Changed("median") || cmd.Flags().Changed("rain-day-threshold") {
+				mode, err := bom.ParseMedianMode(medianMode)
+				if err != nil {
+					return err
+				}
+				threshold, err := bom.ParseAmount(rainDayThreshold)
+				if err != nil {
+					return fmt.Errorf("invalid --rain-day-threshold: %w", err)
+				}
+				if threshold <= 0 {
+					return fmt.Errorf("--rain-day-threshold must be positive, got %s", rainDayThreshold)
+				}
+				aggregatorOptions.Median = bom.MedianOptions{
+					Mode:             mode,
+					RainDayThreshold: threshold,
+				}
+			}
+
+			filter, err := filterFlags.filter()
+			if err != nil {
+				return err
+			}
+			schemaVersion, err := bom.ParseSchemaVersion(schema)
+			if err != nil {
+				return err
+			}
+			if _, err := bom.DefaultEncoders().Lookup(format); err != nil {
+				return err
+			}
+			for _, flag := range []struct {
+				name    string
+				formats []string
+			}{
+				{"schema", []string{bom.FormatJSON, bom.FormatNDJSON}},
+				{"include-daily", []string{bom.FormatJSON, bom.FormatNDJSON, bom.FormatXML}},
+				{"xsd", []string{bom.FormatXML}},
+				{"width", []string{bom.FormatTable}},
+			} {
+				if cmd.Flags().Changed(flag.name) && !slices.Contains(flag.formats, format) {
+					return fmt.Errorf("--%s requires --format %s", flag.name, strings.Join(flag.formats, " or "))
+				}
+			}
+			if width < 0 {
+				return fmt.Errorf("--width must not be negative, got %d", width)
+			}
+
+			imputation := bom.ImputationOptions{Climatology: imputeClimatology}
+			if imputeNeighbour != "" {
+				neighbourFile, err := os.Open(imputeNeighbour)
+				if err != nil {
+					return fmt.Errorf("failed to open neighbour file %s: %w", imputeNeighbour, err)
+				}
+				defer neighbourFile.Close()
+				imputation.Neighbour, err = bom.NewParser(*verbose).ParseCSV(neighbourFile)
+				if err != nil {
+					return fmt.Errorf("failed to parse neighbour file %s: %w", imputeNeighbour, err)
+				}
+			}
 
 			// Open input file
 			inFile, err := os.Open(inputFile)
@@ -34,26 +153,36 @@ Example:
 			defer inFile.Close()
 
 			// Determine output destination
-			var output io.Writer
-			var outputName string
-			if outputFile == "" {
-				output = cmd.OutOrStdout()
-				outputName = "stdout"
-			} else {
-				outFile, err := os.Create(outputFile)
-				if err != nil {
-					return fmt.Errorf("failed to create output file %s: %w", outputFile, err)
-				}
-				defer outFile.Close()
-				output = outFile
-				outputName = outputFile
+			output, outputName, closeOutput, err := openOutput(cmd, outputFile)
+			if err != nil {
+				return err
+			}
+			defer closeOutput()
+			if width == 0 {
+				width = outputWidth(output)
 			}
 
+			processor := bom.NewProcessorWithOptions(bom.ProcessorOptions{
+				Verbose:    *verbose,
+				Aggregator: aggregatorOptions,
+				Filter:     filter,
+				Imputation: imputation,
+				Schema:     schemaVersion,
+				Format:     format,
+				Width:      width,
+			})
+
 			// Process the data
 			if err := processor.ProcessWeatherData(inFile, output); err != nil {
 				return fmt.Errorf("conversion failed: %w", err)
 			}
 
+			if xsdFile != "" {
+				if err := os.WriteFile(xsdFile, bom.WeatherDataXSD(), 0644); err != nil {
+					return fmt.Errorf("failed to write XML schema %s: %w", xsdFile, err)
+				}
+			}
+
 			if *verbose {
 				fmt.Fprintf(cmd.ErrOrStderr(), "Successfully converted %s to %s\n", inputFile, outputName)
 			}
@@ -62,8 +191,86 @@ Example:
 	}
 
 	cmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input CSV file path (required)")
-	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output JSON file path (required)")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file path (defaults to stdout)")
+	cmd.Flags().StringVarP(&format, "format", "f", bom.FormatJSON,
+		"Output format: "+strings.Join(bom.DefaultEncoders().Names(), ", "))
+	cmd.Flags().Float64Var(&minCompleteness, "min-completeness", 0, "Minimum percentage of days recorded for a period's statistics to be reliable")
+	cmd.Flags().StringVar(&incompleteAction, "incomplete", string(bom.IncompleteFlag), "Action for incomplete periods: flag or omit")
+	cmd.Flags().StringVar(&asOf, "as-of", "", "Treat this date (YYYY-MM-DD) as today when excluding future months")
+	cmd.Flags().StringSliceVar(&statistics, "statistics", nil,
+		"Statistics to include: "+strings.Join(bom.AllStatistics().Names(), ", ")+
+			" (default all but the variance, standard deviation and coefficient of variation; table and markdown default to all)")
+	cmd.Flags().StringVar(&medianMode, "median", string(bom.MedianWet), "Days the median daily rainfall is taken over: all, wet or threshold")
+	cmd.Flags().StringVar(&rainDayThreshold, "rain-day-threshold", bom.DefaultRainDayThreshold.Format(1), "Minimum rainfall in mm of a rain day for --median threshold, to at most two decimal places")
+	cmd.Flags().StringVar(&imputeNeighbour, "impute-neighbour", "", "Fill missing days from this neighbouring station's CSV file")
+	cmd.Flags().BoolVar(&imputeClimatology, "impute-climatology", false, "Fill missing days with the mean daily rainfall of their calendar month")
+	cmd.Flags().BoolVar(&includeDaily, "include-daily", false, "Nest each month's daily records under it in the output")
+	cmd.Flags().IntVar(&width, "width", 0, "Line width of --format table (defaults to the terminal width)")
+	cmd.Flags().StringVar(&xsdFile, "xsd", "", "Also write the XML Schema of --format xml to this path")
+	cmd.Flags().StringVar(&schema, "schema", string(bom.SchemaV1), "Output schema version: v1 (all strings) or v2 (typed)")
+	cmd.Flags().StringVar(&precision, "precision", "legacy", "Decimal places of output values: legacy, bom or a number (table and markdown default to bom)")
+	filterFlags.register(cmd)
 	cmd.MarkFlagRequired("input")
 
 	return cmd
 }
+
+// openOutput returns the writer for the output flag value, falling back to
+// the command's stdout when no path is given. The returned close function
+// must be called once writing has finished.
+func openOutput(cmd *cobra.Command, outputFile string) (io.Writer, string, func(), error) {
+	if outputFile == "" {
+		return cmd.OutOrStdout(), "stdout", func() {}, nil
+	}
+	outFile, err := os.Create(outputFile)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to create output file %s: %w", outputFile, err)
+	}
+	return outFile, outputFile, func() { outFile.Close() }, nil
+}
+
+// filterFlags holds the raw values of the date filter flags
+type filterFlags struct {
+	from   string
+	to     string
+	years  string
+	months string
+}
+
+// register adds the date filter flags to a command
+func (f *filterFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.from, "from", "", "Only include records on or after this date (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&f.to, "to", "", "Only include records on or before this date (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&f.years, "years", "", "Only include these years, e.g. 1961-1990,2019")
+	cmd.Flags().StringVar(&f.months, "months", "", "Only include these months, e.g. nov-mar or 1,2,3")
+}
+
+// filter converts the flag values into a bom.Filter
+func (f *filterFlags) filter() (bom.Filter, error) {
+	var filter bom.Filter
+	var err error
+	if f.from != "" {
+		if filter.From, err = time.Parse("2006-01-02", f.from); err != nil {
+			return bom.Filter{}, fmt.Errorf("invalid --from date '%s' (expected YYYY-MM-DD)", f.from)
+		}
+	}
+	if f.to != "" {
+		if filter.To, err = time.Parse("2006-01-02", f.to); err != nil {
+			return bom.Filter{}, fmt.Errorf("invalid --to date '%s' (expected YYYY-MM-DD)", f.to)
+		}
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return bom.Filter{}, fmt.Errorf("--to date %s is before --from date %s", f.to, f.from)
+	}
+	if f.years != "" {
+		if filter.Years, err = bom.ParseYears(f.years); err != nil {
+			return bom.Filter{}, fmt.Errorf("invalid --years: %w", err)
+		}
+	}
+	if f.months != "" {
+		if filter.Months, err = bom.ParseMonths(f.months); err != nil {
+			return bom.Filter{}, fmt.Errorf("invalid --months: %w", err)
+		}
+	}
+	return filter, nil
+}
@@ -2,9 +2,14 @@ package commands
 
 import (
 	"bytes"
+	"encoding/json"
+	"encoding/xml"
 	"os"
 	"strings"
 	"testing"
+	"unicode/utf8"
+
+	"github.com/terem/bom/internal/bom"
 )
 
 func TestConvertCommandHelp(t *testing.T) {
@@ -231,3 +236,521 @@ func TestConvertCommandFlags(t *testing.T) {
 		t.Errorf("Expected flag shorthand 'o', got: %s", outputFlag.Shorthand)
 	}
 }
+
+func TestConvertCommandIncompleteOmit(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+`IDCJAC0009,066062,2020,1,1,5.2,1,Y
+IDCJAC0009,066062,2020,1,2,0.0,1,Y`)
+
+	verbose := false
+	cmd := NewConvertCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input, "--min-completeness", "90", "--incomplete", "omit"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Convert command failed: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, `"Unreliable": "true"`) || !strings.Contains(output, `"PercentComplete": "6.5"`) {
+		t.Errorf("Expected incomplete January to be marked unreliable, got: %s", output)
+	}
+	if strings.Contains(output, "TotalRainfall") {
+		t.Errorf("Expected statistics to be omitted, got: %s", output)
+	}
+}
+
+func TestConvertCommandInvalidIncompleteAction(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+"IDCJAC0009,066062,2020,1,1,5.2,1,Y\n")
+
+	verbose := false
+	cmd := NewConvertCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input, "--incomplete", "drop"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("Expected error for unknown incomplete action")
+	}
+}
+
+func TestConvertCommandAsOf(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+`IDCJAC0009,066062,2020,1,1,5.2,1,Y
+IDCJAC0009,066062,2020,1,2,3.0,1,Y
+IDCJAC0009,066062,2020,2,1,12.5,1,Y`)
+
+	verbose := false
+	cmd := NewConvertCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input, "--as-of", "2020-01-01"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Convert command failed: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, `"TotalRainfall": "5.200000000000"`) || strings.Contains(output, "February") {
+		t.Errorf("Expected output truncated to 2020-01-01, got: %s", output)
+	}
+}
+
+func TestConvertCommandInvalidAsOf(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+"IDCJAC0009,066062,2020,1,1,5.2,1,Y\n")
+
+	verbose := false
+	cmd := NewConvertCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input, "--as-of", "01/01/2020"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("Expected error for malformed as-of date")
+	}
+}
+
+func TestConvertCommandFilters(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+`IDCJAC0009,066062,2019,12,31,9.9,1,Y
+IDCJAC0009,066062,2020,1,1,5.2,1,Y
+IDCJAC0009,066062,2020,2,1,12.5,1,Y`)
+
+	verbose := false
+	cmd := NewConvertCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input, "--from", "2020-01-01", "--months", "jan"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Convert command failed: %v", err)
+	}
+
+	output := buf.String()
+	if strings.Contains(output, `"Year": "2019"`) || strings.Contains(output, "February") {
+		t.Errorf("Expected only January 2020 in output, got: %s", output)
+	}
+	if !strings.Contains(output, `"From": "2020-01-01"`) {
+		t.Errorf("Expected filter in metadata, got: %s", output)
+	}
+}
+
+func TestConvertCommandInvalidFilters(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+"IDCJAC0009,066062,2020,1,1,5.2,1,Y\n")
+
+	for _, args := range [][]string{
+		{"--from", "2020/01/01"},
+		{"--to", "soon"},
+		{"--from", "2020-02-01", "--to", "2020-01-01"},
+		{"--years", "19x0"},
+		{"--months", "13"},
+	} {
+		verbose := false
+		cmd := NewConvertCmd(&verbose)
+		cmd.SetArgs(append([]string{"--input", input}, args...))
+
+		var buf bytes.Buffer
+		cmd.SetOut(&buf)
+		cmd.SetErr(&buf)
+
+		if err := cmd.Execute(); err == nil {
+			t.Errorf("Expected error for %v", args)
+		}
+	}
+}
+
+func TestConvertCommandStatistics(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+`IDCJAC0009,066062,2020,1,1,5.2,1,Y
+IDCJAC0009,066062,2020,1,2,0.0,1,Y`)
+
+	verbose := false
+	cmd := NewConvertCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input, "--statistics", "TotalRainfall,DaysWithRainfall"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Convert command failed: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, `"TotalRainfall"`) || !strings.Contains(output, `"DaysWithRainfall"`) {
+		t.Errorf("Expected selected statistics in output, got: %s", output)
+	}
+	if strings.Contains(output, "AverageDailyRainfall") || strings.Contains(output, "MedianDailyRainfall") {
+		t.Errorf("Expected unselected statistics to be omitted, got: %s", output)
+	}
+
+	cmd = NewConvertCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input, "--statistics", "Humidity"})
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+	if err := cmd.Execute(); err == nil {
+		t.Error("Expected error for unknown statistic")
+	}
+}
+
+func TestConvertCommandPrecision(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+`IDCJAC0009,066062,2020,1,1,0.1,1,Y
+IDCJAC0009,066062,2020,1,2,0.2,1,Y
+IDCJAC0009,066062,2020,1,3,0.0,1,Y`)
+
+	verbose := false
+	cmd := NewConvertCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input, "--precision", "bom"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Convert command failed: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, `"TotalRainfall": "0.3"`) || !strings.Contains(output, `"AverageDailyRainfall": "0.100000000"`) {
+		t.Errorf("Expected BOM precision output, got: %s", output)
+	}
+
+	cmd = NewConvertCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input, "--precision", "exact"})
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+	if err := cmd.Execute(); err == nil {
+		t.Error("Expected error for unknown precision")
+	}
+}
+
+func TestConvertCommandMedian(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+`IDCJAC0009,066062,2020,1,1,0.0,1,Y
+IDCJAC0009,066062,2020,1,2,0.0,1,Y
+IDCJAC0009,066062,2020,1,3,4.0,1,Y`)
+
+	verbose := false
+	cmd := NewConvertCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input, "--median", "all"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Convert command failed: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, `"MedianDailyRainfall": "0.000000000000"`) {
+		t.Errorf("Expected median over all days, got: %s", output)
+	}
+	if !strings.Contains(output, `"Mode": "all"`) {
+		t.Errorf("Expected median mode in metadata, got: %s", output)
+	}
+
+	for _, args := range [][]string{
+		{"--median", "dry"},
+		{"--median", "threshold", "--rain-day-threshold", "0"},
+		{"--median", "threshold", "--rain-day-threshold", "0.255"},
+		{"--median", "threshold", "--rain-day-threshold", "wet"},
+	} {
+		cmd = NewConvertCmd(&verbose)
+		cmd.SetArgs(append([]string{"--input", input}, args...))
+		cmd.SetOut(&buf)
+		cmd.SetErr(&buf)
+		if err := cmd.Execute(); err == nil {
+			t.Errorf("Expected error for %v", args)
+		}
+	}
+}
+
+func TestConvertCommandImputation(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+`IDCJAC0009,066062,2020,1,1,2.0,1,Y
+IDCJAC0009,066062,2020,1,2,,,
+IDCJAC0009,066062,2020,1,4,4.0,1,Y`)
+	neighbour := writeTempCSV(t, testCSVHeader+`IDCJAC0009,066037,2020,1,1,1.0,1,Y
+IDCJAC0009,066037,2020,1,2,3.0,1,Y
+IDCJAC0009,066037,2020,1,4,2.0,1,Y`)
+
+	verbose := false
+	cmd := NewConvertCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input, "--impute-neighbour", neighbour, "--impute-climatology", "--precision", "bom", "--as-of", "2020-01-31"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Convert command failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{
+		`"TotalRainfall": "15.0"`,
+		`"ImputedDays": "2"`,
+		`"ImputedRainfall": "9.0"`,
+		`"NeighbourScale": "2.0000"`,
+		`"climatology"`,
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %s, got: %s", expected, output)
+		}
+	}
+
+	cmd = NewConvertCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input, "--impute-neighbour", "missing.csv"})
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+	if err := cmd.Execute(); err == nil {
+		t.Error("Expected error for a missing neighbour file")
+	}
+}
+
+func TestConvertCommandSchema(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+`IDCJAC0009,066062,2020,1,1,0.0,1,Y
+IDCJAC0009,066062,2020,1,2,4.0,1,Y`)
+
+	verbose := false
+	cmd := NewConvertCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input, "--schema", "v2", "--precision", "bom"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Convert command failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{`"schemaVersion": 2`, `"Year": 2020`, `"Month": "2020-01"`, `"TotalRainfall": 4.0`, `"DaysWithRainfall": 1`} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %s, got: %s", expected, output)
+		}
+	}
+
+	cmd = NewConvertCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input, "--schema", "v9"})
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+	if err := cmd.Execute(); err == nil {
+		t.Error("Expected error for an unknown schema version")
+	}
+}
+
+func TestConvertCommandIncludeDaily(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+`IDCJAC0009,066062,2020,1,1,1.2,1,Y
+IDCJAC0009,066062,2020,1,2,,,
+IDCJAC0009,066062,2020,1,3,3.0,2,N`)
+
+	verbose := false
+	cmd := NewConvertCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input, "--include-daily", "--precision", "bom"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Convert command failed: %v", err)
+	}
+
+	var out bom.WeatherData
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	daily := out.WeatherDataForYear[0].MonthlyAggregates.WeatherDataForMonth[0].DailyRecords
+	if len(daily) != 3 {
+		t.Fatalf("Expected 3 daily records, got %+v", daily)
+	}
+	if daily[1].Rainfall != nil || *daily[2].Rainfall != "3.0" || daily[2].Quality != "N" || daily[2].Period != "2" {
+		t.Errorf("Unexpected daily records %+v", daily)
+	}
+}
+
+func TestConvertCommandFormat(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+`IDCJAC0009,066062,2020,1,1,1.2,1,Y
+IDCJAC0009,066062,2020,2,1,3.0,1,Y`)
+
+	verbose := false
+	cmd := NewConvertCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input, "--format", "csv-months", "--precision", "bom"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Convert command failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "Year,Month,") || !strings.HasPrefix(lines[2], "2020,February,") {
+		t.Errorf("Expected a monthly CSV summary, got: %s", buf.String())
+	}
+
+	for _, args := range [][]string{
+		{"--format", "yaml"},
+		{"--format", "csv-years", "--schema", "v2"},
+		{"--format", "csv-years", "--include-daily"},
+	} {
+		cmd = NewConvertCmd(&verbose)
+		cmd.SetArgs(append([]string{"--input", input}, args...))
+		cmd.SetOut(&buf)
+		cmd.SetErr(&buf)
+		if err := cmd.Execute(); err == nil {
+			t.Errorf("Expected error for %v", args)
+		}
+	}
+}
+
+func TestConvertCommandNDJSON(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+`IDCJAC0009,066062,2020,1,1,1.2,1,Y
+IDCJAC0009,066062,2020,2,1,3.0,1,Y`)
+
+	verbose := false
+	cmd := NewConvertCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input, "--format", "ndjson", "--schema", "v2", "--include-daily"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Convert command failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 2 month lines and a year line, got: %s", buf.String())
+	}
+	var year struct {
+		Type    string
+		Station string
+		Year    int
+	}
+	if err := json.Unmarshal([]byte(lines[2]), &year); err != nil {
+		t.Fatalf("Year line is not valid JSON: %v", err)
+	}
+	if year.Type != "year" || year.Station != "066062" || year.Year != 2020 {
+		t.Errorf("Unexpected year line %s", lines[2])
+	}
+	if !strings.Contains(lines[0], `"DailyRecords":[`) {
+		t.Errorf("Expected daily records on the month lines, got %s", lines[0])
+	}
+}
+
+func TestConvertCommandXML(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+`IDCJAC0009,066062,2020,1,1,1.2,1,Y
+IDCJAC0009,066062,2020,2,1,3.0,1,Y`)
+	xsdFile := t.TempDir() + "/weatherdata.xsd"
+
+	verbose := false
+	cmd := NewConvertCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input, "--format", "xml", "--include-daily", "--xsd", xsdFile})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Convert command failed: %v", err)
+	}
+
+	var data bom.WeatherDataXML
+	if err := xml.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatalf("Output is not valid XML: %v", err)
+	}
+	if data.Station != "066062" || len(data.WeatherDataForYear) != 1 {
+		t.Fatalf("Unexpected output %+v", data)
+	}
+	months := data.WeatherDataForYear[0].MonthlyAggregates.WeatherDataForMonth
+	if len(months) != 2 || months[1].Month != "February" || months[0].DailyRecords == nil {
+		t.Errorf("Unexpected months %+v", months)
+	}
+
+	xsd, err := os.ReadFile(xsdFile)
+	if err != nil {
+		t.Fatalf("Expected the XML schema to be written: %v", err)
+	}
+	if !bytes.Equal(xsd, bom.WeatherDataXSD()) {
+		t.Error("Expected the written schema to match WeatherDataXSD")
+	}
+
+	for _, args := range [][]string{
+		{"--format", "xml", "--schema", "v2"},
+		{"--xsd", xsdFile},
+	} {
+		cmd = NewConvertCmd(&verbose)
+		cmd.SetArgs(append([]string{"--input", input}, args...))
+		cmd.SetOut(&buf)
+		cmd.SetErr(&buf)
+		if err := cmd.Execute(); err == nil {
+			t.Errorf("Expected error for %v", args)
+		}
+	}
+}
+
+func TestConvertCommandTable(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+`IDCJAC0009,066062,2020,1,1,1.23,1,Y
+IDCJAC0009,066062,2020,2,1,3.0,1,Y`)
+
+	verbose := false
+	cmd := NewConvertCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input, "--format", "table", "--width", "50", "--as-of", "2020-02-29"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Convert command failed: %v", err)
+	}
+
+	output := buf.String()
+	// Tables default to BOM precision
+	for _, expected := range []string{"Rainfall at station 066062", "2020       4.2", "January        1.2"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, output)
+		}
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if utf8.RuneCountInString(line) > 50 {
+			t.Errorf("Expected lines to fit 50 columns, got %q", line)
+		}
+	}
+
+	cmd = NewConvertCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input, "--format", "markdown", "--precision", "2", "--as-of", "2020-02-29"})
+	buf.Reset()
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Convert command failed: %v", err)
+	}
+	if output := buf.String(); !strings.Contains(output, "# Rainfall at station 066062") || !strings.Contains(output, "| 2020 |     4.23 |") {
+		t.Errorf("Unexpected Markdown output:\n%s", output)
+	}
+
+	for _, args := range [][]string{
+		{"--width", "80"},
+		{"--format", "markdown", "--width", "80"},
+		{"--format", "table", "--width", "-1"},
+	} {
+		cmd = NewConvertCmd(&verbose)
+		cmd.SetArgs(append([]string{"--input", input}, args...))
+		cmd.SetOut(&buf)
+		cmd.SetErr(&buf)
+		if err := cmd.Execute(); err == nil {
+			t.Errorf("Expected error for %v", args)
+		}
+	}
+}
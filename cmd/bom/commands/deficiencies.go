@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/terem/bom/internal/bom"
+)
+
+func NewDeficienciesCmd(verbose *bool) *cobra.Command {
+	var inputFile string
+	var outputFile string
+	var asOf string
+	opts := bom.DefaultDeficiencyOptions()
+
+	cmd := &cobra.Command{
+		Use:   "deficiencies",
+		Short: "Detect serious and severe rainfall deficiencies",
+		Long: `Detect rainfall deficiency periods in a Bureau of Meteorology (BOM) CSV file.
+
+The deficiencies command totals rainfall over every run of --duration months,
+three or more, and ranks each total against all totals of the same length ending in the
+same calendar month. Totals in the lowest 10% of the record are serious
+deficiencies and those in the lowest 5% are severe, as defined by BOM.
+Overlapping periods are reduced to the one with the lowest rank. Months
+where fewer than --min-completeness percent of days have a reading are
+treated as missing, and months that have not finished are excluded.
+
+Example:
+  bom deficiencies -i weather.csv -o deficiencies.json
+  bom deficiencies -i weather.csv -d 3 -d 6 -d 12 -d 24`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			processor := bom.NewProcessorWithVerbose(*verbose)
+
+			for _, d := range opts.Durations {
+				if d < bom.MinDeficiencyMonths {
+					return fmt.Errorf("--duration must be at least %d months, as BOM assesses deficiencies over three months or longer, got %d", bom.MinDeficiencyMonths, d)
+				}
+			}
+			if asOf != "" {
+				clock, err := bom.ParseAsOfDate(asOf)
+				if err != nil {
+					return err
+				}
+				opts.Clock = clock
+			}
+
+			inFile, err := os.Open(inputFile)
+			if err != nil {
+				return fmt.Errorf("failed to open input file %s: %w", inputFile, err)
+			}
+			defer inFile.Close()
+
+			output, outputName, closeOutput, err := openOutput(cmd, outputFile)
+			if err != nil {
+				return err
+			}
+			defer closeOutput()
+
+			if err := processor.ProcessDeficiencies(inFile, output, opts); err != nil {
+				return fmt.Errorf("deficiency detection failed: %w", err)
+			}
+
+			if *verbose {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Successfully wrote deficiencies for %s to %s\n", inputFile, outputName)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input CSV file path (required)")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output JSON file path (defaults to stdout)")
+	cmd.Flags().IntSliceVarP(&opts.Durations, "duration", "d", opts.Durations, "Accumulation length in months, 3 or more (repeatable)")
+	cmd.Flags().Float64Var(&opts.MinCompleteness, "min-completeness", opts.MinCompleteness, "Minimum percentage of days recorded for a month to be used")
+	cmd.Flags().StringVar(&asOf, "as-of", "", "Treat this date (YYYY-MM-DD) as today when excluding unfinished months")
+	cmd.MarkFlagRequired("input")
+
+	return cmd
+}
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDeficienciesCommandHelp(t *testing.T) {
+	verbose := false
+	cmd := NewDeficienciesCmd(&verbose)
+	cmd.SetArgs([]string{"--help"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Deficiencies command help failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{"serious", "severe", "--duration", "--min-completeness"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain '%s', got: %s", expected, output)
+		}
+	}
+}
+
+func TestDeficienciesCommandSampleData(t *testing.T) {
+	verbose := false
+	cmd := NewDeficienciesCmd(&verbose)
+	cmd.SetArgs([]string{"--input", "../../../test_data/IDCJAC0009_066062_1800_Data.csv", "--as-of", "2020-01-01"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Deficiencies command failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{`"StartDate": "2019-10-01"`, `"Severity": "severe"`, `"Severity": "serious"`} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %s", expected)
+		}
+	}
+}
+
+func TestDeficienciesCommandInvalidDuration(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+"IDCJAC0009,066062,2020,1,1,5.2,1,Y\n")
+
+	verbose := false
+	for _, duration := range []string{"0", "2"} {
+		cmd := NewDeficienciesCmd(&verbose)
+		cmd.SetArgs([]string{"--input", input, "--duration", duration})
+
+		var buf bytes.Buffer
+		cmd.SetOut(&buf)
+		cmd.SetErr(&buf)
+
+		if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "at least 3 months") {
+			t.Errorf("Expected error for a %s-month duration, got %v", duration, err)
+		}
+	}
+}
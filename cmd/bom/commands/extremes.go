@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/terem/bom/internal/bom"
+)
+
+func NewExtremesCmd(verbose *bool) *cobra.Command {
+	var inputFile string
+	var outputFile string
+	var maxMissing int
+
+	cmd := &cobra.Command{
+		Use:   "extremes",
+		Short: "Report maximum 1, 3, 5 and 7-day rainfall per year",
+		Long: `Report the maximum N-day rainfall accumulations from a Bureau of Meteorology (BOM) CSV file.
+
+The extremes command outputs the Rx1day, Rx3day, Rx5day and Rx7day indices
+for each year in JSON format, with the total and the start and end date of
+each window. A window is attributed to the year in which it starts, and windows
+with more missing days than --max-missing are ignored.
+
+Example:
+  bom extremes -i weather.csv -o extremes.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			processor := bom.NewProcessorWithVerbose(*verbose)
+
+			inFile, err := os.Open(inputFile)
+			if err != nil {
+				return fmt.Errorf("failed to open input file %s: %w", inputFile, err)
+			}
+			defer inFile.Close()
+
+			output, outputName, closeOutput, err := openOutput(cmd, outputFile)
+			if err != nil {
+				return err
+			}
+			defer closeOutput()
+
+			if err := processor.ProcessExtremes(inFile, output, maxMissing); err != nil {
+				return fmt.Errorf("extremes failed: %w", err)
+			}
+
+			if *verbose {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Successfully wrote extremes for %s to %s\n", inputFile, outputName)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input CSV file path (required)")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output JSON file path (defaults to stdout)")
+	cmd.Flags().IntVar(&maxMissing, "max-missing", 0, "Maximum missing days allowed in a window")
+	cmd.MarkFlagRequired("input")
+
+	return cmd
+}
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExtremesCommandHelp(t *testing.T) {
+	verbose := false
+	cmd := NewExtremesCmd(&verbose)
+	cmd.SetArgs([]string{"--help"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Extremes command help failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{"Rx1day, Rx3day, Rx5day and Rx7day", "--input", "--max-missing"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain '%s', got: %s", expected, output)
+		}
+	}
+}
+
+func TestExtremesCommandValidCSV(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+`IDCJAC0009,066062,2020,1,1,5.2,1,Y
+IDCJAC0009,066062,2020,1,2,0.0,1,Y
+IDCJAC0009,066062,2020,1,3,12.5,1,Y`)
+
+	verbose := false
+	cmd := NewExtremesCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Extremes command failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{`"Year": "2020"`, `"TotalRainfall": "12.5"`, `"StartDate": "2020-01-03"`} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %s, got: %s", expected, output)
+		}
+	}
+}
+
+func TestExtremesCommandInvalidFile(t *testing.T) {
+	verbose := false
+	cmd := NewExtremesCmd(&verbose)
+	cmd.SetArgs([]string{"--input", "nonexistent_file.csv"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("Expected error for nonexistent file")
+	}
+}
@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/terem/bom/internal/bom"
+)
+
+func NewReportCmd(verbose *bool) *cobra.Command {
+	var inputFile string
+	var outputFile string
+	var asOf string
+	var inputFormat string
+	opts := bom.DefaultReportOptions()
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Write an HTML rainfall report with charts",
+		Long: `Write a rainfall report for a Bureau of Meteorology (BOM) CSV file.
+
+The report command writes a single HTML file with a station summary, a chart
+of annual totals, a chart of the mean rainfall of each calendar month, a
+table comparing the months of the latest year with that climatology and a
+list of the wettest days. The charts are inline SVG and the file uses no
+scripts or external resources, so it can be viewed offline and attached to
+an email.
+
+Years and months with fewer than --min-completeness percent of days recorded
+are shown as incomplete and left out of the long-term means, as is the
+current year until it has finished. Figures use BOM's published precision.
+Use --year to report on an earlier year, and --as-of to treat a date as
+today.
+
+Use --input-format json to report from the v1 JSON written by convert
+instead of the CSV. Its years, completeness and climatology are used as
+they were aggregated, so --min-completeness does not apply, and the wettest
+days are listed only when it was written with --include-daily.
+
+Example:
+  bom report -i weather.csv -o report.html
+  bom report -i weather.csv -o report.html --year 2019 --title "Sydney rainfall"
+  bom report -i weather.csv -o report.html --wettest-days 20 --min-completeness 80
+  bom report -i weather.json --input-format json -o report.html`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.WettestDays < 0 {
+				return fmt.Errorf("--wettest-days must not be negative, got %d", opts.WettestDays)
+			}
+			if opts.Aggregator.MinCompleteness < 0 || opts.Aggregator.MinCompleteness > 100 {
+				return fmt.Errorf("--min-completeness must be between 0 and 100, got %g", opts.Aggregator.MinCompleteness)
+			}
+			if asOf != "" {
+				clock, err := bom.ParseAsOfDate(asOf)
+				if err != nil {
+					return err
+				}
+				opts.Aggregator.Clock = clock
+			}
+
+			processor := bom.NewProcessorWithOptions(bom.ProcessorOptions{
+				Verbose:     *verbose,
+				Aggregator:  bom.DefaultAggregatorOptions(),
+				InputFormat: inputFormat,
+			})
+
+			inFile, err := os.Open(inputFile)
+			if err != nil {
+				return fmt.Errorf("failed to open input file %s: %w", inputFile, err)
+			}
+			defer inFile.Close()
+
+			output, outputName, closeOutput, err := openOutput(cmd, outputFile)
+			if err != nil {
+				return err
+			}
+			defer closeOutput()
+
+			if err := processor.ProcessReport(inFile, output, opts); err != nil {
+				return fmt.Errorf("report failed: %w", err)
+			}
+
+			if *verbose {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Successfully wrote report for %s to %s\n", inputFile, outputName)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input CSV or JSON file path (required)")
+	cmd.Flags().StringVar(&inputFormat, "input-format", bom.InputCSV, "Input format: csv, or json for the v1 output of convert")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output HTML file path (defaults to stdout)")
+	cmd.Flags().StringVar(&opts.Title, "title", "", "Report heading (defaults to the station number)")
+	cmd.Flags().IntVar(&opts.Year, "year", 0, "Year of the monthly table (defaults to the latest year)")
+	cmd.Flags().IntVar(&opts.WettestDays, "wettest-days", opts.WettestDays, "Number of wettest days listed")
+	cmd.Flags().Float64Var(&opts.Aggregator.MinCompleteness, "min-completeness", opts.Aggregator.MinCompleteness, "Minimum percentage of days recorded for a year or month to be complete")
+	cmd.Flags().StringVar(&asOf, "as-of", "", "Treat this date (YYYY-MM-DD) as today when excluding future months")
+	cmd.MarkFlagRequired("input")
+
+	return cmd
+}
@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReportCommandHelp(t *testing.T) {
+	verbose := false
+	cmd := NewReportCmd(&verbose)
+	cmd.SetArgs([]string{"--help"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Report command help failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{"single HTML file", "--input", "--year", "--wettest-days", "--min-completeness", "--input-format"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain '%s', got: %s", expected, output)
+		}
+	}
+}
+
+func TestReportCommandValidCSV(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+`IDCJAC0009,066062,2020,1,1,5.2,1,Y
+IDCJAC0009,066062,2020,1,2,0.0,1,Y
+IDCJAC0009,066062,2020,1,3,12.5,1,Y`)
+	output := filepath.Join(t.TempDir(), "report.html")
+
+	verbose := false
+	cmd := NewReportCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input, "--output", output, "--as-of", "2020-01-03", "--title", "Test station"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Report command failed: %v", err)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("Expected the report to be written: %v", err)
+	}
+	html := string(data)
+	for _, expected := range []string{"<h1>Test station</h1>", "<dd>066062</dd>", "<svg ", "<td>2020-01-03</td><td class=\"number\">12.5</td>"} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("Expected report to contain %s, got: %s", expected, html)
+		}
+	}
+}
+
+func TestReportCommandInvalidFlags(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+`IDCJAC0009,066062,2020,1,1,5.2,1,Y`)
+
+	for _, args := range [][]string{
+		{"--input", "nonexistent_file.csv"},
+		{"--input", input, "--wettest-days", "-1"},
+		{"--input", input, "--min-completeness", "101"},
+		{"--input", input, "--as-of", "2020-13-01"},
+		{"--input", input, "--year", "1999"},
+	} {
+		verbose := false
+		cmd := NewReportCmd(&verbose)
+		cmd.SetArgs(args)
+
+		var buf bytes.Buffer
+		cmd.SetOut(&buf)
+		cmd.SetErr(&buf)
+
+		if err := cmd.Execute(); err == nil {
+			t.Errorf("Expected error for %v", args)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/terem/bom/internal/bom"
+)
+
+func NewReturnPeriodsCmd(verbose *bool) *cobra.Command {
+	var inputFile string
+	var outputFile string
+	opts := bom.DefaultReturnPeriodOptions()
+
+	cmd := &cobra.Command{
+		Use:   "return-periods",
+		Short: "Estimate rainfall depths for average recurrence intervals",
+		Long: `Estimate rainfall depths for average recurrence intervals from a Bureau of Meteorology (BOM) CSV file.
+
+The return-periods command extracts the annual maximum daily (or N-day)
+rainfall, fits Gumbel and GEV distributions by the method of L-moments and
+reports the estimated depth for each average recurrence interval (ARI) with
+bootstrap confidence intervals. Years where fewer than --min-completeness
+percent of days have a reading are excluded from the fit.
+
+Example:
+  bom return-periods -i weather.csv --duration 1 --duration 3 -o ari.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			processor := bom.NewProcessorWithVerbose(*verbose)
+
+			if opts.Confidence <= 0 || opts.Confidence >= 1 {
+				return fmt.Errorf("confidence must be between 0 and 1, got %g", opts.Confidence)
+			}
+			for _, ari := range opts.ARIs {
+				if ari <= 1 {
+					return fmt.Errorf("average recurrence interval must be greater than 1 year, got %g", ari)
+				}
+			}
+
+			inFile, err := os.Open(inputFile)
+			if err != nil {
+				return fmt.Errorf("failed to open input file %s: %w", inputFile, err)
+			}
+			defer inFile.Close()
+
+			output, outputName, closeOutput, err := openOutput(cmd, outputFile)
+			if err != nil {
+				return err
+			}
+			defer closeOutput()
+
+			if err := processor.ProcessReturnPeriods(inFile, output, opts); err != nil {
+				return fmt.Errorf("return period analysis failed: %w", err)
+			}
+
+			if *verbose {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Successfully wrote return periods for %s to %s\n", inputFile, outputName)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input CSV file path (required)")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output JSON file path (defaults to stdout)")
+	cmd.Flags().IntSliceVarP(&opts.Durations, "duration", "d", opts.Durations, "Accumulation duration in days (repeatable)")
+	cmd.Flags().Float64SliceVar(&opts.ARIs, "ari", opts.ARIs, "Average recurrence intervals in years")
+	cmd.Flags().Float64Var(&opts.MinCompleteness, "min-completeness", opts.MinCompleteness, "Minimum percentage of days recorded for a year to be used")
+	cmd.Flags().Float64Var(&opts.Confidence, "confidence", opts.Confidence, "Confidence level of the intervals")
+	cmd.Flags().IntVar(&opts.Resamples, "resamples", opts.Resamples, "Number of bootstrap resamples")
+	cmd.Flags().Int64Var(&opts.Seed, "seed", opts.Seed, "Random seed for the bootstrap")
+	cmd.MarkFlagRequired("input")
+
+	return cmd
+}
@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReturnPeriodsCommandHelp(t *testing.T) {
+	verbose := false
+	cmd := NewReturnPeriodsCmd(&verbose)
+	cmd.SetArgs([]string{"--help"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Return periods command help failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{"average recurrence intervals", "--duration", "--min-completeness", "--confidence"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain '%s', got: %s", expected, output)
+		}
+	}
+}
+
+func TestReturnPeriodsCommandSampleData(t *testing.T) {
+	verbose := false
+	cmd := NewReturnPeriodsCmd(&verbose)
+	cmd.SetArgs([]string{"--input", "../../../test_data/IDCJAC0009_066062_1800_Data.csv", "--resamples", "50"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Return periods command failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{`"Distribution": "Gumbel"`, `"Distribution": "GEV"`, `"ARI": "100"`} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %s", expected)
+		}
+	}
+}
+
+func TestReturnPeriodsCommandInvalidConfidence(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+"IDCJAC0009,066062,2020,1,1,5.2,1,Y\n")
+
+	verbose := false
+	cmd := NewReturnPeriodsCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input, "--confidence", "1.5"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("Expected error for confidence outside (0, 1)")
+	}
+}
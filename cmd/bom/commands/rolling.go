@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/terem/bom/internal/bom"
+)
+
+func NewRollingCmd(verbose *bool) *cobra.Command {
+	var inputFile string
+	var outputFile string
+	var windows []int
+	var maxMissing int
+
+	cmd := &cobra.Command{
+		Use:   "rolling",
+		Short: "Export rolling rainfall totals as a daily CSV time series",
+		Long: `Export rolling rainfall totals from a Bureau of Meteorology (BOM) CSV file.
+
+The rolling command computes the rainfall accumulated over trailing windows
+(30, 90 and 365 days by default) for every day in the record and writes them
+as a CSV time series with one column per window. Windows that extend before
+the start of the record, or contain more missing days than --max-missing,
+are left blank.
+
+Example:
+  bom rolling -i weather.csv -o rolling.csv --window 30 --window 90`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			processor := bom.NewProcessorWithVerbose(*verbose)
+
+			inFile, err := os.Open(inputFile)
+			if err != nil {
+				return fmt.Errorf("failed to open input file %s: %w", inputFile, err)
+			}
+			defer inFile.Close()
+
+			output, outputName, closeOutput, err := openOutput(cmd, outputFile)
+			if err != nil {
+				return err
+			}
+			defer closeOutput()
+
+			if err := processor.ProcessRollingTotals(inFile, output, windows, maxMissing); err != nil {
+				return fmt.Errorf("rolling totals failed: %w", err)
+			}
+
+			if *verbose {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Successfully wrote rolling totals for %s to %s\n", inputFile, outputName)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input CSV file path (required)")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output CSV file path (defaults to stdout)")
+	cmd.Flags().IntSliceVarP(&windows, "window", "w", []int{30, 90, 365}, "Window length in days (repeatable)")
+	cmd.Flags().IntVar(&maxMissing, "max-missing", 0, "Maximum missing days allowed in a window")
+	cmd.MarkFlagRequired("input")
+
+	return cmd
+}
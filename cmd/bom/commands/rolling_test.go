@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRollingCommandHelp(t *testing.T) {
+	verbose := false
+	cmd := NewRollingCmd(&verbose)
+	cmd.SetArgs([]string{"--help"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Rolling command help failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{"Export rolling rainfall totals", "--window", "--max-missing"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain '%s', got: %s", expected, output)
+		}
+	}
+}
+
+func TestRollingCommandValidCSV(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+`IDCJAC0009,066062,2020,1,1,5.2,1,Y
+IDCJAC0009,066062,2020,1,2,0.0,1,Y
+IDCJAC0009,066062,2020,1,3,12.5,1,Y`)
+
+	verbose := false
+	cmd := NewRollingCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input, "--window", "2"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Rolling command failed: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.HasPrefix(output, "Date,Rainfall2Day\n") {
+		t.Errorf("Expected CSV header, got: %s", output)
+	}
+	if !strings.Contains(output, "2020-01-03,12.5") {
+		t.Errorf("Expected 2-day total for 2020-01-03, got: %s", output)
+	}
+}
+
+func TestRollingCommandMissingInput(t *testing.T) {
+	verbose := false
+	cmd := NewRollingCmd(&verbose)
+	cmd.SetArgs([]string{})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("Expected error for missing input file")
+	}
+}
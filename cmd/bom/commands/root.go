@@ -29,6 +29,18 @@ The tool reads BOM CSV files and outputs aggregated weather data including:
 
 	rootCmd.AddCommand(NewConvertCmd(&verbose))
 	rootCmd.AddCommand(NewValidateCmd(&verbose))
+	rootCmd.AddCommand(NewUpdateCmd(&verbose))
+	rootCmd.AddCommand(NewRollingCmd(&verbose))
+	rootCmd.AddCommand(NewExtremesCmd(&verbose))
+	rootCmd.AddCommand(NewReturnPeriodsCmd(&verbose))
+	rootCmd.AddCommand(NewSPICmd(&verbose))
+	rootCmd.AddCommand(NewDeficienciesCmd(&verbose))
+	rootCmd.AddCommand(NewTrendCmd(&verbose))
+	rootCmd.AddCommand(NewCompareCmd(&verbose))
+	rootCmd.AddCommand(NewReportCmd(&verbose))
+	rootCmd.AddCommand(NewChartCmd(&verbose))
+	rootCmd.AddCommand(NewSchemaCmd(&verbose))
+	rootCmd.AddCommand(NewCheckOutputCmd(&verbose))
 	rootCmd.AddCommand(NewVersionCmd())
 
 	return rootCmd
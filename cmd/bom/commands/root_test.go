@@ -2,6 +2,7 @@ package commands
 
 import (
 	"bytes"
+	"os"
 	"testing"
 )
 
@@ -109,3 +110,21 @@ func containsSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+// writeTempCSV writes CSV content to a temporary file that is removed when the test ends
+func writeTempCSV(t *testing.T, content string) string {
+	t.Helper()
+	tmpFile, err := os.CreateTemp("", "test_cmd_*.csv")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	t.Cleanup(func() { os.Remove(tmpFile.Name()) })
+	defer tmpFile.Close()
+
+	if _, err := tmpFile.WriteString(content); err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+	return tmpFile.Name()
+}
+
+const testCSVHeader = "Product code,Bureau of Meteorology station number,Year,Month,Day,Rainfall amount (millimetres),Period over which rainfall was measured (days),Quality\n"
@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/terem/bom/internal/bom"
+)
+
+func NewSchemaCmd(verbose *bool) *cobra.Command {
+	var outputFile string
+	var schema string
+
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Write the JSON Schema of convert's JSON output",
+		Long: `Write the JSON Schema of the JSON written by bom convert.
+
+The schema is generated from the output types, so it always matches the
+output of this build: fields that are always written are required, values
+that may be unavailable are optional or nullable, and unknown fields are
+rejected. Use --schema to choose the schema of v1 (all strings, the
+default) or v2 (typed) output. Schemas use JSON Schema draft 2020-12.
+
+Use bom check-output to validate a file against the schema.
+
+Example:
+  bom schema -o weatherdata.schema.json
+  bom schema --schema v2 -o weatherdata-v2.schema.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			schemaVersion, err := bom.ParseSchemaVersion(schema)
+			if err != nil {
+				return err
+			}
+
+			output, outputName, closeOutput, err := openOutput(cmd, outputFile)
+			if err != nil {
+				return err
+			}
+			defer closeOutput()
+
+			if _, err := output.Write(bom.WeatherDataJSONSchema(schemaVersion)); err != nil {
+				return fmt.Errorf("failed to write JSON Schema: %w", err)
+			}
+
+			if *verbose {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Successfully wrote the %s JSON Schema to %s\n", schemaVersion, outputName)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file path (defaults to stdout)")
+	cmd.Flags().StringVar(&schema, "schema", string(bom.SchemaV1), "Output schema version: v1 (all strings) or v2 (typed)")
+
+	return cmd
+}
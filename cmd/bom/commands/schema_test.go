@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSchemaCommandHelp(t *testing.T) {
+	verbose := false
+	cmd := NewSchemaCmd(&verbose)
+	cmd.SetArgs([]string{"--help"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Schema command help failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{"JSON Schema", "--schema", "check-output", "2020-12"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain '%s', got: %s", expected, output)
+		}
+	}
+}
+
+func TestSchemaCommand(t *testing.T) {
+	for _, schema := range []string{"v1", "v2"} {
+		output := filepath.Join(t.TempDir(), "schema.json")
+
+		verbose := false
+		cmd := NewSchemaCmd(&verbose)
+		cmd.SetArgs([]string{"--output", output, "--schema", schema})
+
+		var buf bytes.Buffer
+		cmd.SetOut(&buf)
+		cmd.SetErr(&buf)
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("Schema command failed for %s: %v", schema, err)
+		}
+
+		data, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatalf("Expected the schema to be written: %v", err)
+		}
+		var doc map[string]any
+		if err := json.Unmarshal(data, &doc); err != nil {
+			t.Fatalf("Expected the %s schema to be JSON: %v", schema, err)
+		}
+		if doc["title"] != "BOM weather data, schema "+schema {
+			t.Errorf("Expected the %s schema, got %v", schema, doc["title"])
+		}
+	}
+}
+
+func TestSchemaCommandInvalidSchema(t *testing.T) {
+	verbose := false
+	cmd := NewSchemaCmd(&verbose)
+	cmd.SetArgs([]string{"--schema", "v9"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err == nil {
+		t.Error("Expected an error for an unknown schema version")
+	}
+}
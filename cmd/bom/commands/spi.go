@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/terem/bom/internal/bom"
+)
+
+func NewSPICmd(verbose *bool) *cobra.Command {
+	var inputFile string
+	var outputFile string
+	var baseline string
+	var asOf string
+	opts := bom.DefaultSPIOptions()
+
+	cmd := &cobra.Command{
+		Use:   "spi",
+		Short: "Compute the Standardised Precipitation Index",
+		Long: `Compute the Standardised Precipitation Index (SPI) from a Bureau of Meteorology (BOM) CSV file.
+
+The spi command accumulates monthly rainfall over --scale months, fits a
+gamma distribution to each calendar month's accumulations over the baseline
+period, with the probability of no rain handled separately, and reports the
+SPI of every month with its drought category. Months where fewer than
+--min-completeness percent of days have a reading are treated as missing,
+as is any accumulation that includes them. Months that have not finished
+are excluded.
+
+Example:
+  bom spi -i weather.csv --scale 3 -o spi3.json
+  bom spi -i weather.csv --scale 12 --baseline 1961-1990`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			processor := bom.NewProcessorWithVerbose(*verbose)
+
+			if opts.Scale < 1 {
+				return fmt.Errorf("scale must be at least 1 month, got %d", opts.Scale)
+			}
+			if baseline != "" {
+				years, err := bom.ParseYears(baseline)
+				if err != nil {
+					return fmt.Errorf("invalid --baseline: %w", err)
+				}
+				opts.BaselineYears = years
+			}
+			if asOf != "" {
+				clock, err := bom.ParseAsOfDate(asOf)
+				if err != nil {
+					return err
+				}
+				opts.Clock = clock
+			}
+
+			inFile, err := os.Open(inputFile)
+			if err != nil {
+				return fmt.Errorf("failed to open input file %s: %w", inputFile, err)
+			}
+			defer inFile.Close()
+
+			output, outputName, closeOutput, err := openOutput(cmd, outputFile)
+			if err != nil {
+				return err
+			}
+			defer closeOutput()
+
+			if err := processor.ProcessSPI(inFile, output, opts); err != nil {
+				return fmt.Errorf("SPI calculation failed: %w", err)
+			}
+
+			if *verbose {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Successfully wrote SPI-%d for %s to %s\n", opts.Scale, inputFile, outputName)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input CSV file path (required)")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output JSON file path (defaults to stdout)")
+	cmd.Flags().IntVarP(&opts.Scale, "scale", "s", opts.Scale, "Number of months accumulated, e.g. 1, 3, 6 or 12")
+	cmd.Flags().StringVar(&baseline, "baseline", "", "Years used to fit the distributions, e.g. 1961-1990 (defaults to the whole record)")
+	cmd.Flags().Float64Var(&opts.MinCompleteness, "min-completeness", opts.MinCompleteness, "Minimum percentage of days recorded for a month to be used")
+	cmd.Flags().IntVar(&opts.MinSamples, "min-samples", opts.MinSamples, "Minimum number of baseline accumulations needed to fit a calendar month")
+	cmd.Flags().StringVar(&asOf, "as-of", "", "Treat this date (YYYY-MM-DD) as today when excluding unfinished months")
+	cmd.MarkFlagRequired("input")
+
+	return cmd
+}
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSPICommandHelp(t *testing.T) {
+	verbose := false
+	cmd := NewSPICmd(&verbose)
+	cmd.SetArgs([]string{"--help"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("SPI command help failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{"Standardised Precipitation Index", "--scale", "--baseline", "--min-completeness"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain '%s', got: %s", expected, output)
+		}
+	}
+}
+
+func TestSPICommandSampleData(t *testing.T) {
+	verbose := false
+	cmd := NewSPICmd(&verbose)
+	cmd.SetArgs([]string{"--input", "../../../test_data/IDCJAC0009_066062_1800_Data.csv", "--scale", "3", "--baseline", "1961-1990", "--as-of", "2020-01-01"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("SPI command failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{`"Scale": "3"`, `"BaselineYears": [`, `"Category": "Extremely dry"`} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %s", expected)
+		}
+	}
+	if strings.Contains(output, `"Year": "2020"`) {
+		t.Error("Expected months after the as-of date to be excluded")
+	}
+}
+
+func TestSPICommandInvalidOptions(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+"IDCJAC0009,066062,2020,1,1,5.2,1,Y\n")
+
+	for _, args := range [][]string{
+		{"--scale", "0"},
+		{"--baseline", "1990-1961"},
+		{"--as-of", "tomorrow"},
+	} {
+		verbose := false
+		cmd := NewSPICmd(&verbose)
+		cmd.SetArgs(append([]string{"--input", input}, args...))
+
+		var buf bytes.Buffer
+		cmd.SetOut(&buf)
+		cmd.SetErr(&buf)
+
+		if err := cmd.Execute(); err == nil {
+			t.Errorf("Expected error for %v", args)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package commands
+
+import (
+	"io"
+	"os"
+	"strconv"
+)
+
+// outputWidth returns the width of the terminal that w writes to, then the
+// COLUMNS environment variable, or zero when neither is known
+func outputWidth(w io.Writer) int {
+	if f, ok := w.(*os.File); ok {
+		if width := terminalWidth(f); width > 0 {
+			return width
+		}
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return 0
+}
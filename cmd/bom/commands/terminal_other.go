@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package commands
+
+import "os"
+
+// terminalWidth returns zero, as terminal sizes are only read on Linux and
+// macOS
+func terminalWidth(f *os.File) int {
+	return 0
+}
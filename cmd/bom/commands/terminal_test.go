@@ -0,0 +1,33 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOutputWidth(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "out.txt"))
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	defer file.Close()
+
+	t.Setenv("COLUMNS", "")
+	if width := outputWidth(&bytes.Buffer{}); width != 0 {
+		t.Errorf("Expected no width for a buffer, got %d", width)
+	}
+	if width := outputWidth(file); width != 0 {
+		t.Errorf("Expected no width for a regular file, got %d", width)
+	}
+
+	t.Setenv("COLUMNS", "132")
+	if width := outputWidth(file); width != 132 {
+		t.Errorf("Expected the width from COLUMNS, got %d", width)
+	}
+	t.Setenv("COLUMNS", "wide")
+	if width := outputWidth(&bytes.Buffer{}); width != 0 {
+		t.Errorf("Expected an invalid COLUMNS to be ignored, got %d", width)
+	}
+}
@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/terem/bom/internal/bom"
+)
+
+func NewTrendCmd(verbose *bool) *cobra.Command {
+	var inputFile string
+	var outputFile string
+	var from string
+	var to string
+	var asOf string
+	opts := bom.DefaultTrendOptions()
+
+	cmd := &cobra.Command{
+		Use:   "trend",
+		Short: "Test annual and monthly rainfall for trends",
+		Long: `Test rainfall series from a Bureau of Meteorology (BOM) CSV file for trends.
+
+The trend command aggregates the record into years and tests the annual
+total, the number of days with rainfall, the maximum daily fall and the
+total of each calendar month. Each series is fitted by ordinary least
+squares and tested with the Mann-Kendall test, and Sen's slope is reported
+as a robust estimate. Slopes are given per decade and trends with a p-value
+below --significance are marked significant.
+
+Years and months where fewer than --min-completeness percent of days have a
+reading are excluded, including years only partly inside --from and --to.
+The current year and month, or those of --as-of, are left out until they
+have finished.
+
+Example:
+  bom trend -i weather.csv -o trend.json
+  bom trend -i weather.csv --from 1910-01-01 --to 2019-12-31`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			processor := bom.NewProcessorWithVerbose(*verbose)
+
+			var err error
+			if from != "" {
+				if opts.From, err = time.Parse("2006-01-02", from); err != nil {
+					return fmt.Errorf("invalid --from date '%s' (expected YYYY-MM-DD)", from)
+				}
+			}
+			if to != "" {
+				if opts.To, err = time.Parse("2006-01-02", to); err != nil {
+					return fmt.Errorf("invalid --to date '%s' (expected YYYY-MM-DD)", to)
+				}
+			}
+			if !opts.From.IsZero() && !opts.To.IsZero() && opts.To.Before(opts.From) {
+				return fmt.Errorf("--to date %s is before --from date %s", to, from)
+			}
+			if opts.Significance <= 0 || opts.Significance >= 1 {
+				return fmt.Errorf("significance must be between 0 and 1, got %g", opts.Significance)
+			}
+			if asOf != "" {
+				clock, err := bom.ParseAsOfDate(asOf)
+				if err != nil {
+					return err
+				}
+				opts.Clock = clock
+			}
+
+			inFile, err := os.Open(inputFile)
+			if err != nil {
+				return fmt.Errorf("failed to open input file %s: %w", inputFile, err)
+			}
+			defer inFile.Close()
+
+			output, outputName, closeOutput, err := openOutput(cmd, outputFile)
+			if err != nil {
+				return err
+			}
+			defer closeOutput()
+
+			if err := processor.ProcessTrends(inFile, output, opts); err != nil {
+				return fmt.Errorf("trend analysis failed: %w", err)
+			}
+
+			if *verbose {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Successfully wrote trends for %s to %s\n", inputFile, outputName)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input CSV file path (required)")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output JSON file path (defaults to stdout)")
+	cmd.Flags().StringVar(&from, "from", "", "Only include records on or after this date (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&to, "to", "", "Only include records on or before this date (YYYY-MM-DD)")
+	cmd.Flags().Float64Var(&opts.MinCompleteness, "min-completeness", opts.MinCompleteness, "Minimum percentage of days recorded for a year or month to be used")
+	cmd.Flags().Float64Var(&opts.Significance, "significance", opts.Significance, "p-value below which a trend is significant")
+	cmd.Flags().IntVar(&opts.MinYears, "min-years", opts.MinYears, "Minimum number of years needed to test a series")
+	cmd.Flags().StringVar(&asOf, "as-of", "", "Treat this date (YYYY-MM-DD) as today when excluding future months")
+	cmd.MarkFlagRequired("input")
+
+	return cmd
+}
@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTrendCommandHelp(t *testing.T) {
+	verbose := false
+	cmd := NewTrendCmd(&verbose)
+	cmd.SetArgs([]string{"--help"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Trend command help failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{"Mann-Kendall", "--from", "--to", "--significance"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain '%s', got: %s", expected, output)
+		}
+	}
+}
+
+func TestTrendCommandSampleData(t *testing.T) {
+	verbose := false
+	cmd := NewTrendCmd(&verbose)
+	cmd.SetArgs([]string{"--input", "../../../test_data/IDCJAC0009_066062_1800_Data.csv", "--from", "1900-06-01", "--to", "2019-12-31"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Trend command failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{`"Series": "MaximumDailyRainfall"`, `"FirstYear": "1901"`, `"ExcludedYears": [`, `"SensSlopePerDecade"`} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %s", expected)
+		}
+	}
+}
+
+func TestTrendCommandInvalidOptions(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+"IDCJAC0009,066062,2020,1,1,5.2,1,Y\n")
+
+	for _, args := range [][]string{
+		{"--from", "1990"},
+		{"--from", "2000-01-01", "--to", "1999-01-01"},
+		{"--significance", "5"},
+	} {
+		verbose := false
+		cmd := NewTrendCmd(&verbose)
+		cmd.SetArgs(append([]string{"--input", input}, args...))
+
+		var buf bytes.Buffer
+		cmd.SetOut(&buf)
+		cmd.SetErr(&buf)
+
+		if err := cmd.Execute(); err == nil {
+			t.Errorf("Expected error for %v", args)
+		}
+	}
+}
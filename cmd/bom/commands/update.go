@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/terem/bom/internal/bom"
+)
+
+func NewUpdateCmd(verbose *bool) *cobra.Command {
+	var stateFile string
+	var inputFile string
+	var outputFile string
+	var schema string
+
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "Apply new CSV rows to a saved aggregation state",
+		Long: `Apply new rows from a Bureau of Meteorology (BOM) CSV file to a saved aggregation state.
+
+The update command loads the state file (starting empty if it does not exist),
+adds the rows from the input CSV, and saves the state again. New readings
+replace earlier ones for the same date, but blank readings never replace
+recorded ones. With --output, the JSON for the updated state is also written,
+in the layout selected by --schema.
+
+The state keeps the aggregates of finished months and years alongside the
+days, so an update only aggregates again the months and years its rows
+change.
+
+Example:
+  bom update --state station.state.json -i IDCJAC0009_066062_1800_Data.csv
+  bom update --state station.state.json -i today.csv -o output.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			schemaVersion, err := bom.ParseSchemaVersion(schema)
+			if err != nil {
+				return err
+			}
+			processor := bom.NewProcessorWithOptions(bom.ProcessorOptions{
+				Verbose:    *verbose,
+				Aggregator: bom.DefaultAggregatorOptions(),
+				Schema:     schemaVersion,
+			})
+
+			inFile, err := os.Open(inputFile)
+			if err != nil {
+				return fmt.Errorf("failed to open input file %s: %w", inputFile, err)
+			}
+			defer inFile.Close()
+
+			var output io.Writer
+			if outputFile != "" {
+				var closeOutput func()
+				output, _, closeOutput, err = openOutput(cmd, outputFile)
+				if err != nil {
+					return err
+				}
+				defer closeOutput()
+			}
+
+			applied, err := processor.ProcessUpdate(stateFile, inFile, output)
+			if err != nil {
+				return fmt.Errorf("update failed: %w", err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Applied %d records from %s to %s\n", applied, inputFile, stateFile)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&stateFile, "state", "s", "", "State file path (required)")
+	cmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input CSV file path (required)")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output JSON file path for the updated aggregates")
+	cmd.Flags().StringVar(&schema, "schema", string(bom.SchemaV1), "Output schema version: v1 (all strings) or v2 (typed)")
+	cmd.MarkFlagRequired("state")
+	cmd.MarkFlagRequired("input")
+
+	return cmd
+}
@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpdateCommandHelp(t *testing.T) {
+	verbose := false
+	cmd := NewUpdateCmd(&verbose)
+	cmd.SetArgs([]string{"--help"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Update command help failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{"saved aggregation state", "--state", "--input", "--output"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain '%s', got: %s", expected, output)
+		}
+	}
+}
+
+func TestUpdateCommandAppliesRows(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	outputPath := filepath.Join(t.TempDir(), "output.json")
+	first := writeTempCSV(t, testCSVHeader+"IDCJAC0009,066062,2020,1,1,5.2,1,Y\n")
+	second := writeTempCSV(t, testCSVHeader+"IDCJAC0009,066062,2020,1,2,1.0,1,Y\n")
+
+	for _, args := range [][]string{
+		{"--state", statePath, "--input", first},
+		{"--state", statePath, "--input", second, "--output", outputPath},
+	} {
+		verbose := false
+		cmd := NewUpdateCmd(&verbose)
+		cmd.SetArgs(args)
+
+		var buf bytes.Buffer
+		cmd.SetOut(&buf)
+		cmd.SetErr(&buf)
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("Update command failed: %v", err)
+		}
+		if !strings.Contains(buf.String(), "Applied 1 records") {
+			t.Errorf("Expected applied count, got: %s", buf.String())
+		}
+	}
+
+	outputData, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	if !strings.Contains(string(outputData), `"TotalRainfall": "6.200000000000"`) {
+		t.Errorf("Expected output for both updates, got: %s", outputData)
+	}
+}
+
+func TestUpdateCommandMissingState(t *testing.T) {
+	input := writeTempCSV(t, testCSVHeader+"IDCJAC0009,066062,2020,1,1,5.2,1,Y\n")
+
+	verbose := false
+	cmd := NewUpdateCmd(&verbose)
+	cmd.SetArgs([]string{"--input", input})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("Expected error for missing state flag")
+	}
+}
+
+func TestUpdateCommandSchema(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	outputPath := filepath.Join(t.TempDir(), "output.json")
+	input := writeTempCSV(t, testCSVHeader+"IDCJAC0009,066062,2020,1,1,5.2,1,Y\n")
+
+	verbose := false
+	cmd := NewUpdateCmd(&verbose)
+	cmd.SetArgs([]string{"--state", statePath, "--input", input, "--output", outputPath, "--schema", "v2"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Update command failed: %v", err)
+	}
+
+	outputData, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	if !strings.Contains(string(outputData), `"schemaVersion": 2`) {
+		t.Errorf("Expected v2 output, got: %s", outputData)
+	}
+}
@@ -0,0 +1,392 @@
+package bom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// accumulatorStateVersion is the version of the serialised accumulator
+// format. Version 1 states, which hold only the days, still load.
+const accumulatorStateVersion = 2
+
+// Accumulator holds the aggregation state of a station's record. It takes
+// records one at a time, can be saved and restored, and can be merged with
+// accumulators built from other partitions of the same record.
+//
+// Each month keeps its days, because exact statistics such as medians
+// cannot be derived from running totals alone, along with a running total
+// and count of recorded days. The aggregates of finished months and years
+// are kept as well, so that AggregateAccumulator recomputes only the
+// periods that records have been added to since.
+type Accumulator struct {
+	years map[int]*accumulatedYear
+	// stations counts the days held for each named station
+	stations map[string]int
+	// aggregatesKey identifies the aggregator options that the kept
+	// aggregates were computed with
+	aggregatesKey string
+}
+
+// accumulatedYear is the state of one year of the record
+type accumulatedYear struct {
+	months [13]*accumulatedMonth
+	// aggregate is the year's output, or nil when it must be computed
+	aggregate *WeatherDataForYear
+}
+
+// accumulatedMonth is the state of one month of the record
+type accumulatedMonth struct {
+	days map[time.Time]DailyRecord
+	// total is the running total of the days with a reading, and recorded
+	// the count of those that were observed rather than imputed
+	total    Amount
+	recorded int
+	// aggregate is the month's output, or nil when it must be computed
+	aggregate *WeatherDataForMonth
+}
+
+// NewAccumulator creates an empty Accumulator
+func NewAccumulator() *Accumulator {
+	return &Accumulator{years: make(map[int]*accumulatedYear), stations: make(map[string]int)}
+}
+
+// NewAccumulatorFromRecords creates an Accumulator holding the given records
+func NewAccumulatorFromRecords(records []DailyRecord) *Accumulator {
+	acc := NewAccumulator()
+	for _, rec := range records {
+		acc.Add(rec)
+	}
+	return acc
+}
+
+// Add records a single day. A later record for the same date replaces the
+// earlier one, except that a day without a reading never replaces a day
+// with one, so re-applying an overlapping file with blanks loses nothing.
+// Only a day that changes invalidates the aggregates of its month and year.
+func (acc *Accumulator) Add(rec DailyRecord) {
+	rec.Date = truncateToDay(rec.Date)
+	y, ok := acc.years[rec.Date.Year()]
+	if !ok {
+		y = &accumulatedYear{}
+		acc.years[rec.Date.Year()] = y
+	}
+	m := y.months[rec.Date.Month()]
+	if m == nil {
+		m = &accumulatedMonth{days: make(map[time.Time]DailyRecord)}
+		y.months[rec.Date.Month()] = m
+	}
+
+	if existing, ok := m.days[rec.Date]; ok {
+		if existing == rec || (existing.HasData && !rec.HasData) {
+			return
+		}
+		m.remove(existing)
+		acc.countStation(existing.Station, -1)
+	}
+	m.days[rec.Date] = rec
+	m.add(rec)
+	acc.countStation(rec.Station, 1)
+	m.aggregate = nil
+	y.aggregate = nil
+}
+
+func (m *accumulatedMonth) add(rec DailyRecord) {
+	if rec.HasData {
+		m.total += rec.Rainfall
+	}
+	if rec.observed() {
+		m.recorded++
+	}
+}
+
+func (m *accumulatedMonth) remove(rec DailyRecord) {
+	if rec.HasData {
+		m.total -= rec.Rainfall
+	}
+	if rec.observed() {
+		m.recorded--
+	}
+}
+
+func (acc *Accumulator) countStation(station string, n int) {
+	if station == "" {
+		return
+	}
+	acc.stations[station] += n
+	if acc.stations[station] == 0 {
+		delete(acc.stations, station)
+	}
+}
+
+// station returns the station named by the days held, or an empty string
+// when they name none or more than one
+func (acc *Accumulator) station() string {
+	if len(acc.stations) != 1 {
+		return ""
+	}
+	for station := range acc.stations {
+		return station
+	}
+	return ""
+}
+
+// Merge adds every record held by other into acc using the same rules as Add
+func (acc *Accumulator) Merge(other *Accumulator) {
+	for _, rec := range other.Records() {
+		acc.Add(rec)
+	}
+}
+
+// Len returns the number of days held
+func (acc *Accumulator) Len() int {
+	n := 0
+	for _, y := range acc.years {
+		for _, m := range y.months {
+			if m != nil {
+				n += len(m.days)
+			}
+		}
+	}
+	return n
+}
+
+// Years returns the years held, in ascending order
+func (acc *Accumulator) Years() []int {
+	years := make([]int, 0, len(acc.years))
+	for y := range acc.years {
+		years = append(years, y)
+	}
+	sort.Ints(years)
+	return years
+}
+
+// YearRecords returns the records for a year sorted by date
+func (acc *Accumulator) YearRecords(year int) []DailyRecord {
+	var records []DailyRecord
+	if y, ok := acc.years[year]; ok {
+		for _, m := range y.months {
+			if m != nil {
+				records = append(records, m.records()...)
+			}
+		}
+	}
+	return records
+}
+
+// records returns the month's records sorted by date
+func (m *accumulatedMonth) records() []DailyRecord {
+	records := make([]DailyRecord, 0, len(m.days))
+	for _, rec := range m.days {
+		records = append(records, rec)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Date.Before(records[j].Date)
+	})
+	return records
+}
+
+// Records returns every record held, sorted by date
+func (acc *Accumulator) Records() []DailyRecord {
+	var records []DailyRecord
+	for _, year := range acc.Years() {
+		records = append(records, acc.YearRecords(year)...)
+	}
+	return records
+}
+
+// monthlyTotals returns the running totals of the months held that have
+// finished as of today, in date order
+func (acc *Accumulator) monthlyTotals(today time.Time) []MonthlyTotal {
+	var totals []MonthlyTotal
+	for _, year := range acc.Years() {
+		for month, m := range acc.years[year].months {
+			if m == nil || !monthFinished(year, time.Month(month), today) {
+				continue
+			}
+			totals = append(totals, MonthlyTotal{
+				Year:         year,
+				Month:        time.Month(month),
+				Total:        m.total,
+				Completeness: Completeness{ExpectedDays: daysIn(year, time.Month(month)), RecordedDays: m.recorded},
+			})
+		}
+	}
+	return totals
+}
+
+// monthAggregate returns the kept aggregate of a month. It is safe to call
+// on a nil year, which keeps no aggregates.
+func (y *accumulatedYear) monthAggregate(month time.Month) (WeatherDataForMonth, bool) {
+	if y == nil || y.months[month] == nil || y.months[month].aggregate == nil {
+		return WeatherDataForMonth{}, false
+	}
+	return *y.months[month].aggregate, true
+}
+
+// keepMonthAggregate keeps the aggregate of a month. It does nothing on a
+// nil year.
+func (y *accumulatedYear) keepMonthAggregate(month time.Month, data WeatherDataForMonth) {
+	if y != nil && y.months[month] != nil {
+		y.months[month].aggregate = &data
+	}
+}
+
+// resetAggregates discards the kept aggregates, which were computed with
+// other options than those identified by key
+func (acc *Accumulator) resetAggregates(key string) {
+	acc.aggregatesKey = key
+	for _, y := range acc.years {
+		y.aggregate = nil
+		for _, m := range y.months {
+			if m != nil {
+				m.aggregate = nil
+			}
+		}
+	}
+}
+
+// accumulatorState is the serialised form of an Accumulator
+type accumulatorState struct {
+	Version int              `json:"Version"`
+	Days    []accumulatedDay `json:"Days"`
+	// Aggregates are the kept aggregates of finished periods
+	Aggregates *accumulatedAggregates `json:"Aggregates,omitempty"`
+}
+
+// accumulatedDay is the serialised form of a DailyRecord. Rainfall is null
+// for days without a reading. The other fields are omitted when not given,
+// so states saved before they were recorded still load.
+type accumulatedDay struct {
+	Date     string           `json:"Date"`
+	Rainfall *float64         `json:"Rainfall"`
+	Period   int              `json:"Period,omitempty"`
+	Quality  string           `json:"Quality,omitempty"`
+	Station  string           `json:"Station,omitempty"`
+	Imputed  ImputationMethod `json:"Imputed,omitempty"`
+}
+
+// accumulatedAggregates are the kept aggregates of an Accumulator. Years
+// hold their months; Months holds the finished months of other years.
+type accumulatedAggregates struct {
+	Key    string                      `json:"Key"`
+	Years  []WeatherDataForYear        `json:"Years,omitempty"`
+	Months []accumulatedMonthAggregate `json:"Months,omitempty"`
+}
+
+// accumulatedMonthAggregate is a kept month aggregate and its year
+type accumulatedMonthAggregate struct {
+	Year int `json:"Year"`
+	WeatherDataForMonth
+}
+
+// Save writes the accumulator state as JSON
+func (acc *Accumulator) Save(w io.Writer) error {
+	state := accumulatorState{Version: accumulatorStateVersion, Days: []accumulatedDay{}}
+	for _, rec := range acc.Records() {
+		day := accumulatedDay{
+			Date:    rec.Date.Format("2006-01-02"),
+			Period:  rec.Period,
+			Quality: rec.Quality,
+			Station: rec.Station,
+			Imputed: rec.Imputed,
+		}
+		if rec.HasData {
+			rainfall := rec.Rainfall.Float64()
+			day.Rainfall = &rainfall
+		}
+		state.Days = append(state.Days, day)
+	}
+
+	aggregates := accumulatedAggregates{Key: acc.aggregatesKey}
+	for _, year := range acc.Years() {
+		y := acc.years[year]
+		if y.aggregate != nil {
+			aggregates.Years = append(aggregates.Years, *y.aggregate)
+			continue
+		}
+		for _, m := range y.months {
+			if m != nil && m.aggregate != nil {
+				aggregates.Months = append(aggregates.Months, accumulatedMonthAggregate{Year: year, WeatherDataForMonth: *m.aggregate})
+			}
+		}
+	}
+	if aggregates.Key != "" && (len(aggregates.Years) > 0 || len(aggregates.Months) > 0) {
+		state.Aggregates = &aggregates
+	}
+
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(state); err != nil {
+		return fmt.Errorf("failed to encode accumulator state (days: %d): %w", len(state.Days), err)
+	}
+	return nil
+}
+
+// LoadAccumulator reads accumulator state previously written by Save
+func LoadAccumulator(r io.Reader) (*Accumulator, error) {
+	var state accumulatorState
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return nil, fmt.Errorf("failed to decode accumulator state: %w", err)
+	}
+	if state.Version != 1 && state.Version != accumulatorStateVersion {
+		return nil, fmt.Errorf("unsupported accumulator state version %d (expected %d)", state.Version, accumulatorStateVersion)
+	}
+
+	acc := NewAccumulator()
+	for i, day := range state.Days {
+		date, err := time.Parse("2006-01-02", day.Date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date in accumulator state entry %d: %w", i+1, err)
+		}
+		rec := DailyRecord{Date: date, Period: day.Period, Quality: day.Quality, Station: day.Station, Imputed: day.Imputed}
+		if day.Rainfall != nil {
+			rec.Rainfall = AmountFromFloat(*day.Rainfall)
+			rec.HasData = true
+		}
+		acc.Add(rec)
+	}
+
+	if state.Aggregates != nil {
+		acc.aggregatesKey = state.Aggregates.Key
+		for i := range state.Aggregates.Years {
+			data := state.Aggregates.Years[i]
+			year, err := strconv.Atoi(data.Year)
+			if err != nil {
+				return nil, fmt.Errorf("invalid year '%s' in accumulator state aggregates", data.Year)
+			}
+			y, ok := acc.years[year]
+			if !ok {
+				return nil, fmt.Errorf("accumulator state has aggregates for %d but no days", year)
+			}
+			y.aggregate = &data
+			for _, m := range data.MonthlyAggregates.WeatherDataForMonth {
+				if err := acc.loadMonthAggregate(year, m); err != nil {
+					return nil, err
+				}
+			}
+		}
+		for _, m := range state.Aggregates.Months {
+			if err := acc.loadMonthAggregate(m.Year, m.WeatherDataForMonth); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return acc, nil
+}
+
+// loadMonthAggregate keeps a month aggregate read from a saved state
+func (acc *Accumulator) loadMonthAggregate(year int, data WeatherDataForMonth) error {
+	month, err := parseMonth(data.Month)
+	if err != nil {
+		return fmt.Errorf("invalid month in accumulator state aggregates: %w", err)
+	}
+	y, ok := acc.years[year]
+	if !ok || y.months[month] == nil {
+		return fmt.Errorf("accumulator state has aggregates for %s %d but no days", data.Month, year)
+	}
+	y.months[month].aggregate = &data
+	return nil
+}
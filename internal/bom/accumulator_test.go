@@ -0,0 +1,220 @@
+package bom
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccumulator_Add(t *testing.T) {
+	acc := NewAccumulator()
+	acc.Add(DailyRecord{Date: day(2020, 1, 2), Rainfall: mm(2.0), HasData: true})
+	acc.Add(DailyRecord{Date: day(2020, 1, 1), Rainfall: mm(1.0), HasData: true})
+	acc.Add(DailyRecord{Date: day(2019, 12, 31), HasData: false})
+
+	if acc.Len() != 3 {
+		t.Fatalf("Expected 3 days, got %d", acc.Len())
+	}
+	if !reflect.DeepEqual(acc.Years(), []int{2019, 2020}) {
+		t.Errorf("Unexpected years %v", acc.Years())
+	}
+	records := acc.YearRecords(2020)
+	if len(records) != 2 || !records[0].Date.Equal(day(2020, 1, 1)) {
+		t.Errorf("Expected 2020 records sorted by date, got %+v", records)
+	}
+}
+
+func TestAccumulator_AddReplacement(t *testing.T) {
+	acc := NewAccumulator()
+	acc.Add(DailyRecord{Date: day(2020, 1, 1), Rainfall: mm(1.0), HasData: true})
+
+	// A blank reading does not erase a recorded one
+	acc.Add(DailyRecord{Date: day(2020, 1, 1), HasData: false})
+	if rec := acc.Records()[0]; !rec.HasData || rec.Rainfall != mm(1.0) {
+		t.Errorf("Expected recorded reading to be kept, got %+v", rec)
+	}
+
+	// A corrected reading replaces the earlier one
+	acc.Add(DailyRecord{Date: day(2020, 1, 1), Rainfall: mm(1.4), HasData: true})
+	if rec := acc.Records()[0]; rec.Rainfall != mm(1.4) || acc.Len() != 1 {
+		t.Errorf("Expected corrected reading 1.4, got %+v", rec)
+	}
+}
+
+func TestAccumulator_Merge(t *testing.T) {
+	a := NewAccumulatorFromRecords([]DailyRecord{
+		{Date: day(2020, 1, 1), Rainfall: mm(1.0), HasData: true},
+		{Date: day(2020, 1, 2), HasData: false},
+	})
+	b := NewAccumulatorFromRecords([]DailyRecord{
+		{Date: day(2020, 1, 2), Rainfall: mm(2.0), HasData: true},
+		{Date: day(2021, 1, 1), Rainfall: mm(3.0), HasData: true},
+	})
+
+	a.Merge(b)
+
+	if a.Len() != 3 {
+		t.Fatalf("Expected 3 days after merge, got %d", a.Len())
+	}
+	if rec := a.YearRecords(2020)[1]; !rec.HasData || rec.Rainfall != mm(2.0) {
+		t.Errorf("Expected merged reading to fill the gap, got %+v", rec)
+	}
+}
+
+func TestAccumulator_SaveAndLoad(t *testing.T) {
+	acc := NewAccumulatorFromRecords([]DailyRecord{
+		{Date: day(2020, 1, 1), Rainfall: mm(1.2), HasData: true, Period: 1, Quality: "Y", Station: "066062"},
+		{Date: day(2020, 1, 2), HasData: false},
+		{Date: day(2020, 1, 3), Rainfall: mm(0.0), HasData: true},
+		{Date: day(2020, 1, 4), Rainfall: mm(0.4), HasData: true, Imputed: ImputeClimatology},
+	})
+
+	var buf bytes.Buffer
+	if err := acc.Save(&buf); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `{"Date":"2020-01-02","Rainfall":null}`) {
+		t.Errorf("Expected missing reading to be saved as null, got: %s", buf.String())
+	}
+
+	loaded, err := LoadAccumulator(&buf)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Records(), acc.Records()) {
+		t.Errorf("Expected round trip to preserve records, got %+v", loaded.Records())
+	}
+	if loaded.station() != "066062" {
+		t.Errorf("Expected the station to be restored, got %q", loaded.station())
+	}
+
+	// States saved before stations, imputation and aggregates were kept
+	// still load
+	v1 := `{"Version":1,"Days":[{"Date":"2020-01-01","Rainfall":1.2,"Period":1,"Quality":"Y"}]}`
+	if old, err := LoadAccumulator(strings.NewReader(v1)); err != nil || old.Len() != 1 {
+		t.Errorf("Expected a version 1 state to load, got %v", err)
+	}
+}
+
+func TestLoadAccumulator_Errors(t *testing.T) {
+	testCases := map[string]string{
+		"not json":    "nope",
+		"bad version": `{"Version":99,"Days":[]}`,
+		"bad date":    `{"Version":1,"Days":[{"Date":"2020-13-01","Rainfall":1}]}`,
+		"orphan year": `{"Version":2,"Days":[],"Aggregates":{"Key":"k","Years":[{"Year":"2019"}]}}`,
+		"bad month":   `{"Version":2,"Days":[{"Date":"2019-01-01","Rainfall":1}],"Aggregates":{"Key":"k","Months":[{"Year":2019,"Month":"Smarch"}]}}`,
+	}
+	for name, input := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadAccumulator(strings.NewReader(input)); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+func TestAggregateAccumulator_MatchesAggregate(t *testing.T) {
+	records := []DailyRecord{
+		{Date: day(2019, 12, 31), Rainfall: mm(4.0), HasData: true},
+		{Date: day(2020, 1, 1), Rainfall: mm(1.0), HasData: true},
+		{Date: day(2020, 1, 2), Rainfall: mm(0.0), HasData: true},
+		{Date: day(2020, 2, 1), Rainfall: mm(3.0), HasData: true},
+	}
+
+	// Aggregate two partitions separately and merge the state
+	first := NewAccumulatorFromRecords(records[:2])
+	second := NewAccumulatorFromRecords(records[2:])
+	first.Merge(second)
+
+	agg := NewAggregator()
+	if !reflect.DeepEqual(agg.AggregateAccumulator(first), agg.Aggregate(records)) {
+		t.Error("Expected merged accumulator to aggregate the same as the full record")
+	}
+}
+
+// incrementalRecords are two finished years and an unfinished month as of
+// 15 February 2020
+func incrementalRecords() []DailyRecord {
+	var records []DailyRecord
+	for d := day(2018, 1, 1); d.Before(day(2020, 1, 20)); d = d.AddDate(0, 0, 1) {
+		records = append(records, DailyRecord{Date: d, Rainfall: mm(float64(d.Day() % 4)), HasData: true, Station: "066062"})
+	}
+	return records
+}
+
+func TestAggregateAccumulator_KeepsFinishedPeriods(t *testing.T) {
+	options := DefaultAggregatorOptions()
+	options.Clock = FixedClock{Time: day(2020, 2, 15)}
+	agg := NewAggregatorWithOptions(options)
+	acc := NewAccumulatorFromRecords(incrementalRecords())
+
+	if !reflect.DeepEqual(agg.AggregateAccumulator(acc), agg.Aggregate(acc.Records())) {
+		t.Fatal("Expected the accumulator to aggregate the same as its records")
+	}
+	if acc.years[2018].aggregate == nil || acc.years[2019].aggregate == nil {
+		t.Error("Expected the aggregates of finished years to be kept")
+	}
+	if acc.years[2020].aggregate != nil || acc.years[2020].months[time.January].aggregate == nil {
+		t.Error("Expected the unfinished year to be recomputed but its finished January kept")
+	}
+
+	// A changed day drops only the aggregates of its month and year
+	acc.Add(DailyRecord{Date: day(2019, 6, 10), Rainfall: mm(25.0), HasData: true, Station: "066062"})
+	if acc.years[2018].aggregate == nil || acc.years[2019].aggregate != nil {
+		t.Error("Expected only 2019 to be invalidated")
+	}
+	if acc.years[2019].months[time.June].aggregate != nil || acc.years[2019].months[time.May].aggregate == nil {
+		t.Error("Expected only June 2019 to be invalidated")
+	}
+	// Re-adding an unchanged day keeps them
+	acc.Add(DailyRecord{Date: day(2018, 3, 1), Rainfall: mm(1.0), HasData: true, Station: "066062"})
+	if acc.years[2018].aggregate == nil {
+		t.Error("Expected an unchanged day to keep the aggregates")
+	}
+
+	if !reflect.DeepEqual(agg.AggregateAccumulator(acc), agg.Aggregate(acc.Records())) {
+		t.Error("Expected the updated accumulator to aggregate the same as its records")
+	}
+
+	// An earlier clock, under which 2019 has not finished, does not reuse
+	// its kept aggregate
+	earlier := options
+	earlier.Clock = FixedClock{Time: day(2019, 6, 30)}
+	agg = NewAggregatorWithOptions(earlier)
+	if !reflect.DeepEqual(agg.AggregateAccumulator(acc), agg.Aggregate(acc.Records())) {
+		t.Error("Expected periods unfinished as of the clock to be recomputed")
+	}
+
+	// Nor do other options
+	bom := options
+	bom.Precision = &BOMPrecision
+	agg = NewAggregatorWithOptions(bom)
+	if !reflect.DeepEqual(agg.AggregateAccumulator(acc), agg.Aggregate(acc.Records())) {
+		t.Error("Expected aggregates kept with other options to be recomputed")
+	}
+}
+
+func TestAccumulator_SaveKeepsAggregates(t *testing.T) {
+	options := DefaultAggregatorOptions()
+	options.Clock = FixedClock{Time: day(2020, 2, 15)}
+	agg := NewAggregatorWithOptions(options)
+	acc := NewAccumulatorFromRecords(incrementalRecords())
+	want := agg.AggregateAccumulator(acc)
+
+	var buf bytes.Buffer
+	if err := acc.Save(&buf); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := LoadAccumulator(&buf)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.years[2018].aggregate == nil || loaded.years[2020].months[time.January].aggregate == nil {
+		t.Fatal("Expected the kept aggregates to be restored")
+	}
+	if got := agg.AggregateAccumulator(loaded); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the restored state to aggregate the same\nwant: %+v\ngot:  %+v", want, got)
+	}
+}
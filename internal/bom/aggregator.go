@@ -1,46 +1,243 @@
 package bom
 
 import (
+	"encoding/json"
 	"sort"
 	"strconv"
 	"time"
 )
 
+// AggregatorOptions configures how the Aggregator treats its input
+type AggregatorOptions struct {
+	// MinCompleteness is the minimum percentage of expected days that must
+	// have a reading for a period's statistics to be considered reliable.
+	// Zero disables the check.
+	MinCompleteness float64
+	// IncompleteAction is applied to periods below MinCompleteness
+	IncompleteAction IncompleteAction
+	// Clock determines which days have occurred. Records after the clock's
+	// current day are excluded, so a month containing that day is truncated
+	// to it. A nil Clock uses the system time.
+	Clock Clock
+	// DayFilter, when set, restricts which calendar days count towards a
+	// period's expected days. It should match any filter applied to the
+	// records so that filtered-out days are not reported as missing.
+	DayFilter Predicate
+	// Statistics are computed for every year and month. A nil registry
+	// uses DefaultStatistics.
+	Statistics *StatisticsRegistry
+	// Precision sets the decimal places of output values. A nil Precision
+	// uses LegacyPrecision.
+	Precision *Precision
+	// Median selects the days MedianDailyRainfall is taken over. The zero
+	// value takes the median over wet days.
+	Median MedianOptions
+	// IncludeDaily nests each month's daily records under it in the output
+	IncludeDaily bool
+}
+
+// DefaultAggregatorOptions returns options that reproduce the original output
+func DefaultAggregatorOptions() AggregatorOptions {
+	return AggregatorOptions{
+		MinCompleteness:  0,
+		IncompleteAction: IncompleteFlag,
+		Clock:            SystemClock{},
+		Statistics:       DefaultStatistics(),
+	}
+}
+
 // Aggregator computes statistics from daily weather records
-type Aggregator struct{}
+type Aggregator struct {
+	options    AggregatorOptions
+	statistics *StatisticsRegistry
+}
 
 // NewAggregator creates a new Aggregator
 func NewAggregator() *Aggregator {
-	return &Aggregator{}
+	return NewAggregatorWithOptions(DefaultAggregatorOptions())
 }
 
-// Aggregate aggregates daily records into yearly and monthly statistics
+// NewAggregatorWithOptions creates a new Aggregator with the given options
+func NewAggregatorWithOptions(options AggregatorOptions) *Aggregator {
+	statistics := options.Statistics
+	if statistics == nil {
+		statistics = DefaultStatistics()
+	}
+	if !options.Median.IsZero() {
+		statistics = statistics.withFactory("MedianDailyRainfall", MedianDailyRainfall(options.Median))
+	}
+	return &Aggregator{options: options, statistics: statistics}
+}
+
+// Aggregate aggregates daily records into yearly and monthly statistics.
+// Records dated after the current day of the Aggregator's clock are ignored.
+// It is AggregateAccumulator over the records, so a date given more than
+// once is counted once.
 func (a *Aggregator) Aggregate(records []DailyRecord) WeatherData {
-	yearMap := make(map[int][]DailyRecord)
+	return a.AggregateAccumulator(NewAccumulatorFromRecords(records))
+}
+
+// AggregateYears aggregates daily records as Aggregate does, but passes
+// each year to yield, in order, as soon as it is computed, so only one
+// year's aggregates are held at a time. The returned WeatherData has the
+// interannual variability and station but no years. Aggregation stops at
+// the first error returned by yield.
+func (a *Aggregator) AggregateYears(records []DailyRecord, yield func(WeatherDataForYear) error) (WeatherData, error) {
+	acc := NewAccumulatorFromRecords(records)
+	today := truncateToDay(a.now())
+	key := a.aggregatesKey()
+	for _, year := range acc.Years() {
+		yearData, ok := a.aggregateAccumulatedYear(acc, year, today, key)
+		if !ok {
+			continue
+		}
+		if err := yield(yearData); err != nil {
+			return WeatherData{}, err
+		}
+	}
+
+	return WeatherData{
+		InterannualVariability: a.interannualVariability(acc.monthlyTotals(today), today),
+		Station:                acc.station(),
+	}, nil
+}
+
+// AggregateAccumulator computes yearly and monthly statistics from the
+// records held by an Accumulator.
+// Records dated after the current day of the Aggregator's clock are ignored.
+//
+// The aggregates of months and years that have finished are kept in the
+// Accumulator, and reused until records are added to them, so repeated
+// calls recompute only the periods that changed. Aggregates are not kept
+// when a day filter, daily records or custom statistics are configured.
+func (a *Aggregator) AggregateAccumulator(acc *Accumulator) WeatherData {
+	today := truncateToDay(a.now())
+	key := a.aggregatesKey()
+	var yearlyAggregates []WeatherDataForYear
+	for _, year := range acc.Years() {
+		if yearData, ok := a.aggregateAccumulatedYear(acc, year, today, key); ok {
+			yearlyAggregates = append(yearlyAggregates, yearData)
+		}
+	}
+
+	return WeatherData{
+		WeatherDataForYear:     yearlyAggregates,
+		InterannualVariability: a.interannualVariability(acc.monthlyTotals(today), today),
+		Station:                acc.station(),
+	}
+}
+
+// aggregateAccumulatedYear computes the statistics of one of an
+// Accumulator's years, reusing and keeping the aggregates of finished
+// periods when key is not empty. It reports false when the year has no
+// records up to today.
+func (a *Aggregator) aggregateAccumulatedYear(acc *Accumulator, year int, today time.Time, key string) (WeatherDataForYear, bool) {
+	if key != "" && key != acc.aggregatesKey {
+		acc.resetAggregates(key)
+	}
+	y := acc.years[year]
+	finished := monthFinished(year, time.December, today)
+	if key != "" && finished && y.aggregate != nil {
+		return *y.aggregate, true
+	}
+	var yearRecords []DailyRecord
+	for _, rec := range acc.YearRecords(year) {
+		if !rec.Date.After(today) {
+			yearRecords = append(yearRecords, rec)
+		}
+	}
+	if len(yearRecords) == 0 {
+		return WeatherDataForYear{}, false
+	}
+	if key == "" {
+		return a.aggregateYear(year, yearRecords, nil), true
+	}
+	yearData := a.aggregateYear(year, yearRecords, y)
+	if finished {
+		y.aggregate = &yearData
+	}
+	return yearData, true
+}
+
+// aggregatesKey identifies the options that the aggregates of finished
+// periods depend on, or returns an empty string when they are not kept
+func (a *Aggregator) aggregatesKey() string {
+	if a.options.DayFilter != nil || a.options.IncludeDaily || a.statistics.custom {
+		return ""
+	}
+	key, err := json.Marshal(struct {
+		MinCompleteness  float64
+		IncompleteAction IncompleteAction
+		Precision        Precision
+		Median           MedianOptions
+		Statistics       []string
+	}{a.options.MinCompleteness, a.options.IncompleteAction, a.precision(), a.options.Median, a.statistics.names})
+	if err != nil {
+		return ""
+	}
+	return string(key)
+}
+
+// monthFinished reports whether the last day of a month is no later than
+// today, after which its aggregate no longer changes with the clock
+func monthFinished(year int, month time.Month, today time.Time) bool {
+	return !time.Date(year, month, daysIn(year, month), 0, 0, 0, 0, time.UTC).After(today)
+}
+
+// recordStation returns the station named by the records, or an empty
+// string when they name none or more than one. Records without a station,
+// such as imputed days, are ignored.
+func recordStation(records []DailyRecord) string {
+	station := ""
 	for _, rec := range records {
-		year := rec.Date.Year()
-		yearMap[year] = append(yearMap[year], rec)
+		if rec.Station == "" || rec.Station == station {
+			continue
+		}
+		if station != "" {
+			return ""
+		}
+		station = rec.Station
 	}
+	return station
+}
 
-	var years []int
-	for y := range yearMap {
-		years = append(years, y)
+// precision returns the output precision
+func (a *Aggregator) precision() Precision {
+	if a.options.Precision == nil {
+		return LegacyPrecision
 	}
-	sort.Ints(years)
+	return *a.options.Precision
+}
 
-	var yearlyAggregates []WeatherDataForYear
-	for _, year := range years {
-		yearRecords := yearMap[year]
-		yearlyAggregates = append(yearlyAggregates, a.aggregateYear(year, yearRecords))
+// now returns the current time according to the Aggregator's clock
+func (a *Aggregator) now() time.Time {
+	if a.options.Clock == nil {
+		return SystemClock{}.Now()
 	}
+	return a.options.Clock.Now()
+}
 
-	return WeatherData{WeatherDataForYear: yearlyAggregates}
+// expectedDays returns the number of days in a month that have occurred
+// and are accepted by the day filter
+func (a *Aggregator) expectedDays(year int, month time.Month, now time.Time) int {
+	days := expectedDaysInMonth(year, month, now)
+	if a.options.DayFilter == nil {
+		return days
+	}
+	count := 0
+	for d := 1; d <= days; d++ {
+		if a.options.DayFilter(DailyRecord{Date: time.Date(year, month, d, 0, 0, 0, 0, time.UTC)}) {
+			count++
+		}
+	}
+	return count
 }
 
 // isFutureMonth checks if a date is in a future month relative to the current date
 func (a *Aggregator) isFutureMonth(year int, month time.Month) bool {
-	currentYear := time.Now().Year()
-	currentMonth := time.Now().Month()
+	now := a.now()
+	currentYear := now.Year()
+	currentMonth := now.Month()
 
 	if year > currentYear {
 		return true // Future year
@@ -51,7 +248,10 @@ func (a *Aggregator) isFutureMonth(year int, month time.Month) bool {
 	return false
 }
 
-func (a *Aggregator) aggregateYear(year int, records []DailyRecord) WeatherDataForYear {
+// aggregateYear computes the statistics of a year and its months. Month
+// aggregates kept in kept are reused, and those of finished months are kept
+// there; kept may be nil.
+func (a *Aggregator) aggregateYear(year int, records []DailyRecord, kept *accumulatedYear) WeatherDataForYear {
 	if len(records) == 0 {
 		return WeatherDataForYear{}
 	}
@@ -63,34 +263,27 @@ func (a *Aggregator) aggregateYear(year int, records []DailyRecord) WeatherDataF
 	firstDate := records[0].Date.Format("2006-01-02")
 	lastDate := records[len(records)-1].Date.Format("2006-01-02")
 
-	var totalRainfall float64
-	var daysWithRainfall, daysWithNoRainfall, longestStreak, currentStreak int
-	var prevRained bool
+	stats := a.statistics.newStatistics(Period{Level: LevelYear, Year: year})
+	recordedDays := 0
+	var imputed imputedTally
 
 	monthMap := make(map[time.Month][]DailyRecord)
+	dailyMap := make(map[time.Month][]DailyRecord)
 	for _, rec := range records {
+		if a.options.IncludeDaily {
+			// Days without a reading are listed too, with a null rainfall
+			dailyMap[rec.Date.Month()] = append(dailyMap[rec.Date.Month()], rec)
+		}
 		if rec.HasData {
-			// Check if this record is in a future month
-			isFutureMonth := a.isFutureMonth(year, rec.Date.Month())
-
 			// Only include records that are not in future months
-			if !isFutureMonth {
-				totalRainfall += rec.Rainfall
-				if rec.Rainfall > 0 {
-					daysWithRainfall++
-					if prevRained {
-						currentStreak++
-					} else {
-						currentStreak = 1
-					}
-					if currentStreak > longestStreak {
-						longestStreak = currentStreak
-					}
-					prevRained = true
-				} else {
-					daysWithNoRainfall++
-					prevRained = false
+			if !a.isFutureMonth(year, rec.Date.Month()) {
+				for _, s := range stats {
+					s.Accumulate(rec)
 				}
+				if rec.observed() {
+					recordedDays++
+				}
+				imputed.add(rec)
 			}
 
 			// Always add to monthMap for monthly aggregation (filtering happens later)
@@ -98,12 +291,6 @@ func (a *Aggregator) aggregateYear(year int, records []DailyRecord) WeatherDataF
 		}
 	}
 
-	totalDays := daysWithRainfall + daysWithNoRainfall
-	avgRain := 0.0
-	if totalDays > 0 {
-		avgRain = totalRainfall / float64(totalDays)
-	}
-
 	// Monthly aggregates - filter out future months
 	var months []time.Month
 	for m := range monthMap {
@@ -115,24 +302,43 @@ func (a *Aggregator) aggregateYear(year int, records []DailyRecord) WeatherDataF
 	sort.Slice(months, func(i, j int) bool { return months[i] < months[j] })
 
 	var monthlyAggregates []WeatherDataForMonth
+	now := a.now()
 	for _, m := range months {
-		monthlyAggregates = append(monthlyAggregates, a.aggregateMonth(m, monthMap[m]))
+		// Months that are yet to finish change with the clock
+		finished := monthFinished(year, m, truncateToDay(now))
+		monthData, ok := kept.monthAggregate(m)
+		if !ok || !finished {
+			monthData = a.aggregateMonth(year, m, monthMap[m])
+			if finished {
+				kept.keepMonthAggregate(m, monthData)
+			}
+		}
+		if a.options.IncludeDaily {
+			monthData.DailyRecords = newDailyValues(dailyMap[m], a.precision())
+		}
+		monthlyAggregates = append(monthlyAggregates, monthData)
 	}
 
-	return WeatherDataForYear{
-		Year:                 strconv.Itoa(year),
-		FirstRecordedDate:    firstDate,
-		LastRecordedDate:     lastDate,
-		TotalRainfall:        formatFloat(totalRainfall, 12),
-		AverageDailyRainfall: formatFloat(avgRain, 12),
-		DaysWithNoRainfall:   strconv.Itoa(daysWithNoRainfall),
-		DaysWithRainfall:     strconv.Itoa(daysWithRainfall),
-		LongestDaysRaining:   strconv.Itoa(longestStreak),
-		MonthlyAggregates:    MonthlyAggregates{WeatherDataForMonth: monthlyAggregates},
+	expectedDays := 0
+	for m := time.January; m <= time.December; m++ {
+		if !a.isFutureMonth(year, m) {
+			expectedDays += a.expectedDays(year, m, now)
+		}
 	}
+
+	yearData := WeatherDataForYear{
+		Year:              strconv.Itoa(year),
+		FirstRecordedDate: firstDate,
+		LastRecordedDate:  lastDate,
+		MonthlyAggregates: MonthlyAggregates{WeatherDataForMonth: monthlyAggregates},
+	}
+	yearData.AdditionalStatistics = statisticResults(stats, yearFields(&yearData), a.precision())
+	a.options.applyYearCompleteness(&yearData, Completeness{ExpectedDays: expectedDays, RecordedDays: recordedDays})
+	imputed.apply(&yearData.ImputedDays, &yearData.ImputedRainfall, a.precision())
+	return yearData
 }
 
-func (a *Aggregator) aggregateMonth(month time.Month, records []DailyRecord) WeatherDataForMonth {
+func (a *Aggregator) aggregateMonth(year int, month time.Month, records []DailyRecord) WeatherDataForMonth {
 	if len(records) == 0 {
 		return WeatherDataForMonth{}
 	}
@@ -142,51 +348,34 @@ func (a *Aggregator) aggregateMonth(month time.Month, records []DailyRecord) Wea
 	firstDate := records[0].Date.Format("2006-01-02")
 	lastDate := records[len(records)-1].Date.Format("2006-01-02")
 
-	var totalRainfall float64
-	var daysWithRainfall, daysWithNoRainfall int
-	var rainfallDays []float64
+	stats := a.statistics.newStatistics(Period{Level: LevelMonth, Year: year, Month: month})
+	recordedDays := 0
+	var imputed imputedTally
 
 	for _, rec := range records {
 		if rec.HasData {
-			totalRainfall += rec.Rainfall
-			if rec.Rainfall > 0 {
-				daysWithRainfall++
-				rainfallDays = append(rainfallDays, rec.Rainfall)
-			} else {
-				daysWithNoRainfall++
+			for _, s := range stats {
+				s.Accumulate(rec)
 			}
+			if rec.observed() {
+				recordedDays++
+			}
+			imputed.add(rec)
 		}
 	}
 
-	totalDays := daysWithRainfall + daysWithNoRainfall
-	avgRain := 0.0
-	if totalDays > 0 {
-		avgRain = totalRainfall / float64(totalDays)
-	}
-
-	medianRain := 0.0
-	if len(rainfallDays) > 0 {
-		sort.Float64s(rainfallDays)
-		if len(rainfallDays)%2 == 0 {
-			// Even number of values: average of two middle values
-			mid := len(rainfallDays) / 2
-			medianRain = (rainfallDays[mid-1] + rainfallDays[mid]) / 2.0
-		} else {
-			// Odd number of values: middle value
-			medianRain = rainfallDays[len(rainfallDays)/2]
-		}
-	}
-
-	return WeatherDataForMonth{
-		Month:                month.String(),
-		FirstRecordedDate:    firstDate,
-		LastRecordedDate:     lastDate,
-		TotalRainfall:        formatFloat(totalRainfall, 12),
-		AverageDailyRainfall: formatFloat(avgRain, 12),
-		MedianDailyRainfall:  formatFloat(medianRain, 12),
-		DaysWithNoRainfall:   strconv.Itoa(daysWithNoRainfall),
-		DaysWithRainfall:     strconv.Itoa(daysWithRainfall),
+	monthData := WeatherDataForMonth{
+		Month:             month.String(),
+		FirstRecordedDate: firstDate,
+		LastRecordedDate:  lastDate,
 	}
+	monthData.AdditionalStatistics = statisticResults(stats, monthFields(&monthData), a.precision())
+	a.options.applyMonthCompleteness(&monthData, Completeness{
+		ExpectedDays: a.expectedDays(year, month, a.now()),
+		RecordedDays: recordedDays,
+	})
+	imputed.apply(&monthData.ImputedDays, &monthData.ImputedRainfall, a.precision())
+	return monthData
 }
 
 // formatFloat formats a float64 to string with specified precision (defaults to 9)
@@ -1,6 +1,8 @@
 package bom
 
 import (
+	"errors"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -24,10 +26,10 @@ func TestAggregate_EmptyRecords(t *testing.T) {
 func TestAggregate_SingleYear(t *testing.T) {
 	agg := NewAggregator()
 	records := []DailyRecord{
-		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: 10.5, HasData: true},
-		{Date: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Rainfall: 0.0, HasData: true},
-		{Date: time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC), Rainfall: 15.3, HasData: true},
-		{Date: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), Rainfall: 5.2, HasData: true},
+		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(10.5), HasData: true},
+		{Date: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Rainfall: mm(0.0), HasData: true},
+		{Date: time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC), Rainfall: mm(15.3), HasData: true},
+		{Date: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(5.2), HasData: true},
 	}
 
 	result := agg.Aggregate(records)
@@ -76,9 +78,9 @@ func TestAggregate_SingleYear(t *testing.T) {
 func TestAggregate_MultipleYears(t *testing.T) {
 	agg := NewAggregator()
 	records := []DailyRecord{
-		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: 10.0, HasData: true},
-		{Date: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: 20.0, HasData: true},
-		{Date: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: 30.0, HasData: true},
+		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(10.0), HasData: true},
+		{Date: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(20.0), HasData: true},
+		{Date: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(30.0), HasData: true},
 	}
 
 	result := agg.Aggregate(records)
@@ -96,15 +98,50 @@ func TestAggregate_MultipleYears(t *testing.T) {
 	}
 }
 
+func TestAggregateYears(t *testing.T) {
+	agg := NewAggregator()
+	records := []DailyRecord{
+		{Date: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(20.0), HasData: true, Station: "066062"},
+		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(10.0), HasData: true, Station: "066062"},
+		{Date: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(30.0), HasData: true, Station: "066062"},
+	}
+
+	var years []WeatherDataForYear
+	data, err := agg.AggregateYears(records, func(year WeatherDataForYear) error {
+		years = append(years, year)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if data.WeatherDataForYear != nil {
+		t.Errorf("Expected no years in the returned WeatherData, got %d", len(data.WeatherDataForYear))
+	}
+	data.WeatherDataForYear = years
+	if want := agg.Aggregate(records); !reflect.DeepEqual(data, want) {
+		t.Errorf("Expected the yielded years to match Aggregate, got %+v, want %+v", data, want)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	_, err = agg.AggregateYears(records, func(WeatherDataForYear) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("Expected aggregation to stop at the first error, got %v after %d years", err, calls)
+	}
+}
+
 func TestAggregate_LongestStreak(t *testing.T) {
 	agg := NewAggregator()
 	records := []DailyRecord{
-		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: 10.0, HasData: true}, // Day 1 of streak
-		{Date: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Rainfall: 5.0, HasData: true},  // Day 2 of streak
-		{Date: time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC), Rainfall: 15.0, HasData: true}, // Day 3 of streak
-		{Date: time.Date(2020, 1, 4, 0, 0, 0, 0, time.UTC), Rainfall: 0.0, HasData: true},  // Break
-		{Date: time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC), Rainfall: 8.0, HasData: true},  // Day 1 of new streak
-		{Date: time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC), Rainfall: 12.0, HasData: true}, // Day 2 of new streak
+		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(10.0), HasData: true}, // Day 1 of streak
+		{Date: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Rainfall: mm(5.0), HasData: true},  // Day 2 of streak
+		{Date: time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC), Rainfall: mm(15.0), HasData: true}, // Day 3 of streak
+		{Date: time.Date(2020, 1, 4, 0, 0, 0, 0, time.UTC), Rainfall: mm(0.0), HasData: true},  // Break
+		{Date: time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC), Rainfall: mm(8.0), HasData: true},  // Day 1 of new streak
+		{Date: time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC), Rainfall: mm(12.0), HasData: true}, // Day 2 of new streak
 	}
 
 	result := agg.Aggregate(records)
@@ -119,9 +156,9 @@ func TestAggregate_LongestStreak(t *testing.T) {
 func TestAggregate_MissingData(t *testing.T) {
 	agg := NewAggregator()
 	records := []DailyRecord{
-		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: 10.0, HasData: true},
-		{Date: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Rainfall: 0.0, HasData: false}, // Missing data
-		{Date: time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC), Rainfall: 15.0, HasData: true},
+		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(10.0), HasData: true},
+		{Date: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Rainfall: mm(0.0), HasData: false}, // Missing data
+		{Date: time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC), Rainfall: mm(15.0), HasData: true},
 	}
 
 	result := agg.Aggregate(records)
@@ -144,10 +181,10 @@ func TestAggregate_MissingData(t *testing.T) {
 func TestAggregate_MonthlyBreakdown(t *testing.T) {
 	agg := NewAggregator()
 	records := []DailyRecord{
-		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: 10.0, HasData: true},
-		{Date: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Rainfall: 5.0, HasData: true},
-		{Date: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), Rainfall: 15.0, HasData: true},
-		{Date: time.Date(2020, 2, 2, 0, 0, 0, 0, time.UTC), Rainfall: 0.0, HasData: true},
+		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(10.0), HasData: true},
+		{Date: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Rainfall: mm(5.0), HasData: true},
+		{Date: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(15.0), HasData: true},
+		{Date: time.Date(2020, 2, 2, 0, 0, 0, 0, time.UTC), Rainfall: mm(0.0), HasData: true},
 	}
 
 	result := agg.Aggregate(records)
@@ -191,10 +228,10 @@ func TestAggregate_MonthlyBreakdown(t *testing.T) {
 func TestAggregate_MedianCalculation(t *testing.T) {
 	agg := NewAggregator()
 	records := []DailyRecord{
-		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: 5.0, HasData: true},
-		{Date: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Rainfall: 10.0, HasData: true},
-		{Date: time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC), Rainfall: 15.0, HasData: true},
-		{Date: time.Date(2020, 1, 4, 0, 0, 0, 0, time.UTC), Rainfall: 0.0, HasData: true}, // Not included in median
+		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(5.0), HasData: true},
+		{Date: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Rainfall: mm(10.0), HasData: true},
+		{Date: time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC), Rainfall: mm(15.0), HasData: true},
+		{Date: time.Date(2020, 1, 4, 0, 0, 0, 0, time.UTC), Rainfall: mm(0.0), HasData: true}, // Not included in median
 	}
 
 	result := agg.Aggregate(records)
@@ -210,11 +247,11 @@ func TestAggregate_MedianCalculation(t *testing.T) {
 func TestAggregate_MedianWithEvenNumberOfValues(t *testing.T) {
 	agg := NewAggregator()
 	records := []DailyRecord{
-		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: 5.0, HasData: true},
-		{Date: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Rainfall: 10.0, HasData: true},
-		{Date: time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC), Rainfall: 15.0, HasData: true},
-		{Date: time.Date(2020, 1, 4, 0, 0, 0, 0, time.UTC), Rainfall: 20.0, HasData: true},
-		{Date: time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC), Rainfall: 0.0, HasData: true}, // Not included in median
+		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(5.0), HasData: true},
+		{Date: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Rainfall: mm(10.0), HasData: true},
+		{Date: time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC), Rainfall: mm(15.0), HasData: true},
+		{Date: time.Date(2020, 1, 4, 0, 0, 0, 0, time.UTC), Rainfall: mm(20.0), HasData: true},
+		{Date: time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC), Rainfall: mm(0.0), HasData: true}, // Not included in median
 	}
 
 	result := agg.Aggregate(records)
@@ -230,10 +267,10 @@ func TestAggregate_MedianWithEvenNumberOfValues(t *testing.T) {
 func TestAggregate_MonthlyMissingData(t *testing.T) {
 	agg := NewAggregator()
 	records := []DailyRecord{
-		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: 10.0, HasData: true},
-		{Date: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Rainfall: 0.0, HasData: false}, // Missing data
-		{Date: time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC), Rainfall: 15.0, HasData: true},
-		{Date: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), Rainfall: 5.0, HasData: true},
+		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(10.0), HasData: true},
+		{Date: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Rainfall: mm(0.0), HasData: false}, // Missing data
+		{Date: time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC), Rainfall: mm(15.0), HasData: true},
+		{Date: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(5.0), HasData: true},
 	}
 
 	result := agg.Aggregate(records)
@@ -255,9 +292,9 @@ func TestAggregate_MonthlyMissingData(t *testing.T) {
 func TestAggregate_MonthlyZeroRainfall(t *testing.T) {
 	agg := NewAggregator()
 	records := []DailyRecord{
-		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: 0.0, HasData: true},
-		{Date: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Rainfall: 0.0, HasData: true},
-		{Date: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), Rainfall: 10.0, HasData: true},
+		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(0.0), HasData: true},
+		{Date: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Rainfall: mm(0.0), HasData: true},
+		{Date: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(10.0), HasData: true},
 	}
 
 	result := agg.Aggregate(records)
@@ -285,10 +322,10 @@ func TestAggregate_MonthlyZeroRainfall(t *testing.T) {
 func TestAggregate_MonthlyDateRanges(t *testing.T) {
 	agg := NewAggregator()
 	records := []DailyRecord{
-		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: 10.0, HasData: true},
-		{Date: time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC), Rainfall: 20.0, HasData: true},
-		{Date: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), Rainfall: 15.0, HasData: true},
-		{Date: time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC), Rainfall: 25.0, HasData: true}, // Leap year
+		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(10.0), HasData: true},
+		{Date: time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC), Rainfall: mm(20.0), HasData: true},
+		{Date: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(15.0), HasData: true},
+		{Date: time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC), Rainfall: mm(25.0), HasData: true}, // Leap year
 	}
 
 	result := agg.Aggregate(records)
@@ -316,8 +353,8 @@ func TestAggregate_MonthlyDateRanges(t *testing.T) {
 func TestAggregate_ZeroRainfall(t *testing.T) {
 	agg := NewAggregator()
 	records := []DailyRecord{
-		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: 0.0, HasData: true},
-		{Date: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Rainfall: 0.0, HasData: true},
+		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(0.0), HasData: true},
+		{Date: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Rainfall: mm(0.0), HasData: true},
 	}
 
 	result := agg.Aggregate(records)
@@ -347,9 +384,9 @@ func TestAggregate_ZeroRainfall(t *testing.T) {
 func TestAggregate_RecordsNotSorted(t *testing.T) {
 	agg := NewAggregator()
 	records := []DailyRecord{
-		{Date: time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC), Rainfall: 15.0, HasData: true},
-		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: 10.0, HasData: true},
-		{Date: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Rainfall: 5.0, HasData: true},
+		{Date: time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC), Rainfall: mm(15.0), HasData: true},
+		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(10.0), HasData: true},
+		{Date: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Rainfall: mm(5.0), HasData: true},
 	}
 
 	result := agg.Aggregate(records)
@@ -370,16 +407,18 @@ func TestAggregate_FutureMonthsExcluded(t *testing.T) {
 	// This test uses data from 2020, so future months should be excluded
 	// based on the current date (which is after 2020)
 
-	agg := NewAggregator()
+	options := DefaultAggregatorOptions()
+	options.Clock = FixedClock{Time: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)}
+	agg := NewAggregatorWithOptions(options)
 	records := []DailyRecord{
 		// January 2020 - should be included (past month)
-		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: 10.0, HasData: true},
-		{Date: time.Date(2020, 1, 15, 0, 0, 0, 0, time.UTC), Rainfall: 5.0, HasData: true},
+		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(10.0), HasData: true},
+		{Date: time.Date(2020, 1, 15, 0, 0, 0, 0, time.UTC), Rainfall: mm(5.0), HasData: true},
 		// February 2020 - should be included (past month)
-		{Date: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), Rainfall: 15.0, HasData: true},
-		{Date: time.Date(2020, 2, 15, 0, 0, 0, 0, time.UTC), Rainfall: 20.0, HasData: true},
+		{Date: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(15.0), HasData: true},
+		{Date: time.Date(2020, 2, 15, 0, 0, 0, 0, time.UTC), Rainfall: mm(20.0), HasData: true},
 		// March 2020 - should be included (past month)
-		{Date: time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC), Rainfall: 25.0, HasData: true},
+		{Date: time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(25.0), HasData: true},
 	}
 
 	result := agg.Aggregate(records)
@@ -403,3 +442,122 @@ func TestAggregate_FutureMonthsExcluded(t *testing.T) {
 		t.Errorf("Expected yearly 5 days with rain (all months), got %s", yearData.DaysWithRainfall)
 	}
 }
+
+func TestAggregate_AsOfTruncatesMonth(t *testing.T) {
+	options := DefaultAggregatorOptions()
+	options.Clock = FixedClock{Time: time.Date(2020, 2, 10, 0, 0, 0, 0, time.UTC)}
+	agg := NewAggregatorWithOptions(options)
+	records := []DailyRecord{
+		{Date: time.Date(2020, 1, 15, 0, 0, 0, 0, time.UTC), Rainfall: mm(10.0), HasData: true},
+		{Date: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(5.0), HasData: true},
+		{Date: time.Date(2020, 2, 10, 0, 0, 0, 0, time.UTC), Rainfall: mm(2.0), HasData: true},
+		// After the as-of date: excluded from February and the year
+		{Date: time.Date(2020, 2, 11, 0, 0, 0, 0, time.UTC), Rainfall: mm(7.0), HasData: true},
+		// Future month and future year: excluded entirely
+		{Date: time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(9.0), HasData: true},
+		{Date: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(9.0), HasData: true},
+	}
+
+	result := agg.Aggregate(records)
+
+	if len(result.WeatherDataForYear) != 1 {
+		t.Fatalf("Expected only 2020, got %d years", len(result.WeatherDataForYear))
+	}
+	yearData := result.WeatherDataForYear[0]
+	if yearData.TotalRainfall != "17.000000000000" {
+		t.Errorf("Expected yearly total 17.000000000000, got %s", yearData.TotalRainfall)
+	}
+	if yearData.LastRecordedDate != "2020-02-10" {
+		t.Errorf("Expected last recorded date 2020-02-10, got %s", yearData.LastRecordedDate)
+	}
+	if yearData.ExpectedDays != "41" {
+		t.Errorf("Expected 41 expected days to 2020-02-10, got %s", yearData.ExpectedDays)
+	}
+
+	months := yearData.MonthlyAggregates.WeatherDataForMonth
+	if len(months) != 2 {
+		t.Fatalf("Expected January and February only, got %d months", len(months))
+	}
+	feb := months[1]
+	if feb.TotalRainfall != "7.000000000000" || feb.LastRecordedDate != "2020-02-10" || feb.ExpectedDays != "10" {
+		t.Errorf("Expected February truncated to the 10th, got %+v", feb)
+	}
+}
+
+func TestAggregate_ExactTotalsWithPrecision(t *testing.T) {
+	precision := BOMPrecision
+	options := DefaultAggregatorOptions()
+	options.Clock = FixedClock{Time: time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC)}
+	options.Precision = &precision
+	agg := NewAggregatorWithOptions(options)
+
+	var records []DailyRecord
+	for d := 1; d <= 30; d++ {
+		records = append(records, DailyRecord{Date: time.Date(2020, 4, d, 0, 0, 0, 0, time.UTC), Rainfall: mm(0.1), HasData: true})
+	}
+	records[0].Rainfall = mm(0.2)
+	records[1].Rainfall = mm(0.3)
+
+	result := agg.Aggregate(records)
+	april := result.WeatherDataForYear[0].MonthlyAggregates.WeatherDataForMonth[0]
+
+	// 0.2 + 0.3 + 28 * 0.1 = 3.3
+	if april.TotalRainfall != "3.3" {
+		t.Errorf("Expected total 3.3, got %s", april.TotalRainfall)
+	}
+	if april.AverageDailyRainfall != "0.110000000" {
+		t.Errorf("Expected average 0.110000000, got %s", april.AverageDailyRainfall)
+	}
+	if april.MedianDailyRainfall != "0.1" {
+		t.Errorf("Expected median 0.1, got %s", april.MedianDailyRainfall)
+	}
+
+	// The legacy precision no longer shows floating point drift
+	options.Precision = nil
+	legacy := NewAggregatorWithOptions(options).Aggregate(records)
+	if got := legacy.WeatherDataForYear[0].TotalRainfall; got != "3.300000000000" {
+		t.Errorf("Expected legacy total 3.300000000000, got %s", got)
+	}
+}
+
+func TestAggregate_Station(t *testing.T) {
+	tests := []struct {
+		name     string
+		stations []string
+		want     string
+	}{
+		{"single station", []string{"066062", "066062"}, "066062"},
+		{"blank stations ignored", []string{"", "066062"}, "066062"},
+		{"mixed stations", []string{"066062", "086071"}, ""},
+		{"no stations", []string{"", ""}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var records []DailyRecord
+			for i, station := range tt.stations {
+				records = append(records, DailyRecord{Date: day(2020, 1, i+1), Rainfall: mm(1.0), HasData: true, Station: station})
+			}
+			if got := NewAggregator().Aggregate(records).Station; got != tt.want {
+				t.Errorf("Expected station %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAggregate_DuplicateDates(t *testing.T) {
+	// Aggregate goes through an Accumulator, so both keep one record per
+	// date
+	records := []DailyRecord{
+		{Date: day(2020, 1, 1), Rainfall: mm(1.0), HasData: true},
+		{Date: day(2020, 1, 1), Rainfall: mm(2.0), HasData: true},
+	}
+	agg := NewAggregator()
+	aggregated := agg.Aggregate(records)
+	accumulated := agg.AggregateAccumulator(NewAccumulatorFromRecords(records))
+	if !reflect.DeepEqual(aggregated, accumulated) {
+		t.Errorf("Expected Aggregate to match AggregateAccumulator\nwant: %+v\ngot:  %+v", accumulated, aggregated)
+	}
+	if got := aggregated.WeatherDataForYear[0].RecordedDays; got != "1" {
+		t.Errorf("Expected one record per date, got %s recorded days", got)
+	}
+}
@@ -0,0 +1,152 @@
+package bom
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Amount is a rainfall depth held as a whole number of hundredths of a
+// millimetre, so that totals are exact rather than accumulating float64
+// rounding error
+type Amount int64
+
+// amountScale is the number of Amount units in a millimetre
+const amountScale = 100
+
+// ParseAmount parses a decimal rainfall depth in millimetres exactly. Values
+// that are not a whole number of hundredths of a millimetre, or that do not
+// fit in an Amount, are rejected rather than rounded.
+func ParseAmount(value string) (Amount, error) {
+	value = strings.TrimSpace(value)
+	r, ok := new(big.Rat).SetString(value)
+	// big.Rat also accepts fractions such as "1/2", which are not amounts
+	if !ok || strings.Contains(value, "/") {
+		return 0, fmt.Errorf("invalid amount '%s'", value)
+	}
+	r.Mul(r, big.NewRat(amountScale, 1))
+	if !r.IsInt() {
+		return 0, fmt.Errorf("invalid amount '%s': more than two decimal places", value)
+	}
+	if !r.Num().IsInt64() {
+		return 0, fmt.Errorf("invalid amount '%s': out of range", value)
+	}
+	return Amount(r.Num().Int64()), nil
+}
+
+// AmountFromFloat converts a depth in millimetres to the nearest Amount
+func AmountFromFloat(mm float64) Amount {
+	return Amount(math.Round(mm * amountScale))
+}
+
+// Float64 returns the depth in millimetres
+func (a Amount) Float64() float64 {
+	return float64(a) / amountScale
+}
+
+// Format returns the depth in millimetres with the given number of decimal
+// places, rounding half away from zero
+func (a Amount) Format(places int) string {
+	return formatRatio(big.NewRat(int64(a), amountScale), places)
+}
+
+// Precision sets the number of decimal places used for output values
+type Precision struct {
+	// Amount applies to rainfall depths such as totals and medians
+	Amount int
+	// Average applies to averages of daily rainfall
+	Average int
+}
+
+var (
+	// LegacyPrecision reproduces the original output of 12 decimal places
+	LegacyPrecision = Precision{Amount: 12, Average: 12}
+	// BOMPrecision matches BOM's published figures: depths to 0.1 mm and
+	// averages to 9 decimal places
+	BOMPrecision = Precision{Amount: 1, Average: 9}
+)
+
+// ParsePrecision parses "legacy", "bom" or a number of decimal places
+// applied to every value
+func ParsePrecision(value string) (Precision, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "legacy":
+		return LegacyPrecision, nil
+	case "bom":
+		return BOMPrecision, nil
+	}
+	places, err := strconv.Atoi(value)
+	if err != nil || places < 0 || places > 20 {
+		return Precision{}, fmt.Errorf("invalid precision '%s' (expected legacy, bom or 0-20 decimal places)", value)
+	}
+	return Precision{Amount: places, Average: places}, nil
+}
+
+// FormatAmount formats a rainfall depth
+func (p Precision) FormatAmount(a Amount) string {
+	return a.Format(p.Amount)
+}
+
+// FormatAmountRatio formats a rainfall depth divided by n, such as the
+// midpoint of two values for a median. n must be positive.
+func (p Precision) FormatAmountRatio(a Amount, n int) string {
+	return formatRatio(big.NewRat(int64(a), int64(n)*amountScale), p.Amount)
+}
+
+// FormatMean formats the mean of a total over n days, or zero when n is zero
+func (p Precision) FormatMean(total Amount, n int) string {
+	if n == 0 {
+		return Amount(0).Format(p.Average)
+	}
+	return formatRatio(big.NewRat(int64(total), int64(n)*amountScale), p.Average)
+}
+
+// FormatDepthFloat formats a derived depth in millimetres, such as a mean
+// or standard deviation of totals, with the rainfall depth precision
+func (p Precision) FormatDepthFloat(mm float64) string {
+	return formatFloat(mm, p.Amount)
+}
+
+// FormatAverageFloat formats a derived daily value or ratio with the
+// average precision
+func (p Precision) FormatAverageFloat(f float64) string {
+	return formatFloat(f, p.Average)
+}
+
+// formatRatio formats an exact rational with the given number of decimal
+// places, rounding half away from zero
+func formatRatio(r *big.Rat, places int) string {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil)
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(scale))
+	n := roundRat(scaled)
+
+	sign := ""
+	if n.Sign() < 0 {
+		sign = "-"
+		n.Neg(n)
+	}
+	digits := n.String()
+	if places == 0 {
+		return sign + digits
+	}
+	if len(digits) <= places {
+		digits = strings.Repeat("0", places-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-places] + "." + digits[len(digits)-places:]
+}
+
+// roundRat rounds a rational to the nearest integer, half away from zero
+func roundRat(r *big.Rat) *big.Int {
+	num := new(big.Int).Abs(r.Num())
+	den := r.Denom()
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if new(big.Int).Mul(rem, big.NewInt(2)).Cmp(den) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if r.Sign() < 0 {
+		q.Neg(q)
+	}
+	return q
+}
@@ -0,0 +1,113 @@
+package bom
+
+import "testing"
+
+// mm returns a depth in millimetres as an Amount
+func mm(v float64) Amount {
+	return AmountFromFloat(v)
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Amount
+	}{
+		{"0", 0},
+		{"0.2", 20},
+		{"12.5", 1250},
+		{" 3.05 ", 305},
+		{"1.50", 150},
+		{"1.500", 150},
+		{"-1.25", -125},
+		{"100", 10000},
+	}
+	for _, tt := range tests {
+		got, err := ParseAmount(tt.value)
+		if err != nil {
+			t.Errorf("ParseAmount(%q) returned error: %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseAmount(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "abc", "1/2", "1.2.3", "1.005", "-0.001", "92233720368547758.08", "1e30"} {
+		if _, err := ParseAmount(value); err == nil {
+			t.Errorf("Expected error for %q", value)
+		}
+	}
+}
+
+func TestAmount_SumIsExact(t *testing.T) {
+	// 0.1 + 0.2 is not exactly 0.3 in float64
+	var total Amount
+	for _, rainfall := range []float64{0.1, 0.2} {
+		total += mm(rainfall)
+	}
+	if got := total.Format(12); got != "0.300000000000" {
+		t.Errorf("Expected exact total 0.300000000000, got %s", got)
+	}
+}
+
+func TestAmount_Format(t *testing.T) {
+	tests := []struct {
+		amount Amount
+		places int
+		want   string
+	}{
+		{37420, 1, "374.2"},
+		{5, 1, "0.1"},
+		{-5, 1, "-0.1"},
+		{4, 1, "0.0"},
+		{1250, 0, "13"},
+		{0, 3, "0.000"},
+		{305, 12, "3.050000000000"},
+	}
+	for _, tt := range tests {
+		if got := tt.amount.Format(tt.places); got != tt.want {
+			t.Errorf("Amount(%d).Format(%d) = %s, want %s", tt.amount, tt.places, got, tt.want)
+		}
+	}
+}
+
+func TestParsePrecision(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Precision
+	}{
+		{"legacy", LegacyPrecision},
+		{"BOM", BOMPrecision},
+		{"2", Precision{Amount: 2, Average: 2}},
+	}
+	for _, tt := range tests {
+		got, err := ParsePrecision(tt.value)
+		if err != nil {
+			t.Errorf("ParsePrecision(%q) returned error: %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePrecision(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "exact", "-1", "21"} {
+		if _, err := ParsePrecision(value); err == nil {
+			t.Errorf("Expected error for %q", value)
+		}
+	}
+}
+
+func TestPrecision_FormatMeanAndRatio(t *testing.T) {
+	// 374.2 mm over 109 days, as published by BOM
+	if got := BOMPrecision.FormatMean(37420, 109); got != "3.433027523" {
+		t.Errorf("Expected mean 3.433027523, got %s", got)
+	}
+	if got := BOMPrecision.FormatMean(0, 0); got != "0.000000000" {
+		t.Errorf("Expected zero mean for no days, got %s", got)
+	}
+	// Midpoint of 0.2 and 0.3 rounds half away from zero
+	if got := BOMPrecision.FormatAmountRatio(50, 2); got != "0.3" {
+		t.Errorf("Expected midpoint 0.3, got %s", got)
+	}
+}
@@ -0,0 +1,356 @@
+package bom
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChartType selects the chart drawn by NewChart
+type ChartType string
+
+const (
+	// ChartAnnual is a bar chart of annual totals
+	ChartAnnual ChartType = "annual"
+	// ChartMonthlyBox is a box plot of each calendar month's totals across
+	// years
+	ChartMonthlyBox ChartType = "boxplot"
+	// ChartCumulative is a year-to-date cumulative curve against the
+	// long-term average
+	ChartCumulative ChartType = "cumulative"
+	// ChartHeatmap is a calendar heatmap of a year's daily rainfall
+	ChartHeatmap ChartType = "heatmap"
+)
+
+// chartTypes lists the chart types in the order they are documented
+var chartTypes = []ChartType{ChartAnnual, ChartMonthlyBox, ChartCumulative, ChartHeatmap}
+
+// ParseChartType converts a CLI value into a ChartType
+func ParseChartType(value string) (ChartType, error) {
+	for _, t := range chartTypes {
+		if string(t) == value {
+			return t, nil
+		}
+	}
+	names := make([]string, len(chartTypes))
+	for i, t := range chartTypes {
+		names[i] = string(t)
+	}
+	return "", fmt.Errorf("unknown chart type '%s' (expected %s)", value, strings.Join(names, ", "))
+}
+
+// Chart is a chart that can be rendered as SVG
+type Chart interface {
+	WriteSVG(w io.Writer, style ChartStyle) error
+}
+
+// ChartOptions configures a chart built from daily records
+type ChartOptions struct {
+	Type ChartType
+	// Labels replace the chart's default title and axis labels when set
+	Labels ChartLabels
+	// YMax sets the top of the y axis, or the rainfall drawn at full colour
+	// in a heatmap. Zero fits the data.
+	YMax float64
+	// Year selects the year of cumulative and heatmap charts. Zero uses the
+	// latest year with a reading.
+	Year int
+	// MinCompleteness is the minimum percentage of days recorded for a year
+	// or month to be used in averages and box plots. Annual totals of years
+	// below it are muted.
+	MinCompleteness float64
+	// Clock determines which months and years have finished. A nil Clock
+	// uses the system time.
+	Clock Clock
+	// Style sets the size, colours and font of the chart
+	Style ChartStyle
+}
+
+// DefaultChartOptions returns options for an annual totals chart that
+// leaves years and months under 90% complete out of its averages
+func DefaultChartOptions() ChartOptions {
+	return ChartOptions{
+		Type:            ChartAnnual,
+		MinCompleteness: 90,
+		Clock:           SystemClock{},
+		Style:           DefaultChartStyle(),
+	}
+}
+
+// today returns the current day of the options' clock
+func (o ChartOptions) today() time.Time {
+	if o.Clock == nil {
+		return truncateToDay(time.Now())
+	}
+	return truncateToDay(o.Clock.Now())
+}
+
+// NewChart builds the chart selected by options.Type from daily records
+func NewChart(records []DailyRecord, options ChartOptions) (Chart, error) {
+	switch options.Type {
+	case ChartAnnual:
+		return annualChart(records, options)
+	case ChartMonthlyBox:
+		return monthlyBoxPlot(records, options)
+	case ChartCumulative:
+		return cumulativeChart(records, options)
+	case ChartHeatmap:
+		return heatmapChart(records, options)
+	}
+	return nil, fmt.Errorf("unknown chart type '%s'", options.Type)
+}
+
+// chartTitle names the station of the records in a default title
+func chartTitle(title string, records []DailyRecord) string {
+	if station := recordStation(records); station != "" {
+		return title + " at station " + station
+	}
+	return title
+}
+
+// annualChart draws the total of each year, muting years that are
+// incomplete or unfinished, with the mean of the other years as a reference
+func annualChart(records []DailyRecord, options ChartOptions) (BarChart, error) {
+	type yearTotal struct {
+		total    Amount
+		recorded int
+	}
+	var years []int
+	byYear := make(map[int]*yearTotal)
+	for _, m := range MonthlyTotals(records, options.today()) {
+		y, ok := byYear[m.Year]
+		if !ok {
+			y = &yearTotal{}
+			byYear[m.Year] = y
+			years = append(years, m.Year)
+		}
+		y.total += m.Total
+		y.recorded += m.Completeness.RecordedDays
+	}
+	if len(years) == 0 {
+		return BarChart{}, fmt.Errorf("no finished months to chart")
+	}
+
+	chart := BarChart{ChartLabels: ChartLabels{
+		Title:  chartTitle("Annual rainfall", records),
+		YLabel: "Rainfall (mm)",
+	}}
+	var complete RunningMoments
+	for _, year := range years {
+		y := byYear[year]
+		c := Completeness{ExpectedDays: daysInYear(year), RecordedDays: y.recorded}
+		muted := year >= options.today().Year() || c.Percent() < options.MinCompleteness
+		chart.Bars = append(chart.Bars, Bar{Label: strconv.Itoa(year), Value: y.total.Float64(), Muted: muted})
+		if !muted {
+			complete.Add(y.total.Float64())
+		}
+	}
+	if complete.Count() > 0 {
+		chart.Reference = complete.Mean()
+		chart.ReferenceLabel = "Mean " + BOMPrecision.FormatDepthFloat(complete.Mean()) + " mm"
+	}
+	chart.ChartLabels.override(options.Labels)
+	chart.YMax = options.YMax
+	return chart, nil
+}
+
+// monthlyBoxPlot draws the spread of each calendar month's total over the
+// years in which that month was complete
+func monthlyBoxPlot(records []DailyRecord, options ChartOptions) (BoxPlot, error) {
+	var byMonth [13][]float64
+	found := false
+	for _, m := range MonthlyTotals(records, options.today()) {
+		if m.IsComplete(options.MinCompleteness) {
+			byMonth[m.Month] = append(byMonth[m.Month], m.Total.Float64())
+			found = true
+		}
+	}
+	if !found {
+		return BoxPlot{}, fmt.Errorf("no complete months to chart")
+	}
+
+	chart := BoxPlot{ChartLabels: ChartLabels{
+		Title:  chartTitle("Monthly rainfall", records),
+		YLabel: "Rainfall (mm)",
+	}}
+	for m := time.January; m <= time.December; m++ {
+		chart.Boxes = append(chart.Boxes, NewBox(m.String()[:3], byMonth[m]))
+	}
+	chart.ChartLabels.override(options.Labels)
+	chart.YMax = options.YMax
+	return chart, nil
+}
+
+// cumulativeDays is the length of the leap-year calendar that cumulative
+// curves are drawn on, so that every day of every year has a position
+const cumulativeDays = 366
+
+// cumulativeChart draws the rainfall accumulated through a year against the
+// mean accumulation of the finished years that are complete enough
+func cumulativeChart(records []DailyRecord, options ChartOptions) (LineChart, error) {
+	today := options.today()
+	year, err := chartYear(records, options.Year, today)
+	if err != nil {
+		return LineChart{}, err
+	}
+	series := NewDailySeries(records)
+
+	selected, _ := cumulativeCurve(series, year, today)
+	// The curve ends at the year's last reading
+	var last int
+	for i := 0; i < cumulativeDays; i++ {
+		if date, ok := leapCalendarDate(year, i); ok && !date.After(today) {
+			if _, valid := seriesValue(series, date); valid {
+				last = i
+			}
+		}
+	}
+
+	chart := LineChart{
+		ChartLabels: ChartLabels{
+			Title:  chartTitle("Cumulative rainfall in "+strconv.Itoa(year), records),
+			YLabel: "Rainfall (mm)",
+		},
+		XMax: cumulativeDays,
+		YMax: options.YMax,
+	}
+	for m := time.January; m <= time.December; m++ {
+		start := time.Date(2000, m, 1, 0, 0, 0, 0, time.UTC)
+		chart.XTicks = append(chart.XTicks, Tick{Value: float64(start.YearDay() - 1), Label: m.String()[:3]})
+	}
+	chart.Series = append(chart.Series, Series{Label: strconv.Itoa(year), Points: curvePoints(selected[:last+1])})
+
+	var mean [cumulativeDays]float64
+	var years []int
+	if first, ok := chartFirstYear(records); ok {
+		for y := first; y < today.Year(); y++ {
+			if y == year {
+				continue
+			}
+			curve, recorded := cumulativeCurve(series, y, today)
+			c := Completeness{ExpectedDays: daysInYear(y), RecordedDays: recorded}
+			if recorded == 0 || c.Percent() < options.MinCompleteness {
+				continue
+			}
+			for i := range mean {
+				mean[i] += curve[i]
+			}
+			years = append(years, y)
+		}
+	}
+	if len(years) > 0 {
+		for i := range mean {
+			mean[i] /= float64(len(years))
+		}
+		label := "Mean of " + strconv.Itoa(years[0])
+		if len(years) > 1 {
+			label = fmt.Sprintf("Mean of %d years, %d to %d", len(years), years[0], years[len(years)-1])
+		}
+		chart.Series = append(chart.Series, Series{Label: label, Points: curvePoints(mean[:]), Reference: true})
+	}
+	chart.ChartLabels.override(options.Labels)
+	return chart, nil
+}
+
+// cumulativeCurve returns the rainfall accumulated by the end of each day of
+// the leap-year calendar, and the number of days with a reading. In other
+// years 29 February repeats the total of the 28th. Days after today are not
+// accumulated.
+func cumulativeCurve(series DailySeries, year int, today time.Time) ([cumulativeDays]float64, int) {
+	var curve [cumulativeDays]float64
+	total, recorded := 0.0, 0
+	for i := 0; i < cumulativeDays; i++ {
+		if date, ok := leapCalendarDate(year, i); ok && !date.After(today) {
+			if v, valid := seriesValue(series, date); valid {
+				total += v
+				recorded++
+			}
+		}
+		curve[i] = total
+	}
+	return curve, recorded
+}
+
+// leapCalendarDate returns the date in year of the i-th day of a leap year,
+// or false for 29 February in other years
+func leapCalendarDate(year, i int) (time.Time, bool) {
+	day := time.Date(2000, time.January, 1+i, 0, 0, 0, 0, time.UTC)
+	date := time.Date(year, day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	return date, date.Month() == day.Month()
+}
+
+// seriesValue returns the reading of a date in the series, and whether the
+// day has one
+func seriesValue(series DailySeries, date time.Time) (float64, bool) {
+	i := daysBetween(series.Start, date)
+	if i < 0 || i >= series.Len() || !series.Valid[i] {
+		return 0, false
+	}
+	return series.Values[i].Float64(), true
+}
+
+// curvePoints turns an accumulation into points at the end of each day,
+// starting from zero
+func curvePoints(curve []float64) []Point {
+	points := []Point{{X: 0, Y: 0}}
+	for i, v := range curve {
+		points = append(points, Point{X: float64(i + 1), Y: v})
+	}
+	return points
+}
+
+// heatmapChart draws every day of a year shaded by its rainfall
+func heatmapChart(records []DailyRecord, options ChartOptions) (CalendarHeatmap, error) {
+	year, err := chartYear(records, options.Year, options.today())
+	if err != nil {
+		return CalendarHeatmap{}, err
+	}
+	chart := CalendarHeatmap{
+		ChartLabels: ChartLabels{Title: chartTitle("Daily rainfall in "+strconv.Itoa(year), records)},
+		Year:        year,
+		Max:         options.YMax,
+		Unit:        "mm",
+	}
+	for _, rec := range records {
+		if rec.Date.Year() == year {
+			chart.Days = append(chart.Days, HeatmapDay{Date: truncateToDay(rec.Date), Value: rec.Rainfall.Float64(), Valid: rec.HasData})
+		}
+	}
+	chart.ChartLabels.override(options.Labels)
+	return chart, nil
+}
+
+// chartYear returns year if it has a reading, or the latest year with a
+// reading up to today when year is zero
+func chartYear(records []DailyRecord, year int, today time.Time) (int, error) {
+	latest := 0
+	for _, rec := range records {
+		if !rec.HasData || rec.Date.After(today) {
+			continue
+		}
+		if rec.Date.Year() == year {
+			return year, nil
+		}
+		latest = max(latest, rec.Date.Year())
+	}
+	if year != 0 {
+		return 0, fmt.Errorf("no readings in %d", year)
+	}
+	if latest == 0 {
+		return 0, fmt.Errorf("no readings to chart")
+	}
+	return latest, nil
+}
+
+// chartFirstYear returns the earliest year with a reading
+func chartFirstYear(records []DailyRecord) (int, bool) {
+	first := 0
+	for _, rec := range records {
+		if rec.HasData && (first == 0 || rec.Date.Year() < first) {
+			first = rec.Date.Year()
+		}
+	}
+	return first, first != 0
+}
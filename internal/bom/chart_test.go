@@ -0,0 +1,194 @@
+package bom
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func chartTestOptions(chartType ChartType) ChartOptions {
+	options := DefaultChartOptions()
+	options.Type = chartType
+	options.Clock = FixedClock{Time: time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC)}
+	return options
+}
+
+func TestParseChartType(t *testing.T) {
+	for _, value := range []string{"annual", "boxplot", "cumulative", "heatmap"} {
+		chartType, err := ParseChartType(value)
+		if err != nil || string(chartType) != value {
+			t.Errorf("ParseChartType(%q): expected %q, got %q and %v", value, value, chartType, err)
+		}
+	}
+	if _, err := ParseChartType("pie"); err == nil || !strings.Contains(err.Error(), "annual, boxplot") {
+		t.Errorf("Expected an error listing the chart types, got %v", err)
+	}
+}
+
+func TestNewChart_Annual(t *testing.T) {
+	chart, err := NewChart(reportRecords(), chartTestOptions(ChartAnnual))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	bars, ok := chart.(BarChart)
+	if !ok {
+		t.Fatalf("Expected a BarChart, got %T", chart)
+	}
+	if bars.Title != "Annual rainfall at station 066062" || bars.YLabel != "Rainfall (mm)" {
+		t.Errorf("Unexpected labels %+v", bars.ChartLabels)
+	}
+	want := []Bar{
+		{Label: "2018", Value: 365},
+		{Label: "2019", Value: 730},
+		{Label: "2020", Value: 140, Muted: true},
+	}
+	if len(bars.Bars) != len(want) {
+		t.Fatalf("Expected %d bars, got %+v", len(want), bars.Bars)
+	}
+	for i := range want {
+		if bars.Bars[i] != want[i] {
+			t.Errorf("Bar %d: expected %+v, got %+v", i, want[i], bars.Bars[i])
+		}
+	}
+	if bars.Reference != 547.5 || bars.ReferenceLabel != "Mean 547.5 mm" {
+		t.Errorf("Expected a mean of complete years of 547.5 mm, got %g %q", bars.Reference, bars.ReferenceLabel)
+	}
+}
+
+func TestNewChart_Labels(t *testing.T) {
+	options := chartTestOptions(ChartAnnual)
+	options.Labels = ChartLabels{Title: "Sydney", XLabel: "Year"}
+	options.YMax = 1000
+	chart, err := NewChart(reportRecords(), options)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	bars := chart.(BarChart)
+	want := ChartLabels{Title: "Sydney", XLabel: "Year", YLabel: "Rainfall (mm)"}
+	if bars.ChartLabels != want || bars.YMax != 1000 {
+		t.Errorf("Expected labels %+v and a y axis to 1000, got %+v and %g", want, bars.ChartLabels, bars.YMax)
+	}
+}
+
+func TestNewChart_BoxPlot(t *testing.T) {
+	chart, err := NewChart(reportRecords(), chartTestOptions(ChartMonthlyBox))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	plot, ok := chart.(BoxPlot)
+	if !ok {
+		t.Fatalf("Expected a BoxPlot, got %T", chart)
+	}
+	if len(plot.Boxes) != 12 {
+		t.Fatalf("Expected a box per calendar month, got %d", len(plot.Boxes))
+	}
+	// January is complete in all three years, February only in 2018 and 2019
+	jan := Box{Label: "Jan", Count: 3, Min: 31, Q1: 46.5, Median: 62, Q3: 101, Max: 140}
+	if plot.Boxes[0] != jan {
+		t.Errorf("Expected %+v, got %+v", jan, plot.Boxes[0])
+	}
+	if feb := plot.Boxes[1]; feb.Label != "Feb" || feb.Count != 2 || feb.Max != 56 {
+		t.Errorf("Unexpected February box %+v", feb)
+	}
+}
+
+func TestNewChart_Cumulative(t *testing.T) {
+	chart, err := NewChart(reportRecords(), chartTestOptions(ChartCumulative))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	lines, ok := chart.(LineChart)
+	if !ok {
+		t.Fatalf("Expected a LineChart, got %T", chart)
+	}
+	if lines.Title != "Cumulative rainfall in 2020 at station 066062" || lines.XMax != 366 || len(lines.XTicks) != 12 {
+		t.Errorf("Unexpected chart %+v", lines.ChartLabels)
+	}
+	if len(lines.Series) != 2 {
+		t.Fatalf("Expected the year and the mean, got %d series", len(lines.Series))
+	}
+
+	// 2020 ends at its last reading on 31 January
+	year := lines.Series[0]
+	if year.Label != "2020" || year.Reference || len(year.Points) != 32 {
+		t.Fatalf("Unexpected 2020 series %q with %d points", year.Label, len(year.Points))
+	}
+	if last := year.Points[31]; last != (Point{X: 31, Y: 140}) {
+		t.Errorf("Expected 140 mm by the end of January, got %+v", last)
+	}
+
+	mean := lines.Series[1]
+	if mean.Label != "Mean of 2 years, 2018 to 2019" || !mean.Reference || len(mean.Points) != 367 {
+		t.Fatalf("Unexpected mean series %q with %d points", mean.Label, len(mean.Points))
+	}
+	// 29 February repeats 28 February in years that are not leap years
+	if feb28, feb29 := mean.Points[59], mean.Points[60]; feb28.Y != 88.5 || feb29.Y != 88.5 {
+		t.Errorf("Expected 88.5 mm on 28 and 29 February, got %+v and %+v", feb28, feb29)
+	}
+	if end := mean.Points[366]; end.Y != 547.5 {
+		t.Errorf("Expected a mean of 547.5 mm by the end of the year, got %+v", end)
+	}
+}
+
+func TestNewChart_CumulativeYear(t *testing.T) {
+	options := chartTestOptions(ChartCumulative)
+	options.Year = 2019
+	chart, err := NewChart(reportRecords(), options)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	lines := chart.(LineChart)
+	// 2020 has not finished, so only 2018 is averaged
+	if len(lines.Series) != 2 || lines.Series[0].Label != "2019" || lines.Series[1].Label != "Mean of 2018" {
+		t.Fatalf("Unexpected series %+v", lines.Series)
+	}
+	if end := lines.Series[0].Points[366]; end.Y != 730 {
+		t.Errorf("Expected 730 mm by the end of 2019, got %+v", end)
+	}
+}
+
+func TestNewChart_Heatmap(t *testing.T) {
+	options := chartTestOptions(ChartHeatmap)
+	options.Year = 2019
+	options.YMax = 10
+	chart, err := NewChart(reportRecords(), options)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	heatmap, ok := chart.(CalendarHeatmap)
+	if !ok {
+		t.Fatalf("Expected a CalendarHeatmap, got %T", chart)
+	}
+	if heatmap.Title != "Daily rainfall in 2019 at station 066062" || heatmap.Year != 2019 || heatmap.Max != 10 || heatmap.Unit != "mm" {
+		t.Errorf("Unexpected heatmap %+v", heatmap.ChartLabels)
+	}
+	if len(heatmap.Days) != 365 {
+		t.Fatalf("Expected every day of 2019, got %d", len(heatmap.Days))
+	}
+	if d := heatmap.Days[0]; !d.Date.Equal(day(2019, 1, 1)) || d.Value != 2 || !d.Valid {
+		t.Errorf("Unexpected first day %+v", d)
+	}
+}
+
+func TestNewChart_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []DailyRecord
+		options ChartOptions
+	}{
+		{"unknown type", reportRecords(), chartTestOptions("pie")},
+		{"no records", nil, chartTestOptions(ChartAnnual)},
+		{"no complete months", nil, chartTestOptions(ChartMonthlyBox)},
+		{"no readings", nil, chartTestOptions(ChartHeatmap)},
+		{"year without readings", reportRecords(), func() ChartOptions {
+			options := chartTestOptions(ChartCumulative)
+			options.Year = 1999
+			return options
+		}()},
+	}
+	for _, tt := range tests {
+		if _, err := NewChart(tt.records, tt.options); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
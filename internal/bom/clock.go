@@ -0,0 +1,40 @@
+package bom
+
+import (
+	"fmt"
+	"time"
+)
+
+// Clock supplies the current time so that "now"-dependent behaviour, such
+// as excluding months that have yet to occur, can be fixed in tests and
+// reproduced for past report dates
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is a Clock that returns the current system time
+type SystemClock struct{}
+
+// Now returns the current system time
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// FixedClock is a Clock that always returns the same time
+type FixedClock struct {
+	Time time.Time
+}
+
+// Now returns the fixed time
+func (c FixedClock) Now() time.Time {
+	return c.Time
+}
+
+// ParseAsOfDate parses a YYYY-MM-DD date into a FixedClock for that day
+func ParseAsOfDate(value string) (FixedClock, error) {
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return FixedClock{}, fmt.Errorf("invalid as-of date '%s' (expected YYYY-MM-DD): %w", value, err)
+	}
+	return FixedClock{Time: date}, nil
+}
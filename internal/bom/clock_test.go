@@ -0,0 +1,38 @@
+package bom
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFixedClock(t *testing.T) {
+	fixed := time.Date(2019, 4, 19, 0, 0, 0, 0, time.UTC)
+	clock := FixedClock{Time: fixed}
+	if !clock.Now().Equal(fixed) {
+		t.Errorf("Expected %v, got %v", fixed, clock.Now())
+	}
+}
+
+func TestSystemClock(t *testing.T) {
+	before := time.Now()
+	now := SystemClock{}.Now()
+	if now.Before(before) {
+		t.Errorf("Expected system clock time %v to not be before %v", now, before)
+	}
+}
+
+func TestParseAsOfDate(t *testing.T) {
+	clock, err := ParseAsOfDate("2019-04-19")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !clock.Now().Equal(time.Date(2019, 4, 19, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected as-of time %v", clock.Now())
+	}
+
+	for _, value := range []string{"19/04/2019", "2019-02-30", ""} {
+		if _, err := ParseAsOfDate(value); err == nil {
+			t.Errorf("Expected error for %q", value)
+		}
+	}
+}
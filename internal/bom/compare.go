@@ -0,0 +1,269 @@
+package bom
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// MonthComparison compares the totals of two stations for one month over
+// the days both recorded
+type MonthComparison struct {
+	Year       int
+	Month      time.Month
+	CommonDays int
+	TotalA     Amount
+	TotalB     Amount
+}
+
+// CalendarMonthComparison summarises the differences between two stations
+// for one calendar month across years
+type CalendarMonthComparison struct {
+	Month  time.Month
+	Months int
+	TotalA Amount
+	TotalB Amount
+	// MeanDifference is the mean of the monthly B minus A differences
+	MeanDifference float64
+}
+
+// StationComparison describes how station B tracks station A over the days
+// on which both have a reading
+type StationComparison struct {
+	// First and Last are the first and last days recorded at both stations
+	First      time.Time
+	Last       time.Time
+	CommonDays int
+	// OnlyA and OnlyB count the days recorded at just one of the stations
+	OnlyA int
+	OnlyB int
+	// DailyCorrelation and MonthlyCorrelation are Pearson correlations,
+	// NaN when undefined
+	DailyCorrelation   float64
+	MonthlyCorrelation float64
+	// MeanDailyBias is the mean of the daily B minus A differences in mm
+	MeanDailyBias  float64
+	TotalA         Amount
+	TotalB         Amount
+	Months         []MonthComparison
+	CalendarMonths []CalendarMonthComparison
+}
+
+// RatioOfTotals returns the total at B divided by the total at A, or NaN
+// when A recorded no rain
+func (c StationComparison) RatioOfTotals() float64 {
+	return amountRatio(c.TotalB, c.TotalA)
+}
+
+// CompareStations aligns two daily series on date and compares them over
+// the days both recorded. Monthly totals of each station are computed by
+// the Aggregator from the common days only, so that missing days at one
+// station do not bias the comparison.
+func CompareStations(a, b []DailyRecord) (StationComparison, error) {
+	recordedA := recordedDays(a)
+	recordedB := recordedDays(b)
+
+	var commonA, commonB []DailyRecord
+	var cmp StationComparison
+	for date, recA := range recordedA {
+		recB, ok := recordedB[date]
+		if !ok {
+			cmp.OnlyA++
+			continue
+		}
+		commonA = append(commonA, recA)
+		commonB = append(commonB, recB)
+	}
+	cmp.OnlyB = len(recordedB) - len(commonA)
+	cmp.CommonDays = len(commonA)
+	if cmp.CommonDays == 0 {
+		return StationComparison{}, fmt.Errorf("the stations have no days recorded in common")
+	}
+
+	sort.Slice(commonA, func(i, j int) bool { return commonA[i].Date.Before(commonA[j].Date) })
+	sort.Slice(commonB, func(i, j int) bool { return commonB[i].Date.Before(commonB[j].Date) })
+	cmp.First = commonA[0].Date
+	cmp.Last = commonA[len(commonA)-1].Date
+
+	dailyA := make([]float64, len(commonA))
+	dailyB := make([]float64, len(commonB))
+	var bias RunningMoments
+	for i := range commonA {
+		dailyA[i] = commonA[i].Rainfall.Float64()
+		dailyB[i] = commonB[i].Rainfall.Float64()
+		bias.Add(dailyB[i] - dailyA[i])
+		cmp.TotalA += commonA[i].Rainfall
+		cmp.TotalB += commonB[i].Rainfall
+	}
+	cmp.DailyCorrelation = pearson(dailyA, dailyB)
+	cmp.MeanDailyBias = bias.Mean()
+
+	months, err := compareMonths(commonA, commonB)
+	if err != nil {
+		return StationComparison{}, err
+	}
+	cmp.Months = months
+
+	monthlyA := make([]float64, len(months))
+	monthlyB := make([]float64, len(months))
+	var byMonth [13]CalendarMonthComparison
+	var differences [13]RunningMoments
+	for i, m := range months {
+		monthlyA[i] = m.TotalA.Float64()
+		monthlyB[i] = m.TotalB.Float64()
+		c := &byMonth[m.Month]
+		c.Month = m.Month
+		c.Months++
+		c.TotalA += m.TotalA
+		c.TotalB += m.TotalB
+		differences[m.Month].Add((m.TotalB - m.TotalA).Float64())
+	}
+	cmp.MonthlyCorrelation = pearson(monthlyA, monthlyB)
+	for m := time.January; m <= time.December; m++ {
+		if byMonth[m].Months == 0 {
+			continue
+		}
+		byMonth[m].MeanDifference = differences[m].Mean()
+		cmp.CalendarMonths = append(cmp.CalendarMonths, byMonth[m])
+	}
+	return cmp, nil
+}
+
+// recordedDays indexes the records that have an observed reading by day.
+// Imputed days are left out, since they were not recorded.
+func recordedDays(records []DailyRecord) map[time.Time]DailyRecord {
+	days := make(map[time.Time]DailyRecord, len(records))
+	for _, rec := range records {
+		if rec.observed() {
+			rec.Date = truncateToDay(rec.Date)
+			days[rec.Date] = rec
+		}
+	}
+	return days
+}
+
+// compareMonths aggregates the common days of each station and pairs their
+// monthly totals. The records must cover the same days.
+func compareMonths(commonA, commonB []DailyRecord) ([]MonthComparison, error) {
+	// Every common day has already occurred at both stations, so the clock
+	// is set to the last of them
+	options := DefaultAggregatorOptions()
+	options.Clock = FixedClock{Time: commonA[len(commonA)-1].Date}
+	aggregator := NewAggregatorWithOptions(options)
+	dataA := aggregator.Aggregate(commonA)
+	dataB := aggregator.Aggregate(commonB)
+
+	var months []MonthComparison
+	for i, yearA := range dataA.WeatherDataForYear {
+		yearB := dataB.WeatherDataForYear[i]
+		year, err := strconv.Atoi(yearA.Year)
+		if err != nil {
+			return nil, fmt.Errorf("invalid year '%s': %w", yearA.Year, err)
+		}
+		for j, monthA := range yearA.MonthlyAggregates.WeatherDataForMonth {
+			monthB := yearB.MonthlyAggregates.WeatherDataForMonth[j]
+			month, err := parseMonth(monthA.Month)
+			if err != nil {
+				return nil, err
+			}
+			days, err := strconv.Atoi(monthA.RecordedDays)
+			if err != nil {
+				return nil, fmt.Errorf("invalid recorded days '%s': %w", monthA.RecordedDays, err)
+			}
+			totalA, err := ParseAmount(monthA.TotalRainfall)
+			if err != nil {
+				return nil, err
+			}
+			totalB, err := ParseAmount(monthB.TotalRainfall)
+			if err != nil {
+				return nil, err
+			}
+			months = append(months, MonthComparison{
+				Year:       year,
+				Month:      month,
+				CommonDays: days,
+				TotalA:     totalA,
+				TotalB:     totalB,
+			})
+		}
+	}
+	return months, nil
+}
+
+// pearson returns the Pearson correlation of two equal-length series, or
+// NaN when either series is constant or there are fewer than two values
+func pearson(x, y []float64) float64 {
+	if len(x) < 2 {
+		return math.NaN()
+	}
+	var mx, my RunningMoments
+	for i := range x {
+		mx.Add(x[i])
+		my.Add(y[i])
+	}
+	var sxy, sxx, syy float64
+	for i := range x {
+		dx := x[i] - mx.Mean()
+		dy := y[i] - my.Mean()
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return math.NaN()
+	}
+	return sxy / math.Sqrt(sxx*syy)
+}
+
+// amountRatio returns a divided by b, or NaN when b is zero
+func amountRatio(a, b Amount) float64 {
+	if b == 0 {
+		return math.NaN()
+	}
+	return float64(a) / float64(b)
+}
+
+// NewComparisonData converts a station comparison into its JSON output structure
+func NewComparisonData(cmp StationComparison, nameA, nameB string) ComparisonData {
+	data := ComparisonData{
+		StationA: nameA,
+		StationB: nameB,
+		Overlap: OverlapData{
+			FirstDate:  cmp.First.Format("2006-01-02"),
+			LastDate:   cmp.Last.Format("2006-01-02"),
+			CommonDays: strconv.Itoa(cmp.CommonDays),
+			OnlyA:      strconv.Itoa(cmp.OnlyA),
+			OnlyB:      strconv.Itoa(cmp.OnlyB),
+		},
+		DailyCorrelation:   formatOptionalFloat(cmp.DailyCorrelation, 4),
+		MonthlyCorrelation: formatOptionalFloat(cmp.MonthlyCorrelation, 4),
+		MeanDailyBias:      formatFloat(cmp.MeanDailyBias, 4),
+		TotalRainfallA:     cmp.TotalA.Format(1),
+		TotalRainfallB:     cmp.TotalB.Format(1),
+		RatioOfTotals:      formatOptionalFloat(cmp.RatioOfTotals(), 4),
+	}
+	for _, c := range cmp.CalendarMonths {
+		data.CalendarMonths = append(data.CalendarMonths, CalendarMonthComparisonData{
+			Month:          c.Month.String(),
+			Months:         strconv.Itoa(c.Months),
+			TotalRainfallA: c.TotalA.Format(1),
+			TotalRainfallB: c.TotalB.Format(1),
+			MeanDifference: formatFloat(c.MeanDifference, 1),
+			RatioOfTotals:  formatOptionalFloat(amountRatio(c.TotalB, c.TotalA), 4),
+		})
+	}
+	for _, m := range cmp.Months {
+		data.Monthly = append(data.Monthly, MonthComparisonData{
+			Year:           strconv.Itoa(m.Year),
+			Month:          m.Month.String(),
+			CommonDays:     strconv.Itoa(m.CommonDays),
+			TotalRainfallA: m.TotalA.Format(1),
+			TotalRainfallB: m.TotalB.Format(1),
+			Difference:     (m.TotalB - m.TotalA).Format(1),
+			Ratio:          formatOptionalFloat(amountRatio(m.TotalB, m.TotalA), 4),
+		})
+	}
+	return data
+}
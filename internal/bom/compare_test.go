@@ -0,0 +1,134 @@
+package bom
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCompareStations(t *testing.T) {
+	a := []DailyRecord{
+		{Date: day(2020, 1, 30), Rainfall: mm(1.0), HasData: true},
+		{Date: day(2020, 1, 31), Rainfall: mm(3.0), HasData: true},
+		{Date: day(2020, 2, 1), Rainfall: mm(0.0), HasData: true},
+		{Date: day(2020, 2, 2), Rainfall: mm(4.0), HasData: true},
+		{Date: day(2020, 2, 3), Rainfall: mm(9.9), HasData: true},
+		{Date: day(2020, 2, 4), HasData: false},
+	}
+	b := []DailyRecord{
+		{Date: day(2020, 1, 29), Rainfall: mm(7.0), HasData: true},
+		{Date: day(2020, 2, 2), Rainfall: mm(5.0), HasData: true},
+		{Date: day(2020, 1, 30), Rainfall: mm(2.0), HasData: true},
+		{Date: day(2020, 1, 31), Rainfall: mm(4.0), HasData: true},
+		{Date: day(2020, 2, 1), Rainfall: mm(0.5), HasData: true},
+		{Date: day(2020, 2, 4), Rainfall: mm(2.0), HasData: true},
+	}
+
+	cmp, err := CompareStations(a, b)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if !cmp.First.Equal(day(2020, 1, 30)) || !cmp.Last.Equal(day(2020, 2, 2)) {
+		t.Errorf("Expected overlap 2020-01-30 to 2020-02-02, got %v to %v", cmp.First, cmp.Last)
+	}
+	if cmp.CommonDays != 4 || cmp.OnlyA != 1 || cmp.OnlyB != 2 {
+		t.Errorf("Expected 4 common, 1 only A and 2 only B days, got %d, %d and %d", cmp.CommonDays, cmp.OnlyA, cmp.OnlyB)
+	}
+	if cmp.TotalA != 800 || cmp.TotalB != 1150 {
+		t.Errorf("Expected totals 8.0 and 11.5, got %s and %s", cmp.TotalA.Format(1), cmp.TotalB.Format(1))
+	}
+	if !approxEqual(cmp.RatioOfTotals(), 11.5/8.0, 1e-12) {
+		t.Errorf("Expected ratio of totals %g, got %g", 11.5/8.0, cmp.RatioOfTotals())
+	}
+	if !approxEqual(cmp.MeanDailyBias, 3.5/4, 1e-12) {
+		t.Errorf("Expected mean daily bias 0.875, got %g", cmp.MeanDailyBias)
+	}
+	// A = 1, 3, 0, 4 and B = 2, 4, 0.5, 5 differ by a near-constant offset
+	if !approxEqual(cmp.DailyCorrelation, 11/math.Sqrt(121.875), 1e-12) {
+		t.Errorf("Expected daily correlation 0.9964, got %g", cmp.DailyCorrelation)
+	}
+	// Both monthly totals at A are 4.0, so the correlation is undefined
+	if !math.IsNaN(cmp.MonthlyCorrelation) {
+		t.Errorf("Expected an undefined monthly correlation, got %g", cmp.MonthlyCorrelation)
+	}
+
+	want := []MonthComparison{
+		{Year: 2020, Month: time.January, CommonDays: 2, TotalA: 400, TotalB: 600},
+		{Year: 2020, Month: time.February, CommonDays: 2, TotalA: 400, TotalB: 550},
+	}
+	if len(cmp.Months) != len(want) {
+		t.Fatalf("Expected %d months, got %+v", len(want), cmp.Months)
+	}
+	for i := range want {
+		if cmp.Months[i] != want[i] {
+			t.Errorf("Month %d: expected %+v, got %+v", i, want[i], cmp.Months[i])
+		}
+	}
+
+	if len(cmp.CalendarMonths) != 2 {
+		t.Fatalf("Expected 2 calendar months, got %+v", cmp.CalendarMonths)
+	}
+	if jan := cmp.CalendarMonths[0]; jan.Month != time.January || jan.Months != 1 || jan.MeanDifference != 2 {
+		t.Errorf("Unexpected January summary %+v", jan)
+	}
+}
+
+func TestCompareStations_NoOverlap(t *testing.T) {
+	a := []DailyRecord{{Date: day(2020, 1, 1), Rainfall: mm(1), HasData: true}}
+	b := []DailyRecord{
+		{Date: day(2020, 1, 1), HasData: false},
+		{Date: day(2020, 1, 2), Rainfall: mm(1), HasData: true},
+	}
+	if _, err := CompareStations(a, b); err == nil {
+		t.Error("Expected an error for stations without common days")
+	}
+}
+
+func TestPearson(t *testing.T) {
+	if r := pearson([]float64{1, 2, 3}, []float64{6, 4, 2}); !approxEqual(r, -1, 1e-12) {
+		t.Errorf("Expected -1, got %g", r)
+	}
+	if r := pearson([]float64{1, 2, 3}, []float64{5, 5, 5}); !math.IsNaN(r) {
+		t.Errorf("Expected NaN for a constant series, got %g", r)
+	}
+	if r := pearson([]float64{1}, []float64{2}); !math.IsNaN(r) {
+		t.Errorf("Expected NaN for a single value, got %g", r)
+	}
+}
+
+func TestNewComparisonData(t *testing.T) {
+	cmp := StationComparison{
+		First:              day(2020, 1, 1),
+		Last:               day(2020, 1, 31),
+		CommonDays:         31,
+		OnlyA:              2,
+		DailyCorrelation:   0.91234,
+		MonthlyCorrelation: math.NaN(),
+		MeanDailyBias:      -0.125,
+		TotalA:             1000,
+		TotalB:             0,
+		Months:             []MonthComparison{{Year: 2020, Month: time.January, CommonDays: 31, TotalA: 1000}},
+		CalendarMonths:     []CalendarMonthComparison{{Month: time.January, Months: 1, TotalA: 1000, MeanDifference: -10}},
+	}
+
+	data := NewComparisonData(cmp, "a.csv", "b.csv")
+	if data.StationA != "a.csv" || data.StationB != "b.csv" {
+		t.Errorf("Unexpected station names %q and %q", data.StationA, data.StationB)
+	}
+	if data.Overlap.FirstDate != "2020-01-01" || data.Overlap.CommonDays != "31" || data.Overlap.OnlyA != "2" || data.Overlap.OnlyB != "0" {
+		t.Errorf("Unexpected overlap %+v", data.Overlap)
+	}
+	if data.DailyCorrelation != "0.9123" || data.MonthlyCorrelation != "" || data.MeanDailyBias != "-0.1250" {
+		t.Errorf("Unexpected summary %+v", data)
+	}
+	if data.RatioOfTotals != "0.0000" || data.TotalRainfallA != "10.0" || data.TotalRainfallB != "0.0" {
+		t.Errorf("Unexpected totals %+v", data)
+	}
+	if len(data.Monthly) != 1 || data.Monthly[0].Difference != "-10.0" || data.Monthly[0].Month != "January" {
+		t.Errorf("Unexpected monthly table %+v", data.Monthly)
+	}
+	if len(data.CalendarMonths) != 1 || data.CalendarMonths[0].MeanDifference != "-10.0" {
+		t.Errorf("Unexpected calendar months %+v", data.CalendarMonths)
+	}
+}
@@ -0,0 +1,104 @@
+package bom
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// IncompleteAction controls what happens to the statistics of a period that
+// falls below the minimum completeness
+type IncompleteAction string
+
+const (
+	// IncompleteFlag keeps the statistics and marks the period as unreliable
+	IncompleteFlag IncompleteAction = "flag"
+	// IncompleteOmit marks the period as unreliable and leaves its statistics
+	// out of the output, as BOM does for incomplete months
+	IncompleteOmit IncompleteAction = "omit"
+)
+
+// ParseIncompleteAction converts a CLI value into an IncompleteAction
+func ParseIncompleteAction(value string) (IncompleteAction, error) {
+	switch action := IncompleteAction(value); action {
+	case IncompleteFlag, IncompleteOmit:
+		return action, nil
+	default:
+		return "", fmt.Errorf("unknown incomplete action '%s' (expected flag or omit)", value)
+	}
+}
+
+// Completeness describes how many of the expected days in a period were recorded
+type Completeness struct {
+	ExpectedDays int
+	RecordedDays int
+}
+
+// Percent returns the percentage of expected days that were recorded
+func (c Completeness) Percent() float64 {
+	if c.ExpectedDays == 0 {
+		return 0
+	}
+	return 100 * float64(c.RecordedDays) / float64(c.ExpectedDays)
+}
+
+// daysIn returns the number of days in the given month
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// expectedDaysInMonth returns the number of days in a month that have
+// occurred as of now: the whole month for past months, and up to and
+// including today for the current month
+func expectedDaysInMonth(year int, month time.Month, now time.Time) int {
+	if year == now.Year() && month == now.Month() {
+		return now.Day()
+	}
+	return daysIn(year, month)
+}
+
+// isUnreliable reports whether a period fails the configured completeness check
+func (o AggregatorOptions) isUnreliable(c Completeness) bool {
+	return o.MinCompleteness > 0 && c.Percent() < o.MinCompleteness
+}
+
+// applyYearCompleteness records completeness on a year and applies the
+// configured action if the year is incomplete
+func (o AggregatorOptions) applyYearCompleteness(data *WeatherDataForYear, c Completeness) {
+	data.ExpectedDays = strconv.Itoa(c.ExpectedDays)
+	data.RecordedDays = strconv.Itoa(c.RecordedDays)
+	data.PercentComplete = formatFloat(c.Percent(), 1)
+
+	if !o.isUnreliable(c) {
+		return
+	}
+	data.Unreliable = "true"
+	if o.IncompleteAction == IncompleteOmit {
+		clearStatistics(yearFields(data))
+		data.AdditionalStatistics = nil
+	}
+}
+
+// applyMonthCompleteness records completeness on a month and applies the
+// configured action if the month is incomplete
+func (o AggregatorOptions) applyMonthCompleteness(data *WeatherDataForMonth, c Completeness) {
+	data.ExpectedDays = strconv.Itoa(c.ExpectedDays)
+	data.RecordedDays = strconv.Itoa(c.RecordedDays)
+	data.PercentComplete = formatFloat(c.Percent(), 1)
+
+	if !o.isUnreliable(c) {
+		return
+	}
+	data.Unreliable = "true"
+	if o.IncompleteAction == IncompleteOmit {
+		clearStatistics(monthFields(data))
+		data.AdditionalStatistics = nil
+	}
+}
+
+// clearStatistics empties the statistic fields of an omitted period
+func clearStatistics(fields map[string]*string) {
+	for _, field := range fields {
+		*field = ""
+	}
+}
@@ -0,0 +1,130 @@
+package bom
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseIncompleteAction(t *testing.T) {
+	for _, value := range []string{"flag", "omit"} {
+		if _, err := ParseIncompleteAction(value); err != nil {
+			t.Errorf("Expected %q to be valid, got: %v", value, err)
+		}
+	}
+	if _, err := ParseIncompleteAction("drop"); err == nil {
+		t.Error("Expected error for unknown action")
+	}
+}
+
+func TestCompletenessPercent(t *testing.T) {
+	c := Completeness{ExpectedDays: 31, RecordedDays: 31}
+	if c.Percent() != 100 {
+		t.Errorf("Expected 100%%, got %v", c.Percent())
+	}
+	if (Completeness{}).Percent() != 0 {
+		t.Error("Expected 0% when no days are expected")
+	}
+}
+
+func TestExpectedDaysInMonth(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	if got := expectedDaysInMonth(2024, time.February, now); got != 29 {
+		t.Errorf("Expected 29 days in February 2024, got %d", got)
+	}
+	if got := expectedDaysInMonth(2023, time.February, now); got != 28 {
+		t.Errorf("Expected 28 days in February 2023, got %d", got)
+	}
+	if got := expectedDaysInMonth(2024, time.March, now); got != 10 {
+		t.Errorf("Expected 10 elapsed days in the current month, got %d", got)
+	}
+}
+
+func TestAggregate_Completeness(t *testing.T) {
+	agg := NewAggregator()
+	records := []DailyRecord{
+		{Date: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(1.0), HasData: true},
+		{Date: time.Date(2020, 2, 2, 0, 0, 0, 0, time.UTC), Rainfall: mm(0.0), HasData: false},
+		{Date: time.Date(2020, 2, 3, 0, 0, 0, 0, time.UTC), Rainfall: mm(0.0), HasData: true},
+	}
+
+	yearData := agg.Aggregate(records).WeatherDataForYear[0]
+
+	if yearData.ExpectedDays != "366" || yearData.RecordedDays != "2" || yearData.PercentComplete != "0.5" {
+		t.Errorf("Unexpected year completeness: expected=%s recorded=%s percent=%s",
+			yearData.ExpectedDays, yearData.RecordedDays, yearData.PercentComplete)
+	}
+	if yearData.Unreliable != "" {
+		t.Error("Expected no unreliable flag when the check is disabled")
+	}
+
+	feb := yearData.MonthlyAggregates.WeatherDataForMonth[0]
+	if feb.ExpectedDays != "29" || feb.RecordedDays != "2" || feb.PercentComplete != "6.9" {
+		t.Errorf("Unexpected month completeness: expected=%s recorded=%s percent=%s",
+			feb.ExpectedDays, feb.RecordedDays, feb.PercentComplete)
+	}
+}
+
+func TestAggregate_IncompleteFlag(t *testing.T) {
+	agg := NewAggregatorWithOptions(AggregatorOptions{MinCompleteness: 90, IncompleteAction: IncompleteFlag})
+	records := []DailyRecord{
+		{Date: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(1.0), HasData: true},
+	}
+
+	yearData := agg.Aggregate(records).WeatherDataForYear[0]
+	feb := yearData.MonthlyAggregates.WeatherDataForMonth[0]
+
+	if yearData.Unreliable != "true" || feb.Unreliable != "true" {
+		t.Errorf("Expected year and month to be flagged unreliable, got %q and %q", yearData.Unreliable, feb.Unreliable)
+	}
+	if feb.TotalRainfall != "1.000000000000" {
+		t.Errorf("Expected flagged statistics to be kept, got total %q", feb.TotalRainfall)
+	}
+}
+
+func TestAggregate_IncompleteOmit(t *testing.T) {
+	agg := NewAggregatorWithOptions(AggregatorOptions{MinCompleteness: 90, IncompleteAction: IncompleteOmit})
+	var records []DailyRecord
+	// January is complete, February has a single reading
+	for d := 1; d <= 31; d++ {
+		records = append(records, DailyRecord{Date: time.Date(2020, 1, d, 0, 0, 0, 0, time.UTC), Rainfall: mm(1.0), HasData: true})
+	}
+	records = append(records, DailyRecord{Date: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(1.0), HasData: true})
+
+	yearData := agg.Aggregate(records).WeatherDataForYear[0]
+	jan := yearData.MonthlyAggregates.WeatherDataForMonth[0]
+	feb := yearData.MonthlyAggregates.WeatherDataForMonth[1]
+
+	if jan.Unreliable != "" || jan.TotalRainfall != "31.000000000000" {
+		t.Errorf("Expected complete January to keep its statistics, got %+v", jan)
+	}
+	if feb.Unreliable != "true" || feb.TotalRainfall != "" || feb.MedianDailyRainfall != "" || feb.DaysWithRainfall != "" {
+		t.Errorf("Expected incomplete February statistics to be omitted, got %+v", feb)
+	}
+	if feb.RecordedDays != "1" || feb.FirstRecordedDate != "2020-02-01" {
+		t.Errorf("Expected completeness and dates to be kept, got %+v", feb)
+	}
+	if yearData.Unreliable != "true" || yearData.TotalRainfall != "" || yearData.LongestDaysRaining != "" {
+		t.Errorf("Expected incomplete year statistics to be omitted, got %+v", yearData)
+	}
+}
+
+func TestAggregate_IncompleteOmitClearsEveryStatistic(t *testing.T) {
+	agg := NewAggregatorWithOptions(AggregatorOptions{MinCompleteness: 90, IncompleteAction: IncompleteOmit, Statistics: AllStatistics()})
+	records := []DailyRecord{
+		{Date: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(1.0), HasData: true},
+		{Date: time.Date(2020, 2, 2, 0, 0, 0, 0, time.UTC), Rainfall: mm(3.0), HasData: true},
+	}
+
+	yearData := agg.Aggregate(records).WeatherDataForYear[0]
+	for name, field := range yearFields(&yearData) {
+		if *field != "" {
+			t.Errorf("Expected the omitted year's %s to be empty, got %s", name, *field)
+		}
+	}
+	for name, field := range monthFields(&yearData.MonthlyAggregates.WeatherDataForMonth[0]) {
+		if *field != "" {
+			t.Errorf("Expected the omitted month's %s to be empty, got %s", name, *field)
+		}
+	}
+}
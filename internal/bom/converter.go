@@ -1,26 +1,378 @@
 package bom
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 )
 
-// Converter handles conversion of WeatherData to JSON
+// Converter handles conversion of WeatherData and derived series to output formats
 // Only responsible for conversion and error wrapping.
-type Converter struct{}
+type Converter struct {
+	schema  SchemaVersion
+	format  string
+	encoder Encoder
+}
+
+// ConverterOptions configures a Converter
+type ConverterOptions struct {
+	// Schema selects the layout of weather data JSON. The zero value is
+	// SchemaV1.
+	Schema SchemaVersion
+	// Format names the output format of Encode. The empty string selects
+	// FormatJSON.
+	Format string
+	// Encoders provides the output formats. A nil registry uses
+	// DefaultEncoders.
+	Encoders *EncoderRegistry
+	// Width is the line width that FormatTable fits its tables to. Zero
+	// uses DefaultTableWidth.
+	Width int
+}
 
 // NewConverter creates a new Converter
 func NewConverter() *Converter {
-	return &Converter{}
+	return NewConverterWithOptions(ConverterOptions{})
+}
+
+// NewConverterWithOptions creates a new Converter configured by options.
+// An unknown format is reported when Encode is called.
+func NewConverterWithOptions(options ConverterOptions) *Converter {
+	encoders := options.Encoders
+	if encoders == nil {
+		encoders = DefaultEncoders()
+	}
+	format := options.Format
+	if format == "" {
+		format = FormatJSON
+	}
+	c := &Converter{schema: options.Schema, format: format}
+	if factory, err := encoders.Lookup(format); err == nil {
+		c.encoder = factory(options)
+	}
+	return c
+}
+
+// Encode writes WeatherData in the Converter's output format
+func (c *Converter) Encode(w io.Writer, data WeatherData) error {
+	if c.encoder == nil {
+		return fmt.Errorf("unknown output format '%s'", c.format)
+	}
+	return c.encoder.Encode(w, data)
+}
+
+// EncodeStream writes WeatherData whose years are produced by years, in the
+// Converter's output format. data carries the station and metadata. Formats
+// that implement StreamEncoder write each year as it is produced; the others
+// collect the years and encode the complete WeatherData.
+func (c *Converter) EncodeStream(w io.Writer, data WeatherData, years YearSource) error {
+	if c.encoder == nil {
+		return fmt.Errorf("unknown output format '%s'", c.format)
+	}
+	if encoder, ok := c.encoder.(StreamEncoder); ok {
+		return encoder.EncodeStream(w, data, years)
+	}
+	variability, err := years(func(year WeatherDataForYear) error {
+		data.WeatherDataForYear = append(data.WeatherDataForYear, year)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	data.InterannualVariability = variability
+	return c.encoder.Encode(w, data)
 }
 
-// ToJSON serializes WeatherData to pretty-printed JSON.
+// ToJSON serializes WeatherData to pretty-printed JSON in the Converter's
+// schema.
 // Returns error with context if conversion fails.
 func (c *Converter) ToJSON(data WeatherData) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.WriteJSON(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteJSON writes WeatherData as pretty-printed JSON in the Converter's
+// schema. The output is identical to ToJSON, but it is encoded and written
+// one year at a time rather than marshalled into a single buffer. To write
+// years while they are still being aggregated, use EncodeStream.
+func (c *Converter) WriteJSON(w io.Writer, data WeatherData) error {
+	return writeWeatherJSON(w, data, yearsOf(data), c.schema)
+}
+
+// writeWeatherJSON writes WeatherData as pretty-printed JSON in a schema,
+// encoding each year as years produces it. Without years, the v1
+// WeatherData field is null unless data holds an empty slice of them.
+func writeWeatherJSON(w io.Writer, data WeatherData, years YearSource, schema SchemaVersion) error {
+	buffered := bufio.NewWriter(w)
+	stream := newJSONObjectStream(buffered)
+	count := 0
+	var variability []CalendarMonthVariability
+	if schema == SchemaV2 {
+		computed := computedStatistics(data.Metadata)
+		stream.field("schemaVersion", schemaV2Number)
+		stream.streamArray("WeatherData", "[]", func(element func(any) error) error {
+			var err error
+			variability, err = years(func(year WeatherDataForYear) error {
+				count++
+				v2, err := newWeatherDataForYearV2(year, computed)
+				if err != nil {
+					return fmt.Errorf("failed to convert weather data to schema %s (year %s): %w", schema, year.Year, err)
+				}
+				return element(v2)
+			})
+			return err
+		})
+		if len(variability) > 0 {
+			stream.array("InterannualVariability", len(variability), func(i int) (any, error) {
+				return newCalendarMonthVariabilityV2(variability[i])
+			})
+		}
+	} else {
+		empty := "null"
+		if data.WeatherDataForYear != nil {
+			empty = "[]"
+		}
+		stream.streamArray("WeatherData", empty, func(element func(any) error) error {
+			var err error
+			variability, err = years(func(year WeatherDataForYear) error {
+				count++
+				return element(year)
+			})
+			return err
+		})
+		if len(variability) > 0 {
+			stream.field("InterannualVariability", variability)
+		}
+	}
+	if schema == SchemaV2 && data.Metadata != nil {
+		metadata, err := newMetadataV2(data.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to convert metadata to schema %s: %w", schema, err)
+		}
+		stream.field("Metadata", metadata)
+	} else if data.Metadata != nil {
+		stream.field("Metadata", data.Metadata)
+	}
+	if err := stream.close(); err != nil {
+		return fmt.Errorf("failed to convert weather data to JSON (years: %d): %w", count, err)
+	}
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("failed to write weather data JSON: %w", err)
+	}
+	return nil
+}
+
+// jsonObjectStream writes a JSON object field by field, laid out as
+// json.MarshalIndent would with two-space indentation. The first error is
+// kept and later writes are skipped.
+type jsonObjectStream struct {
+	w      io.Writer
+	fields int
+	err    error
+}
+
+func newJSONObjectStream(w io.Writer) *jsonObjectStream {
+	s := &jsonObjectStream{w: w}
+	s.write([]byte("{"))
+	return s
+}
+
+func (s *jsonObjectStream) write(b []byte) {
+	if s.err == nil {
+		_, s.err = s.w.Write(b)
+	}
+}
+
+// key starts a field
+func (s *jsonObjectStream) key(name string) {
+	if s.fields > 0 {
+		s.write([]byte(","))
+	}
+	s.fields++
+	key, err := json.Marshal(name)
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+	s.write([]byte("\n  "))
+	s.write(key)
+	s.write([]byte(": "))
+}
+
+// value writes a value at the given indentation
+func (s *jsonObjectStream) value(v any, prefix string) {
+	if s.err != nil {
+		return
+	}
+	out, err := json.MarshalIndent(v, prefix, "  ")
+	if err != nil {
+		s.err = err
+		return
+	}
+	s.write(out)
+}
+
+// field writes a whole field
+func (s *jsonObjectStream) field(name string, v any) {
+	s.key(name)
+	s.value(v, "  ")
+}
+
+// array writes a field holding n elements, encoding one at a time
+func (s *jsonObjectStream) array(name string, n int, element func(i int) (any, error)) {
+	s.streamArray(name, "[]", func(yield func(any) error) error {
+		for i := 0; i < n; i++ {
+			v, err := element(i)
+			if err != nil {
+				return err
+			}
+			if err := yield(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// streamArray writes a field holding the elements that each passes to
+// element, encoding each as it arrives. An array with no elements is
+// written as empty.
+func (s *jsonObjectStream) streamArray(name, empty string, each func(element func(any) error) error) {
+	s.key(name)
+	n := 0
+	err := each(func(v any) error {
+		if n == 0 {
+			s.write([]byte("["))
+		} else {
+			s.write([]byte(","))
+		}
+		n++
+		s.write([]byte("\n    "))
+		s.value(v, "    ")
+		return s.err
+	})
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+	if s.err != nil {
+		return
+	}
+	if n == 0 {
+		s.write([]byte(empty))
+		return
+	}
+	s.write([]byte("\n  ]"))
+}
+
+// close ends the object and returns the first error
+func (s *jsonObjectStream) close() error {
+	if s.fields > 0 {
+		s.write([]byte("\n"))
+	}
+	s.write([]byte("}"))
+	return s.err
+}
+
+// ExtremesToJSON serializes ExtremesData to pretty-printed JSON.
+func (c *Converter) ExtremesToJSON(data ExtremesData) ([]byte, error) {
+	out, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		yearCount := len(data.ExtremesForYear)
+		return nil, fmt.Errorf("failed to convert extremes data to JSON (years: %d): %w", yearCount, err)
+	}
+	return out, nil
+}
+
+// WriteRollingCSV writes rolling totals as a daily time series with one
+// column per window. All series must come from the same DailySeries.
+// Invalid windows are written as empty cells.
+func (c *Converter) WriteRollingCSV(w io.Writer, series [][]RollingTotal) error {
+	csvWriter := csv.NewWriter(w)
+
+	header := []string{"Date"}
+	for _, s := range series {
+		if len(s) == 0 {
+			continue
+		}
+		header = append(header, fmt.Sprintf("Rainfall%dDay", s[0].Window))
+	}
+	if err := csvWriter.Write(header); err != nil {
+		return fmt.Errorf("failed to write rolling totals header: %w", err)
+	}
+
+	if len(series) > 0 {
+		for i := range series[0] {
+			row := []string{series[0][i].Date.Format("2006-01-02")}
+			for _, s := range series {
+				if len(s) == 0 {
+					continue
+				}
+				cell := ""
+				if s[i].Valid {
+					cell = s[i].Total.Format(1)
+				}
+				row = append(row, cell)
+			}
+			if err := csvWriter.Write(row); err != nil {
+				return fmt.Errorf("failed to write rolling totals row %d: %w", i+1, err)
+			}
+		}
+	}
+
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return fmt.Errorf("failed to write rolling totals: %w", err)
+	}
+	return nil
+}
+
+// ReturnPeriodsToJSON serializes ReturnPeriodData to pretty-printed JSON.
+func (c *Converter) ReturnPeriodsToJSON(data ReturnPeriodData) ([]byte, error) {
+	out, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		durationCount := len(data.ReturnPeriodsForDuration)
+		return nil, fmt.Errorf("failed to convert return period data to JSON (durations: %d): %w", durationCount, err)
+	}
+	return out, nil
+}
+
+// SPIToJSON serializes SPIData to pretty-printed JSON.
+func (c *Converter) SPIToJSON(data SPIData) ([]byte, error) {
+	out, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert SPI data to JSON (months: %d): %w", len(data.SPI), err)
+	}
+	return out, nil
+}
+
+// DeficienciesToJSON serializes DeficiencyData to pretty-printed JSON.
+func (c *Converter) DeficienciesToJSON(data DeficiencyData) ([]byte, error) {
+	out, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert deficiency data to JSON (periods: %d): %w", len(data.Periods), err)
+	}
+	return out, nil
+}
+
+// TrendsToJSON serializes TrendData to pretty-printed JSON.
+func (c *Converter) TrendsToJSON(data TrendData) ([]byte, error) {
+	out, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert trend data to JSON (series: %d): %w", len(data.Trends), err)
+	}
+	return out, nil
+}
+
+// ComparisonToJSON serializes ComparisonData to pretty-printed JSON.
+func (c *Converter) ComparisonToJSON(data ComparisonData) ([]byte, error) {
 	out, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
-		yearCount := len(data.WeatherDataForYear)
-		return nil, fmt.Errorf("failed to convert weather data to JSON (years: %d): %w", yearCount, err)
+		return nil, fmt.Errorf("failed to convert comparison data to JSON (months: %d): %w", len(data.Monthly), err)
 	}
 	return out, nil
 }
@@ -1,6 +1,7 @@
 package bom
 
 import (
+	"bytes"
 	"encoding/json"
 	"strings"
 	"testing"
@@ -58,3 +59,34 @@ func TestToJSON_ErrorHandling(t *testing.T) {
 		t.Fatalf("Expected no error with valid data, got: %v", err)
 	}
 }
+
+func TestWriteJSON_MatchesMarshalIndent(t *testing.T) {
+	rainfall := "1.5"
+	for name, data := range map[string]WeatherData{
+		"empty":    {},
+		"no years": {WeatherDataForYear: []WeatherDataForYear{}},
+		"full": {
+			WeatherDataForYear: []WeatherDataForYear{
+				{Year: "2020", MonthlyAggregates: MonthlyAggregates{WeatherDataForMonth: []WeatherDataForMonth{{
+					Month:        "January",
+					DailyRecords: []DailyValue{{Date: "2020-01-01", Rainfall: &rainfall}, {Date: "2020-01-02"}},
+				}}}},
+				{Year: "2021"},
+			},
+			InterannualVariability: []CalendarMonthVariability{{Month: "January", Years: "2"}},
+			Metadata:               &Metadata{Median: &MedianMetadata{Mode: "all"}},
+		},
+	} {
+		want, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			t.Fatalf("%s: MarshalIndent failed: %v", name, err)
+		}
+		var buf bytes.Buffer
+		if err := NewConverter().WriteJSON(&buf, data); err != nil {
+			t.Fatalf("%s: expected no error, got: %v", name, err)
+		}
+		if buf.String() != string(want) {
+			t.Errorf("%s: expected\n%s\ngot\n%s", name, want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package bom
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// newDailyValues converts a month's records into its daily output values,
+// sorted by date
+func newDailyValues(records []DailyRecord, p Precision) []DailyValue {
+	sort.Slice(records, func(i, j int) bool { return records[i].Date.Before(records[j].Date) })
+	values := make([]DailyValue, 0, len(records))
+	for _, rec := range records {
+		value := DailyValue{
+			Date:    rec.Date.Format("2006-01-02"),
+			Quality: rec.Quality,
+			Imputed: string(rec.Imputed),
+		}
+		if rec.HasData {
+			rainfall := p.FormatAmount(rec.Rainfall)
+			value.Rainfall = &rainfall
+		}
+		if rec.Period > 0 {
+			value.Period = strconv.Itoa(rec.Period)
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// newDailyValuesV2 converts daily output values into the v2 schema
+func newDailyValuesV2(values []DailyValue) ([]DailyValueV2, error) {
+	if values == nil {
+		return nil, nil
+	}
+	out := make([]DailyValueV2, 0, len(values))
+	for _, v := range values {
+		var p v2Parser
+		day := DailyValueV2{
+			Date:    v.Date,
+			Period:  p.optionalInteger("Period", v.Period),
+			Imputed: v.Imputed,
+		}
+		if v.Rainfall != nil {
+			rainfall := p.number("Rainfall", *v.Rainfall)
+			day.Rainfall = &rainfall
+		}
+		if v.Quality != "" {
+			quality := v.Quality
+			day.Quality = &quality
+		}
+		if p.err != nil {
+			return nil, p.err
+		}
+		out = append(out, day)
+	}
+	return out, nil
+}
+
+// RecordsFromDailyValues rebuilds the daily records nested in weather data
+// that was aggregated with IncludeDaily, such as decoded convert output, in
+// date order. Readings keep the precision they were written with. Weather
+// data without daily records gives none.
+func RecordsFromDailyValues(data WeatherData) ([]DailyRecord, error) {
+	var records []DailyRecord
+	for _, year := range data.WeatherDataForYear {
+		for _, month := range year.MonthlyAggregates.WeatherDataForMonth {
+			for _, v := range month.DailyRecords {
+				date, err := time.Parse("2006-01-02", v.Date)
+				if err != nil {
+					return nil, fmt.Errorf("invalid daily record date '%s': %w", v.Date, err)
+				}
+				rec := DailyRecord{
+					Date:    date,
+					Imputed: ImputationMethod(v.Imputed),
+					Quality: v.Quality,
+					Station: data.Station,
+				}
+				if v.Rainfall != nil {
+					rec.Rainfall, err = ParseAmount(*v.Rainfall)
+					if err != nil {
+						return nil, fmt.Errorf("invalid rainfall on %s: %w", v.Date, err)
+					}
+					rec.HasData = true
+				}
+				if v.Period != "" {
+					rec.Period, err = strconv.Atoi(v.Period)
+					if err != nil {
+						return nil, fmt.Errorf("invalid period on %s: %w", v.Date, err)
+					}
+				}
+				records = append(records, rec)
+			}
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Date.Before(records[j].Date) })
+	return records, nil
+}
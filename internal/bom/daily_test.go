@@ -0,0 +1,97 @@
+package bom
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewDailyValues(t *testing.T) {
+	records := []DailyRecord{
+		{Date: day(2020, 1, 2), HasData: false},
+		{Date: day(2020, 1, 1), Rainfall: mm(12.0), HasData: true, Period: 2, Quality: "N"},
+		{Date: day(2020, 1, 3), Rainfall: mm(0.4), HasData: true, Imputed: ImputeClimatology},
+	}
+
+	values := newDailyValues(records, BOMPrecision)
+	if len(values) != 3 {
+		t.Fatalf("Expected 3 values, got %+v", values)
+	}
+	if v := values[0]; v.Date != "2020-01-01" || v.Rainfall == nil || *v.Rainfall != "12.0" || v.Quality != "N" || v.Period != "2" {
+		t.Errorf("Unexpected first value %+v", v)
+	}
+	if v := values[1]; v.Date != "2020-01-02" || v.Rainfall != nil || v.Period != "" {
+		t.Errorf("Expected a null rainfall for 2020-01-02, got %+v", v)
+	}
+	if v := values[2]; v.Imputed != "climatology" {
+		t.Errorf("Expected the imputation method, got %+v", v)
+	}
+
+	v2, err := newDailyValuesV2(values)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if v := v2[0]; *v.Rainfall != "12.0" || *v.Period != 2 || *v.Quality != "N" {
+		t.Errorf("Unexpected v2 value %+v", v)
+	}
+	if v := v2[1]; v.Rainfall != nil || v.Period != nil || v.Quality != nil {
+		t.Errorf("Expected nulls for 2020-01-02, got %+v", v)
+	}
+}
+
+func TestAggregator_IncludeDaily(t *testing.T) {
+	records := []DailyRecord{
+		{Date: day(2020, 1, 1), Rainfall: mm(1.0), HasData: true, Quality: "Y"},
+		{Date: day(2020, 1, 2), HasData: false},
+		{Date: day(2020, 2, 1), Rainfall: mm(2.0), HasData: true},
+	}
+	options := DefaultAggregatorOptions()
+	options.Clock = FixedClock{Time: time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC)}
+
+	months := NewAggregatorWithOptions(options).Aggregate(records).WeatherDataForYear[0].MonthlyAggregates.WeatherDataForMonth
+	if months[0].DailyRecords != nil {
+		t.Errorf("Expected no daily records by default, got %+v", months[0].DailyRecords)
+	}
+
+	options.IncludeDaily = true
+	months = NewAggregatorWithOptions(options).Aggregate(records).WeatherDataForYear[0].MonthlyAggregates.WeatherDataForMonth
+	if len(months[0].DailyRecords) != 2 || len(months[1].DailyRecords) != 1 {
+		t.Fatalf("Expected 2 and 1 daily records, got %+v and %+v", months[0].DailyRecords, months[1].DailyRecords)
+	}
+	if months[0].DailyRecords[1].Rainfall != nil || months[0].LastRecordedDate != "2020-01-01" {
+		t.Errorf("Expected the blank day listed without changing the recorded dates, got %+v", months[0])
+	}
+}
+
+func TestRecordsFromDailyValues(t *testing.T) {
+	records := []DailyRecord{
+		{Date: day(2019, 12, 31), Rainfall: mm(12.34), HasData: true, Period: 2, Quality: "Y", Station: "066062"},
+		{Date: day(2020, 1, 1), Rainfall: mm(1.0), HasData: true, Quality: "N", Station: "066062"},
+		{Date: day(2020, 1, 2), Station: "066062"},
+		{Date: day(2020, 1, 3), Rainfall: mm(0.5), HasData: true, Imputed: ImputeClimatology, Station: "066062"},
+	}
+	options := DefaultAggregatorOptions()
+	options.Clock = FixedClock{Time: time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC)}
+	options.IncludeDaily = true
+	data := NewAggregatorWithOptions(options).Aggregate(records)
+
+	got, err := RecordsFromDailyValues(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !reflect.DeepEqual(got, records) {
+		t.Errorf("Expected the records back\nwant: %+v\ngot:  %+v", records, got)
+	}
+
+	options.IncludeDaily = false
+	if got, err := RecordsFromDailyValues(NewAggregatorWithOptions(options).Aggregate(records)); err != nil || got != nil {
+		t.Errorf("Expected no records without daily values, got %+v, %v", got, err)
+	}
+
+	rainfall := "a lot"
+	data.WeatherDataForYear[0].MonthlyAggregates.WeatherDataForMonth[0].DailyRecords[0].Rainfall = &rainfall
+	if _, err := RecordsFromDailyValues(data); err == nil || !strings.Contains(err.Error(), "invalid rainfall on 2019-12-31") {
+		t.Errorf("Expected an invalid rainfall error, got %v", err)
+	}
+}
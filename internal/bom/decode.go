@@ -0,0 +1,58 @@
+package bom
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// DecodeWeatherData reads weather data JSON in the v1 schema, as written by
+// Converter, back into WeatherData. Unknown fields are rejected, and every
+// string that holds a number must parse as one. Station is not part of the
+// JSON and is left empty.
+func DecodeWeatherData(r io.Reader) (WeatherData, error) {
+	data, _, err := decodeWeatherData(r)
+	return data, err
+}
+
+// DecodeTypedWeatherData reads weather data JSON in the v1 schema into the
+// typed v2 structure: its string numbers become numbers, its months ISO 8601
+// months and its Unreliable flags booleans
+func DecodeTypedWeatherData(r io.Reader) (WeatherDataV2, error) {
+	_, typed, err := decodeWeatherData(r)
+	return typed, err
+}
+
+// decodeWeatherData reads v1 weather data JSON and validates it by
+// converting it to v2, returning both
+func decodeWeatherData(r io.Reader) (WeatherData, WeatherDataV2, error) {
+	input, err := io.ReadAll(r)
+	if err != nil {
+		return WeatherData{}, WeatherDataV2{}, fmt.Errorf("failed to read weather data: %w", err)
+	}
+
+	var version struct {
+		SchemaVersion *json.RawMessage `json:"schemaVersion"`
+	}
+	if err := json.Unmarshal(input, &version); err == nil && version.SchemaVersion != nil {
+		return WeatherData{}, WeatherDataV2{}, fmt.Errorf("weather data has a schemaVersion: only %s output can be decoded", SchemaV1)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	decoder.DisallowUnknownFields()
+	var data WeatherData
+	if err := decoder.Decode(&data); err != nil {
+		return WeatherData{}, WeatherDataV2{}, fmt.Errorf("failed to decode weather data: %w", err)
+	}
+	if decoder.More() {
+		return WeatherData{}, WeatherDataV2{}, fmt.Errorf("failed to decode weather data: more than one value")
+	}
+
+	// Converting to v2 parses every numeric string
+	typed, err := NewWeatherDataV2(data)
+	if err != nil {
+		return WeatherData{}, WeatherDataV2{}, fmt.Errorf("invalid weather data: %w", err)
+	}
+	return data, typed, nil
+}
@@ -0,0 +1,128 @@
+package bom
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// decodeTestDocument aggregates the report records and encodes them as v1
+// JSON
+func decodeTestDocument(t *testing.T, options AggregatorOptions) []byte {
+	t.Helper()
+	data := NewAggregatorWithOptions(options).Aggregate(reportRecords())
+	var buf bytes.Buffer
+	if err := NewConverter().Encode(&buf, data); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeWeatherData_RoundTrip(t *testing.T) {
+	daily := DefaultAggregatorOptions()
+	daily.IncludeDaily = true
+	bom := DefaultAggregatorOptions()
+	bom.Precision = &BOMPrecision
+	bom.MinCompleteness = 95
+
+	for name, options := range map[string]AggregatorOptions{"default": DefaultAggregatorOptions(), "daily": daily, "bom": bom} {
+		doc := decodeTestDocument(t, options)
+		data, err := DecodeWeatherData(bytes.NewReader(doc))
+		if err != nil {
+			t.Fatalf("%s: DecodeWeatherData failed: %v", name, err)
+		}
+
+		var buf bytes.Buffer
+		if err := NewConverter().Encode(&buf, data); err != nil {
+			t.Fatalf("%s: Encode failed: %v", name, err)
+		}
+		if buf.String() != string(doc) {
+			t.Errorf("%s: expected decoded data to encode to the same JSON\nwant: %s\ngot:  %s", name, doc, buf.String())
+		}
+	}
+
+	data, err := DecodeWeatherData(strings.NewReader(`{"WeatherData": null}`))
+	if err != nil || data.WeatherDataForYear != nil {
+		t.Errorf("Expected empty weather data, got %+v, %v", data, err)
+	}
+}
+
+func TestDecodeTypedWeatherData(t *testing.T) {
+	daily := DefaultAggregatorOptions()
+	daily.IncludeDaily = true
+	data, err := DecodeTypedWeatherData(bytes.NewReader(decodeTestDocument(t, daily)))
+	if err != nil {
+		t.Fatalf("DecodeTypedWeatherData failed: %v", err)
+	}
+	if len(data.WeatherDataForYear) != 3 {
+		t.Fatalf("Expected 3 years, got %d", len(data.WeatherDataForYear))
+	}
+
+	year := data.WeatherDataForYear[1]
+	if year.Year != 2019 || year.RecordedDays != 365 || year.DaysWithRainfall.Int != 365 {
+		t.Errorf("Unexpected 2019 values: %+v", year)
+	}
+	if total, _ := year.TotalRainfall.Number.Float64(); !approxEqual(total, 730, 1e-9) {
+		t.Errorf("Expected 2019 total 730, got %v", total)
+	}
+	month := data.WeatherDataForYear[2].MonthlyAggregates[0]
+	if month.Month != "2020-01" {
+		t.Errorf("Expected month 2020-01, got %s", month.Month)
+	}
+	if rainfall, _ := month.DailyRecords[14].Rainfall.Float64(); !approxEqual(rainfall, 50, 1e-9) {
+		t.Errorf("Expected 50 mm on 15 January 2020, got %v", rainfall)
+	}
+}
+
+func TestDecodeWeatherData_TextStatistic(t *testing.T) {
+	data, err := DecodeWeatherData(bytes.NewReader(decodeTestDocument(t, DefaultAggregatorOptions())))
+	if err != nil {
+		t.Fatalf("DecodeWeatherData failed: %v", err)
+	}
+	data.WeatherDataForYear[0].AdditionalStatistics = map[string]string{"Season": "wet", "Storms": "3"}
+	var buf bytes.Buffer
+	if err := NewConverter().Encode(&buf, data); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	decoded, err := DecodeWeatherData(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Expected a text statistic to decode, got: %v", err)
+	}
+	if got := decoded.WeatherDataForYear[0].AdditionalStatistics["Season"]; got != "wet" {
+		t.Errorf("Expected Season wet, got %q", got)
+	}
+	typed, err := DecodeTypedWeatherData(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Expected a text statistic to decode as typed data, got: %v", err)
+	}
+	stats := typed.WeatherDataForYear[0].AdditionalStatistics
+	if stats["Season"].Text != "wet" || stats["Storms"].Number != "3" {
+		t.Errorf("Expected a text and a number statistic, got %+v", stats)
+	}
+}
+
+func TestDecodeWeatherData_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{"invalid JSON", `{"WeatherData": [`, "failed to decode"},
+		{"unknown field", `{"WeatherData": [], "Extra": 1}`, `unknown field "Extra"`},
+		{"more than one value", `{"WeatherData": []} {}`, "more than one value"},
+		{"v2", `{"schemaVersion": 2, "WeatherData": []}`, "schemaVersion"},
+		{"number", `{"WeatherData": [{"Year": "2019", "ExpectedDays": "365", "RecordedDays": "365",
+			"PercentComplete": "100", "TotalRainfall": "lots", "MonthlyAggregates": {"WeatherDataForMonth": []}}]}`,
+			"year 2019: invalid TotalRainfall 'lots'"},
+		{"typed value", `{"WeatherData": [{"Year": 2019}]}`, "cannot unmarshal number"},
+	}
+	for _, tt := range tests {
+		if _, err := DecodeWeatherData(strings.NewReader(tt.doc)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected an error containing %q, got %v", tt.name, tt.want, err)
+		}
+		if _, err := DecodeTypedWeatherData(strings.NewReader(tt.doc)); err == nil {
+			t.Errorf("%s: expected DecodeTypedWeatherData to fail", tt.name)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package bom
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DeficiencySeverity classifies a rainfall deficiency
+type DeficiencySeverity string
+
+const (
+	// DeficiencySerious is a total in the lowest 10% of records
+	DeficiencySerious DeficiencySeverity = "serious"
+	// DeficiencySevere is a total in the lowest 5% of records
+	DeficiencySevere DeficiencySeverity = "severe"
+)
+
+// MinDeficiencyMonths is the shortest duration BOM assesses rainfall
+// deficiencies over
+const MinDeficiencyMonths = 3
+
+// DeficiencyOptions controls rainfall deficiency detection
+type DeficiencyOptions struct {
+	// Durations are the accumulation lengths in months to scan. BOM
+	// assesses deficiencies over periods of three months or longer.
+	Durations []int
+	// MinCompleteness is the minimum percentage of days in a month that
+	// must have a reading for the month to be used
+	MinCompleteness float64
+	// SeriousPercentile and SeverePercentile are the percentile ranks at or
+	// below which a total is a serious or severe deficiency
+	SeriousPercentile float64
+	SeverePercentile  float64
+	// Clock determines which months have finished. A nil Clock uses the
+	// system time.
+	Clock Clock
+}
+
+// DefaultDeficiencyOptions returns the options used by the CLI by default
+func DefaultDeficiencyOptions() DeficiencyOptions {
+	return DeficiencyOptions{
+		Durations:         []int{3, 6, 12},
+		MinCompleteness:   90,
+		SeriousPercentile: 10,
+		SeverePercentile:  5,
+		Clock:             SystemClock{},
+	}
+}
+
+// DeficiencyPeriod is a run of months whose total rainfall was among the
+// lowest on record for a period of that length ending in that calendar month
+type DeficiencyPeriod struct {
+	Start  time.Time
+	End    time.Time
+	Months int
+	Total  Amount
+	// PercentileRank is the percentage of comparable totals at or below
+	// this one
+	PercentileRank float64
+	// Comparisons is the number of comparable totals in the record
+	Comparisons int
+	Severity    DeficiencySeverity
+}
+
+// overlaps reports whether two periods share any month
+func (d DeficiencyPeriod) overlaps(other DeficiencyPeriod) bool {
+	return !d.End.Before(other.Start) && !other.End.Before(d.Start)
+}
+
+// DetectDeficiencies scans accumulations of each duration and reports the
+// periods in the lowest percentiles of the record. Each total is ranked
+// against every complete total of the same length ending in the same
+// calendar month. Where qualifying periods overlap, the one with the lowest
+// percentile rank is kept, preferring longer and then drier periods on ties,
+// so the result contains no overlapping periods. Periods are returned in
+// date order.
+func DetectDeficiencies(records []DailyRecord, opts DeficiencyOptions) ([]DeficiencyPeriod, error) {
+	if opts.SeverePercentile > opts.SeriousPercentile {
+		return nil, fmt.Errorf("severe percentile %g must not exceed serious percentile %g", opts.SeverePercentile, opts.SeriousPercentile)
+	}
+	clock := opts.Clock
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	totals := MonthlyTotals(records, clock.Now())
+
+	var candidates []DeficiencyPeriod
+	for _, months := range opts.Durations {
+		if months < MinDeficiencyMonths {
+			return nil, fmt.Errorf("deficiency duration must be at least %d months, got %d", MinDeficiencyMonths, months)
+		}
+		accumulations := AccumulateMonths(totals, months, opts.MinCompleteness)
+
+		var byMonth [13][]Amount
+		for _, a := range accumulations {
+			if a.Complete {
+				byMonth[a.Month] = append(byMonth[a.Month], a.Total)
+			}
+		}
+		for m := range byMonth {
+			sort.Slice(byMonth[m], func(i, j int) bool { return byMonth[m][i] < byMonth[m][j] })
+		}
+
+		for _, a := range accumulations {
+			if !a.Complete {
+				continue
+			}
+			comparable := byMonth[a.Month]
+			atOrBelow := sort.Search(len(comparable), func(i int) bool { return comparable[i] > a.Total })
+			rank := 100 * float64(atOrBelow) / float64(len(comparable))
+			if rank > opts.SeriousPercentile {
+				continue
+			}
+			severity := DeficiencySerious
+			if rank <= opts.SeverePercentile {
+				severity = DeficiencySevere
+			}
+			candidates = append(candidates, DeficiencyPeriod{
+				Start:          a.Start(),
+				End:            time.Date(a.Year, a.Month+1, 0, 0, 0, 0, 0, time.UTC),
+				Months:         a.Months,
+				Total:          a.Total,
+				PercentileRank: rank,
+				Comparisons:    len(comparable),
+				Severity:       severity,
+			})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].PercentileRank != candidates[j].PercentileRank {
+			return candidates[i].PercentileRank < candidates[j].PercentileRank
+		}
+		if candidates[i].Months != candidates[j].Months {
+			return candidates[i].Months > candidates[j].Months
+		}
+		if candidates[i].Total != candidates[j].Total {
+			return candidates[i].Total < candidates[j].Total
+		}
+		return candidates[i].End.Before(candidates[j].End)
+	})
+
+	var periods []DeficiencyPeriod
+	for _, c := range candidates {
+		overlapping := false
+		for _, p := range periods {
+			if c.overlaps(p) {
+				overlapping = true
+				break
+			}
+		}
+		if !overlapping {
+			periods = append(periods, c)
+		}
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].Start.Before(periods[j].Start) })
+	return periods, nil
+}
+
+// NewDeficiencyData converts deficiency periods into their JSON output structure
+func NewDeficiencyData(periods []DeficiencyPeriod, opts DeficiencyOptions) DeficiencyData {
+	data := DeficiencyData{
+		MinCompleteness:   formatFloat(opts.MinCompleteness, 1),
+		SeriousPercentile: formatFloat(opts.SeriousPercentile, 1),
+		SeverePercentile:  formatFloat(opts.SeverePercentile, 1),
+		Periods:           []DeficiencyPeriodData{},
+	}
+	for _, d := range opts.Durations {
+		data.Durations = append(data.Durations, strconv.Itoa(d))
+	}
+	for _, p := range periods {
+		data.Periods = append(data.Periods, DeficiencyPeriodData{
+			StartDate:      p.Start.Format("2006-01-02"),
+			EndDate:        p.End.Format("2006-01-02"),
+			Months:         strconv.Itoa(p.Months),
+			TotalRainfall:  p.Total.Format(1),
+			PercentileRank: formatFloat(p.PercentileRank, 1),
+			Comparisons:    strconv.Itoa(p.Comparisons),
+			Severity:       string(p.Severity),
+		})
+	}
+	return data
+}
@@ -0,0 +1,114 @@
+package bom
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// deficiencyTestRecords returns twenty years where every month has 100 mm
+// except for a dry spell from October to December 2015
+func deficiencyTestRecords() []DailyRecord {
+	var totals []float64
+	for y := 2000; y < 2020; y++ {
+		for m := time.January; m <= time.December; m++ {
+			total := 100.0 + float64(y-2000)
+			if y == 2015 && m >= time.October {
+				total = 5
+			}
+			totals = append(totals, total)
+		}
+	}
+	return monthlyRecords(2000, totals)
+}
+
+func TestDetectDeficiencies(t *testing.T) {
+	opts := DefaultDeficiencyOptions()
+	opts.Durations = []int{3}
+	opts.Clock = FixedClock{Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	periods, err := DetectDeficiencies(deficiencyTestRecords(), opts)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	// October-December 2015 is the lowest of 20 December totals (5%) and
+	// the driest of the tied windows around it, which are dropped
+	var dry *DeficiencyPeriod
+	for i, p := range periods {
+		if p.Start.Year() == 2015 || p.End.Year() == 2015 || p.End.Year() == 2016 && p.End.Month() <= time.February {
+			if dry != nil {
+				t.Fatalf("Expected a single period around the dry spell, got %+v and %+v", *dry, p)
+			}
+			dry = &periods[i]
+		}
+	}
+	if dry == nil {
+		t.Fatalf("Expected a deficiency around late 2015, got %+v", periods)
+	}
+	if !dry.Start.Equal(day(2015, time.October, 1)) || !dry.End.Equal(day(2015, time.December, 31)) {
+		t.Errorf("Expected October-December 2015, got %s to %s", dry.Start.Format("2006-01-02"), dry.End.Format("2006-01-02"))
+	}
+	if dry.Total != 1500 || dry.Severity != DeficiencySevere || dry.PercentileRank != 5 || dry.Comparisons != 20 {
+		t.Errorf("Unexpected dry period %+v", *dry)
+	}
+
+	for i := 1; i < len(periods); i++ {
+		if periods[i].overlaps(periods[i-1]) || periods[i].Start.Before(periods[i-1].Start) {
+			t.Errorf("Expected ordered, non-overlapping periods, got %+v then %+v", periods[i-1], periods[i])
+		}
+	}
+}
+
+func TestDetectDeficiencies_PrefersLongerPeriodsOnTies(t *testing.T) {
+	opts := DefaultDeficiencyOptions()
+	opts.Durations = []int{3, 6}
+	opts.Clock = FixedClock{Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	periods, err := DetectDeficiencies(deficiencyTestRecords(), opts)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	for _, p := range periods {
+		if p.End.Equal(day(2015, time.December, 31)) && p.Months != 6 {
+			t.Errorf("Expected the 6-month period ending December 2015 to be kept, got %+v", p)
+		}
+	}
+}
+
+func TestDetectDeficiencies_InvalidOptions(t *testing.T) {
+	opts := DefaultDeficiencyOptions()
+	for _, months := range []int{0, 1, 2} {
+		opts.Durations = []int{months}
+		if _, err := DetectDeficiencies(nil, opts); err == nil || !strings.Contains(err.Error(), "at least 3 months") {
+			t.Errorf("Expected error for a %d-month duration, got %v", months, err)
+		}
+	}
+
+	opts = DefaultDeficiencyOptions()
+	opts.SeverePercentile = 20
+	if _, err := DetectDeficiencies(nil, opts); err == nil {
+		t.Error("Expected error for a severe percentile above the serious percentile")
+	}
+}
+
+func TestNewDeficiencyData(t *testing.T) {
+	periods := []DeficiencyPeriod{{
+		Start:          day(2019, time.October, 1),
+		End:            day(2019, time.December, 31),
+		Months:         3,
+		Total:          6200,
+		PercentileRank: 1.851,
+		Comparisons:    162,
+		Severity:       DeficiencySevere,
+	}}
+	data := NewDeficiencyData(periods, DefaultDeficiencyOptions())
+
+	if len(data.Durations) != 3 || data.SeriousPercentile != "10.0" {
+		t.Errorf("Unexpected options in output %+v", data)
+	}
+	p := data.Periods[0]
+	if p.StartDate != "2019-10-01" || p.EndDate != "2019-12-31" || p.TotalRainfall != "62.0" || p.PercentileRank != "1.9" || p.Severity != "severe" {
+		t.Errorf("Unexpected period %+v", p)
+	}
+}
@@ -0,0 +1,136 @@
+package bom
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// RunningMoments accumulates the mean and variance of a sequence of values
+// in a single pass using Welford's algorithm, which avoids the cancellation
+// error of summing squares
+type RunningMoments struct {
+	n    int
+	mean float64
+	m2   float64
+}
+
+// Add includes a value
+func (m *RunningMoments) Add(x float64) {
+	m.n++
+	delta := x - m.mean
+	m.mean += delta / float64(m.n)
+	m.m2 += delta * (x - m.mean)
+}
+
+// Count returns the number of values added
+func (m *RunningMoments) Count() int {
+	return m.n
+}
+
+// Mean returns the mean of the values, or zero when there are none
+func (m *RunningMoments) Mean() float64 {
+	return m.mean
+}
+
+// Variance returns the sample variance, which is undefined for fewer than
+// two values
+func (m *RunningMoments) Variance() (float64, bool) {
+	if m.n < 2 {
+		return 0, false
+	}
+	return m.m2 / float64(m.n-1), true
+}
+
+// StdDev returns the sample standard deviation
+func (m *RunningMoments) StdDev() (float64, bool) {
+	variance, ok := m.Variance()
+	return math.Sqrt(variance), ok
+}
+
+// CoefficientOfVariation returns the standard deviation divided by the mean,
+// which is undefined when the mean is zero
+func (m *RunningMoments) CoefficientOfVariation() (float64, bool) {
+	sd, ok := m.StdDev()
+	if !ok || m.mean == 0 {
+		return 0, false
+	}
+	return sd / m.mean, true
+}
+
+// dispersionStatistic reports one measure of the spread of daily rainfall.
+// Undefined results are reported as empty and left out of the output.
+type dispersionStatistic struct {
+	name    string
+	measure func(*RunningMoments) (float64, bool)
+	moments RunningMoments
+}
+
+func (s *dispersionStatistic) Name() string { return s.name }
+func (s *dispersionStatistic) Level() Level { return LevelYear | LevelMonth }
+
+func (s *dispersionStatistic) Accumulate(rec DailyRecord) {
+	s.moments.Add(rec.Rainfall.Float64())
+}
+
+func (s *dispersionStatistic) Result(p Precision) string {
+	value, ok := s.measure(&s.moments)
+	if !ok {
+		return ""
+	}
+	return p.FormatAverageFloat(value)
+}
+
+// newDispersionStatistic returns a factory for a dispersion statistic
+func newDispersionStatistic(name string, measure func(*RunningMoments) (float64, bool)) StatisticFactory {
+	return func(Period) Statistic {
+		return &dispersionStatistic{name: name, measure: measure}
+	}
+}
+
+// calendarMonthTotals collects the totals of one calendar month across years
+type calendarMonthTotals struct {
+	moments RunningMoments
+	years   []int
+}
+
+// interannualVariability computes, for each calendar month, the spread of
+// that month's total rainfall across years from the totals of finished
+// months in date order. Only months with every expected day recorded are
+// included, whatever the completeness options, since a total with missing
+// days understates the month.
+func (a *Aggregator) interannualVariability(totals []MonthlyTotal, today time.Time) []CalendarMonthVariability {
+	var byMonth [13]calendarMonthTotals
+	for _, total := range totals {
+		expected := a.expectedDays(total.Year, total.Month, today)
+		if expected == 0 || total.Completeness.RecordedDays < expected {
+			continue
+		}
+		byMonth[total.Month].moments.Add(total.Total.Float64())
+		byMonth[total.Month].years = append(byMonth[total.Month].years, total.Year)
+	}
+
+	p := a.precision()
+	var variability []CalendarMonthVariability
+	for m := time.January; m <= time.December; m++ {
+		totals := byMonth[m]
+		if totals.moments.Count() == 0 {
+			continue
+		}
+		v := CalendarMonthVariability{
+			Month:             m.String(),
+			Years:             strconv.Itoa(totals.moments.Count()),
+			FirstYear:         strconv.Itoa(totals.years[0]),
+			LastYear:          strconv.Itoa(totals.years[len(totals.years)-1]),
+			MeanTotalRainfall: p.FormatDepthFloat(totals.moments.Mean()),
+		}
+		if sd, ok := totals.moments.StdDev(); ok {
+			v.StdDevTotalRainfall = p.FormatDepthFloat(sd)
+		}
+		if cv, ok := totals.moments.CoefficientOfVariation(); ok {
+			v.CoefficientOfVariation = p.FormatAverageFloat(cv)
+		}
+		variability = append(variability, v)
+	}
+	return variability
+}
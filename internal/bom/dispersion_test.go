@@ -0,0 +1,126 @@
+package bom
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRunningMoments(t *testing.T) {
+	var m RunningMoments
+	if _, ok := m.Variance(); ok {
+		t.Error("Expected variance to be undefined with no values")
+	}
+	m.Add(2)
+	if _, ok := m.StdDev(); ok {
+		t.Error("Expected standard deviation to be undefined with one value")
+	}
+	for _, x := range []float64{4, 4, 4, 5, 5, 7, 9} {
+		m.Add(x)
+	}
+
+	// Values 2, 4, 4, 4, 5, 5, 7, 9: mean 5, sum of squared deviations 32
+	if m.Count() != 8 || !approxEqual(m.Mean(), 5, 1e-12) {
+		t.Errorf("Expected 8 values with mean 5, got %d with mean %v", m.Count(), m.Mean())
+	}
+	if variance, ok := m.Variance(); !ok || !approxEqual(variance, 32.0/7, 1e-12) {
+		t.Errorf("Expected sample variance %v, got %v", 32.0/7, variance)
+	}
+	if cv, ok := m.CoefficientOfVariation(); !ok || !approxEqual(cv, 0.4276179870, 1e-9) {
+		t.Errorf("Expected coefficient of variation 0.4276179870, got %v", cv)
+	}
+}
+
+func TestRunningMoments_LargeOffsetIsStable(t *testing.T) {
+	// Summing squares would lose the variance entirely at this offset
+	var m RunningMoments
+	for _, x := range []float64{1e9 + 4, 1e9 + 7, 1e9 + 13, 1e9 + 16} {
+		m.Add(x)
+	}
+	if variance, _ := m.Variance(); !approxEqual(variance, 30, 1e-6) {
+		t.Errorf("Expected variance 30, got %v", variance)
+	}
+}
+
+func TestRunningMoments_ZeroMean(t *testing.T) {
+	var m RunningMoments
+	m.Add(0)
+	m.Add(0)
+	if _, ok := m.CoefficientOfVariation(); ok {
+		t.Error("Expected coefficient of variation to be undefined for a zero mean")
+	}
+}
+
+func TestAggregate_DispersionStatistics(t *testing.T) {
+	precision := BOMPrecision
+	options := DefaultAggregatorOptions()
+	options.Clock = FixedClock{Time: time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC)}
+	options.Precision = &precision
+	options.Statistics = AllStatistics()
+
+	records := []DailyRecord{
+		{Date: day(2020, time.January, 1), Rainfall: mm(0), HasData: true},
+		{Date: day(2020, time.January, 2), Rainfall: mm(2), HasData: true},
+		{Date: day(2020, time.January, 3), Rainfall: mm(4), HasData: true},
+		{Date: day(2020, time.February, 1), Rainfall: mm(0), HasData: true},
+	}
+	result := NewAggregatorWithOptions(options).Aggregate(records)
+	year := result.WeatherDataForYear[0]
+	jan := year.MonthlyAggregates.WeatherDataForMonth[0]
+	feb := year.MonthlyAggregates.WeatherDataForMonth[1]
+
+	// January: mean 2, sample variance 4
+	if jan.VarianceDailyRainfall != "4.000000000" || jan.StdDevDailyRainfall != "2.000000000" || jan.CoefficientOfVariation != "1.000000000" {
+		t.Errorf("Unexpected January dispersion %s/%s/%s", jan.VarianceDailyRainfall, jan.StdDevDailyRainfall, jan.CoefficientOfVariation)
+	}
+	// Year: 0, 2, 4, 0 has mean 1.5 and sample variance 11/3
+	if year.VarianceDailyRainfall != "3.666666667" {
+		t.Errorf("Expected year variance 3.666666667, got %s", year.VarianceDailyRainfall)
+	}
+	// February: a single dry day has no defined spread
+	if feb.StdDevDailyRainfall != "" || feb.CoefficientOfVariation != "" {
+		t.Errorf("Expected undefined February dispersion, got %s/%s", feb.StdDevDailyRainfall, feb.CoefficientOfVariation)
+	}
+
+	// The standard output leaves them out
+	options.Statistics = nil
+	year = NewAggregatorWithOptions(options).Aggregate(records).WeatherDataForYear[0]
+	if year.VarianceDailyRainfall != "" || year.StdDevDailyRainfall != "" || year.CoefficientOfVariation != "" {
+		t.Errorf("Expected no dispersion by default, got %s/%s/%s", year.VarianceDailyRainfall, year.StdDevDailyRainfall, year.CoefficientOfVariation)
+	}
+}
+
+func TestAggregate_InterannualVariability(t *testing.T) {
+	precision := BOMPrecision
+	options := DefaultAggregatorOptions()
+	options.Clock = FixedClock{Time: time.Date(2022, 1, 15, 0, 0, 0, 0, time.UTC)}
+	options.Precision = &precision
+
+	var records []DailyRecord
+	// Complete Januaries of 2019-2021 with totals of 31, 62 and 93 mm
+	for year, rainfall := range map[int]float64{2019: 1, 2020: 2, 2021: 3} {
+		for d := 1; d <= 31; d++ {
+			records = append(records, DailyRecord{Date: day(year, time.January, d), Rainfall: mm(rainfall), HasData: true})
+		}
+	}
+	// A January missing a day, an incomplete February and the current,
+	// partial January are excluded, with no --min-completeness set
+	for d := 2; d <= 31; d++ {
+		records = append(records, DailyRecord{Date: day(2018, time.January, d), Rainfall: mm(9), HasData: true})
+	}
+	records = append(records,
+		DailyRecord{Date: day(2021, time.February, 1), Rainfall: mm(50), HasData: true},
+		DailyRecord{Date: day(2022, time.January, 1), Rainfall: mm(500), HasData: true},
+	)
+
+	result := NewAggregatorWithOptions(options).Aggregate(records)
+	if len(result.InterannualVariability) != 1 {
+		t.Fatalf("Expected only January variability, got %+v", result.InterannualVariability)
+	}
+	jan := result.InterannualVariability[0]
+	if jan.Month != "January" || jan.Years != "3" || jan.FirstYear != "2019" || jan.LastYear != "2021" {
+		t.Errorf("Unexpected January coverage %+v", jan)
+	}
+	if jan.MeanTotalRainfall != "62.0" || jan.StdDevTotalRainfall != "31.0" || jan.CoefficientOfVariation != "0.500000000" {
+		t.Errorf("Unexpected January variability %+v", jan)
+	}
+}
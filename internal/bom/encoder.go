@@ -0,0 +1,350 @@
+package bom
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Encoder writes WeatherData in one output format
+type Encoder interface {
+	Encode(w io.Writer, data WeatherData) error
+}
+
+// EncoderFunc adapts an ordinary function to the Encoder interface
+type EncoderFunc func(w io.Writer, data WeatherData) error
+
+// Encode calls f(w, data)
+func (f EncoderFunc) Encode(w io.Writer, data WeatherData) error {
+	return f(w, data)
+}
+
+// YearSource produces the years of WeatherData in order, passing each to
+// yield, and returns the interannual variability once they are done.
+// It stops at the first error returned by yield.
+type YearSource func(yield func(WeatherDataForYear) error) ([]CalendarMonthVariability, error)
+
+// StreamEncoder is implemented by Encoders that can write WeatherData while
+// its years are still being produced. data carries the station and
+// metadata; its years and interannual variability come from years.
+type StreamEncoder interface {
+	Encoder
+	EncodeStream(w io.Writer, data WeatherData, years YearSource) error
+}
+
+// streamEncoderFunc adapts a function writing WeatherData from a YearSource
+// to the StreamEncoder interface
+type streamEncoderFunc func(w io.Writer, data WeatherData, years YearSource) error
+
+// Encode writes complete WeatherData, taking its years from data
+func (f streamEncoderFunc) Encode(w io.Writer, data WeatherData) error {
+	return f(w, data, yearsOf(data))
+}
+
+// EncodeStream calls f(w, data, years)
+func (f streamEncoderFunc) EncodeStream(w io.Writer, data WeatherData, years YearSource) error {
+	return f(w, data, years)
+}
+
+// yearsOf returns a YearSource over the years of complete WeatherData
+func yearsOf(data WeatherData) YearSource {
+	return func(yield func(WeatherDataForYear) error) ([]CalendarMonthVariability, error) {
+		for _, year := range data.WeatherDataForYear {
+			if err := yield(year); err != nil {
+				return nil, err
+			}
+		}
+		return data.InterannualVariability, nil
+	}
+}
+
+// EncoderFactory creates an Encoder for the given converter options
+type EncoderFactory func(options ConverterOptions) Encoder
+
+// Built-in output format names
+const (
+	FormatJSON      = "json"
+	FormatCSVYears  = "csv-years"
+	FormatCSVMonths = "csv-months"
+	FormatNDJSON    = "ndjson"
+	FormatXML       = "xml"
+	FormatTable     = "table"
+	FormatMarkdown  = "markdown"
+)
+
+// NDJSON line types
+const (
+	ndjsonYearType  = "year"
+	ndjsonMonthType = "month"
+)
+
+// EncoderRegistry is an ordered set of output formats registered by name
+type EncoderRegistry struct {
+	names     []string
+	factories map[string]EncoderFactory
+}
+
+// NewEncoderRegistry creates an empty registry
+func NewEncoderRegistry() *EncoderRegistry {
+	return &EncoderRegistry{factories: make(map[string]EncoderFactory)}
+}
+
+// DefaultEncoders returns a registry with the built-in output formats
+func DefaultEncoders() *EncoderRegistry {
+	r := NewEncoderRegistry()
+	r.mustRegister(FormatJSON, func(options ConverterOptions) Encoder {
+		return streamEncoderFunc(func(w io.Writer, data WeatherData, years YearSource) error {
+			return writeWeatherJSON(w, data, years, options.Schema)
+		})
+	})
+	r.mustRegister(FormatNDJSON, func(options ConverterOptions) Encoder {
+		return streamEncoderFunc(func(w io.Writer, data WeatherData, years YearSource) error {
+			return writeNDJSON(w, data, years, options.Schema)
+		})
+	})
+	r.mustRegister(FormatXML, func(ConverterOptions) Encoder { return EncoderFunc(writeXML) })
+	r.mustRegister(FormatCSVYears, func(ConverterOptions) Encoder { return EncoderFunc(writeYearsCSV) })
+	r.mustRegister(FormatCSVMonths, func(ConverterOptions) Encoder { return EncoderFunc(writeMonthsCSV) })
+	r.mustRegister(FormatTable, func(options ConverterOptions) Encoder {
+		width := options.Width
+		if width <= 0 {
+			width = DefaultTableWidth
+		}
+		return EncoderFunc(func(w io.Writer, data WeatherData) error {
+			return writeTable(w, data, width)
+		})
+	})
+	r.mustRegister(FormatMarkdown, func(ConverterOptions) Encoder { return EncoderFunc(writeMarkdown) })
+	return r
+}
+
+// Register adds an output format under the given name
+func (r *EncoderRegistry) Register(name string, factory EncoderFactory) error {
+	if name == "" {
+		return fmt.Errorf("format name must not be empty")
+	}
+	if _, exists := r.factories[name]; exists {
+		return fmt.Errorf("format '%s' is already registered", name)
+	}
+	r.names = append(r.names, name)
+	r.factories[name] = factory
+	return nil
+}
+
+func (r *EncoderRegistry) mustRegister(name string, factory EncoderFactory) {
+	if err := r.Register(name, factory); err != nil {
+		panic(err)
+	}
+}
+
+// Names returns the registered format names in registration order
+func (r *EncoderRegistry) Names() []string {
+	names := make([]string, len(r.names))
+	copy(names, r.names)
+	return names
+}
+
+// Lookup returns the factory registered under name
+func (r *EncoderRegistry) Lookup(name string) (EncoderFactory, error) {
+	factory, ok := r.factories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown format '%s' (available: %s)", name, strings.Join(r.names, ", "))
+	}
+	return factory, nil
+}
+
+// summaryColumn is one statistic column of a CSV summary
+type summaryColumn[T any] struct {
+	name  string
+	value func(*T) string
+}
+
+// yearColumns are the columns of the yearly CSV summary, in output order
+var yearColumns = []summaryColumn[WeatherDataForYear]{
+	{"Year", func(y *WeatherDataForYear) string { return y.Year }},
+	{"FirstRecordedDate", func(y *WeatherDataForYear) string { return y.FirstRecordedDate }},
+	{"LastRecordedDate", func(y *WeatherDataForYear) string { return y.LastRecordedDate }},
+	{"TotalRainfall", func(y *WeatherDataForYear) string { return y.TotalRainfall }},
+	{"AverageDailyRainfall", func(y *WeatherDataForYear) string { return y.AverageDailyRainfall }},
+	{"MedianDailyRainfall", func(y *WeatherDataForYear) string { return y.MedianDailyRainfall }},
+	{"DaysWithNoRainfall", func(y *WeatherDataForYear) string { return y.DaysWithNoRainfall }},
+	{"DaysWithRainfall", func(y *WeatherDataForYear) string { return y.DaysWithRainfall }},
+	{"LongestDaysRaining", func(y *WeatherDataForYear) string { return y.LongestDaysRaining }},
+	{"VarianceDailyRainfall", func(y *WeatherDataForYear) string { return y.VarianceDailyRainfall }},
+	{"StdDevDailyRainfall", func(y *WeatherDataForYear) string { return y.StdDevDailyRainfall }},
+	{"CoefficientOfVariation", func(y *WeatherDataForYear) string { return y.CoefficientOfVariation }},
+	{"ExpectedDays", func(y *WeatherDataForYear) string { return y.ExpectedDays }},
+	{"RecordedDays", func(y *WeatherDataForYear) string { return y.RecordedDays }},
+	{"PercentComplete", func(y *WeatherDataForYear) string { return y.PercentComplete }},
+	{"ImputedDays", func(y *WeatherDataForYear) string { return y.ImputedDays }},
+	{"ImputedRainfall", func(y *WeatherDataForYear) string { return y.ImputedRainfall }},
+	{"Unreliable", func(y *WeatherDataForYear) string { return y.Unreliable }},
+}
+
+// monthColumns are the columns of the monthly CSV summary after Year, in
+// output order
+var monthColumns = []summaryColumn[WeatherDataForMonth]{
+	{"Month", func(m *WeatherDataForMonth) string { return m.Month }},
+	{"FirstRecordedDate", func(m *WeatherDataForMonth) string { return m.FirstRecordedDate }},
+	{"LastRecordedDate", func(m *WeatherDataForMonth) string { return m.LastRecordedDate }},
+	{"TotalRainfall", func(m *WeatherDataForMonth) string { return m.TotalRainfall }},
+	{"AverageDailyRainfall", func(m *WeatherDataForMonth) string { return m.AverageDailyRainfall }},
+	{"MedianDailyRainfall", func(m *WeatherDataForMonth) string { return m.MedianDailyRainfall }},
+	{"DaysWithNoRainfall", func(m *WeatherDataForMonth) string { return m.DaysWithNoRainfall }},
+	{"DaysWithRainfall", func(m *WeatherDataForMonth) string { return m.DaysWithRainfall }},
+	{"VarianceDailyRainfall", func(m *WeatherDataForMonth) string { return m.VarianceDailyRainfall }},
+	{"StdDevDailyRainfall", func(m *WeatherDataForMonth) string { return m.StdDevDailyRainfall }},
+	{"CoefficientOfVariation", func(m *WeatherDataForMonth) string { return m.CoefficientOfVariation }},
+	{"ExpectedDays", func(m *WeatherDataForMonth) string { return m.ExpectedDays }},
+	{"RecordedDays", func(m *WeatherDataForMonth) string { return m.RecordedDays }},
+	{"PercentComplete", func(m *WeatherDataForMonth) string { return m.PercentComplete }},
+	{"ImputedDays", func(m *WeatherDataForMonth) string { return m.ImputedDays }},
+	{"ImputedRainfall", func(m *WeatherDataForMonth) string { return m.ImputedRainfall }},
+	{"Unreliable", func(m *WeatherDataForMonth) string { return m.Unreliable }},
+}
+
+// additionalStatisticNames returns the sorted names of every additional
+// statistic in the given maps
+func additionalStatisticNames(stats []map[string]string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, s := range stats {
+		for name := range s {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writeYearsCSV writes one row per year with the yearly statistics.
+// Additional statistics follow as extra columns, and unavailable values are
+// empty cells.
+func writeYearsCSV(w io.Writer, data WeatherData) error {
+	var stats []map[string]string
+	for _, y := range data.WeatherDataForYear {
+		stats = append(stats, y.AdditionalStatistics)
+	}
+	additional := additionalStatisticNames(stats)
+
+	csvWriter := csv.NewWriter(w)
+	header := make([]string, 0, len(yearColumns)+len(additional))
+	for _, c := range yearColumns {
+		header = append(header, c.name)
+	}
+	if err := csvWriter.Write(append(header, additional...)); err != nil {
+		return fmt.Errorf("failed to write yearly summary header: %w", err)
+	}
+
+	for i := range data.WeatherDataForYear {
+		y := &data.WeatherDataForYear[i]
+		row := make([]string, 0, len(header)+len(additional))
+		for _, c := range yearColumns {
+			row = append(row, c.value(y))
+		}
+		for _, name := range additional {
+			row = append(row, y.AdditionalStatistics[name])
+		}
+		if err := csvWriter.Write(row); err != nil {
+			return fmt.Errorf("failed to write yearly summary row for %s: %w", y.Year, err)
+		}
+	}
+
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return fmt.Errorf("failed to write yearly summary: %w", err)
+	}
+	return nil
+}
+
+// writeMonthsCSV writes one row per month with the monthly statistics.
+// Additional statistics follow as extra columns, and unavailable values are
+// empty cells.
+func writeMonthsCSV(w io.Writer, data WeatherData) error {
+	var stats []map[string]string
+	for _, y := range data.WeatherDataForYear {
+		for _, m := range y.MonthlyAggregates.WeatherDataForMonth {
+			stats = append(stats, m.AdditionalStatistics)
+		}
+	}
+	additional := additionalStatisticNames(stats)
+
+	csvWriter := csv.NewWriter(w)
+	header := []string{"Year"}
+	for _, c := range monthColumns {
+		header = append(header, c.name)
+	}
+	if err := csvWriter.Write(append(header, additional...)); err != nil {
+		return fmt.Errorf("failed to write monthly summary header: %w", err)
+	}
+
+	for _, y := range data.WeatherDataForYear {
+		for i := range y.MonthlyAggregates.WeatherDataForMonth {
+			m := &y.MonthlyAggregates.WeatherDataForMonth[i]
+			row := make([]string, 0, len(header)+len(additional))
+			row = append(row, y.Year)
+			for _, c := range monthColumns {
+				row = append(row, c.value(m))
+			}
+			for _, name := range additional {
+				row = append(row, m.AdditionalStatistics[name])
+			}
+			if err := csvWriter.Write(row); err != nil {
+				return fmt.Errorf("failed to write monthly summary row for %s %s: %w", m.Month, y.Year, err)
+			}
+		}
+	}
+
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return fmt.Errorf("failed to write monthly summary: %w", err)
+	}
+	return nil
+}
+
+// writeNDJSON writes one JSON object per line: a line per month followed by
+// a line for its year, each carrying the station and period keys. Each year
+// is written and flushed as years produces it, and the interannual
+// variability and metadata of the document are not written.
+func writeNDJSON(w io.Writer, data WeatherData, years YearSource, schema SchemaVersion) error {
+	buffered := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buffered)
+	computed := computedStatistics(data.Metadata)
+	_, err := years(func(year WeatherDataForYear) error {
+		var lines []any
+		if schema == SchemaV2 {
+			v2, err := newWeatherDataForYearV2(year, computed)
+			if err != nil {
+				return fmt.Errorf("failed to convert year %s to schema %s: %w", year.Year, schema, err)
+			}
+			for j := range v2.MonthlyAggregates {
+				lines = append(lines, NDJSONMonthV2{Type: ndjsonMonthType, Station: data.Station, Year: v2.Year, WeatherDataForMonthV2: &v2.MonthlyAggregates[j]})
+			}
+			lines = append(lines, NDJSONYearV2{Type: ndjsonYearType, Station: data.Station, WeatherDataForYearV2: &v2})
+		} else {
+			months := year.MonthlyAggregates.WeatherDataForMonth
+			for j := range months {
+				lines = append(lines, NDJSONMonth{Type: ndjsonMonthType, Station: data.Station, Year: year.Year, WeatherDataForMonth: &months[j]})
+			}
+			lines = append(lines, NDJSONYear{Type: ndjsonYearType, Station: data.Station, WeatherDataForYear: &year})
+		}
+
+		for _, line := range lines {
+			if err := encoder.Encode(line); err != nil {
+				return fmt.Errorf("failed to write NDJSON for year %s: %w", year.Year, err)
+			}
+		}
+		if err := buffered.Flush(); err != nil {
+			return fmt.Errorf("failed to write NDJSON for year %s: %w", year.Year, err)
+		}
+		return nil
+	})
+	return err
+}
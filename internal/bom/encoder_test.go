@@ -0,0 +1,282 @@
+package bom
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func encoderTestData() WeatherData {
+	return WeatherData{
+		WeatherDataForYear: []WeatherDataForYear{
+			{
+				Year:                 "2019",
+				TotalRainfall:        "374.2",
+				DaysWithRainfall:     "44",
+				ExpectedDays:         "109",
+				AdditionalStatistics: map[string]string{"Wettest": "65.0"},
+				MonthlyAggregates: MonthlyAggregates{WeatherDataForMonth: []WeatherDataForMonth{
+					{Month: "January", TotalRainfall: "48.8"},
+					{Month: "February", Unreliable: "true", AdditionalStatistics: map[string]string{"Driest": "0.0"}},
+				}},
+			},
+			{Year: "2020", TotalRainfall: "12.0"},
+		},
+	}
+}
+
+func readCSV(t *testing.T, s string) [][]string {
+	t.Helper()
+	rows, err := csv.NewReader(strings.NewReader(s)).ReadAll()
+	if err != nil {
+		t.Fatalf("Output is not valid CSV: %v", err)
+	}
+	return rows
+}
+
+// column returns the index of a header column
+func column(t *testing.T, header []string, name string) int {
+	t.Helper()
+	for i, h := range header {
+		if h == name {
+			return i
+		}
+	}
+	t.Fatalf("Expected column %s in %v", name, header)
+	return -1
+}
+
+func TestDefaultEncoders(t *testing.T) {
+	names := DefaultEncoders().Names()
+	want := []string{FormatJSON, FormatNDJSON, FormatXML, FormatCSVYears, FormatCSVMonths, FormatTable, FormatMarkdown}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected formats %v, got %v", want, names)
+	}
+	if _, err := DefaultEncoders().Lookup("xlsx"); err == nil || !strings.Contains(err.Error(), "csv-years") {
+		t.Errorf("Expected an unknown format error listing the formats, got %v", err)
+	}
+}
+
+func TestEncoderRegistry_Register(t *testing.T) {
+	r := NewEncoderRegistry()
+	custom := func(ConverterOptions) Encoder {
+		return EncoderFunc(func(w io.Writer, data WeatherData) error {
+			_, err := io.WriteString(w, "years: "+data.WeatherDataForYear[0].Year)
+			return err
+		})
+	}
+	if err := r.Register("custom", custom); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := r.Register("custom", custom); err == nil {
+		t.Error("Expected error for a duplicate format")
+	}
+	if err := r.Register("", custom); err == nil {
+		t.Error("Expected error for an empty name")
+	}
+
+	var buf bytes.Buffer
+	conv := NewConverterWithOptions(ConverterOptions{Format: "custom", Encoders: r})
+	if err := conv.Encode(&buf, encoderTestData()); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if buf.String() != "years: 2019" {
+		t.Errorf("Expected the custom encoder output, got %q", buf.String())
+	}
+
+	if err := NewConverterWithOptions(ConverterOptions{Format: "missing"}).Encode(&buf, WeatherData{}); err == nil {
+		t.Error("Expected error for an unknown format")
+	}
+}
+
+func TestConverter_EncodeDefaultsToJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewConverter().Encode(&buf, encoderTestData()); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	want, err := NewConverter().ToJSON(encoderTestData())
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if buf.String() != string(want) {
+		t.Errorf("Expected JSON output, got: %s", buf.String())
+	}
+}
+
+func TestConverter_EncodeStream(t *testing.T) {
+	data := encoderTestData()
+	head := WeatherData{Metadata: data.Metadata, Station: data.Station}
+	for _, format := range []string{FormatJSON, FormatNDJSON, FormatCSVYears, FormatXML} {
+		conv := NewConverterWithOptions(ConverterOptions{Format: format})
+		var want, got bytes.Buffer
+		if err := conv.Encode(&want, data); err != nil {
+			t.Fatalf("%s: expected no error, got: %v", format, err)
+		}
+		if err := conv.EncodeStream(&got, head, yearsOf(data)); err != nil {
+			t.Fatalf("%s: expected no error, got: %v", format, err)
+		}
+		if got.String() != want.String() {
+			t.Errorf("%s: expected\n%s\ngot\n%s", format, want.String(), got.String())
+		}
+	}
+
+	failing := func(func(WeatherDataForYear) error) ([]CalendarMonthVariability, error) {
+		return nil, errors.New("source failed")
+	}
+	for _, format := range []string{FormatJSON, FormatCSVYears} {
+		err := NewConverterWithOptions(ConverterOptions{Format: format}).EncodeStream(io.Discard, head, failing)
+		if err == nil || !strings.Contains(err.Error(), "source failed") {
+			t.Errorf("%s: expected the source error, got: %v", format, err)
+		}
+	}
+}
+
+func TestWriteYearsCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewConverterWithOptions(ConverterOptions{Format: FormatCSVYears}).Encode(&buf, encoderTestData()); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	rows := readCSV(t, buf.String())
+	if len(rows) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got %v", rows)
+	}
+	header := rows[0]
+	if header[0] != "Year" || header[len(header)-1] != "Wettest" {
+		t.Errorf("Unexpected header %v", header)
+	}
+	total := column(t, header, "TotalRainfall")
+	if rows[1][total] != "374.2" || rows[2][total] != "12.0" {
+		t.Errorf("Unexpected totals %q and %q", rows[1][total], rows[2][total])
+	}
+	if rows[1][len(header)-1] != "65.0" || rows[2][len(header)-1] != "" {
+		t.Errorf("Unexpected additional statistic cells %v and %v", rows[1], rows[2])
+	}
+}
+
+func TestWriteMonthsCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewConverterWithOptions(ConverterOptions{Format: FormatCSVMonths}).Encode(&buf, encoderTestData()); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	rows := readCSV(t, buf.String())
+	if len(rows) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got %v", rows)
+	}
+	header := rows[0]
+	if header[0] != "Year" || header[1] != "Month" || header[len(header)-1] != "Driest" {
+		t.Errorf("Unexpected header %v", header)
+	}
+	if rows[1][0] != "2019" || rows[1][1] != "January" || rows[1][column(t, header, "TotalRainfall")] != "48.8" {
+		t.Errorf("Unexpected January row %v", rows[1])
+	}
+	if rows[2][column(t, header, "Unreliable")] != "true" || rows[2][len(header)-1] != "0.0" {
+		t.Errorf("Unexpected February row %v", rows[2])
+	}
+}
+
+// readNDJSON decodes each line of NDJSON output into a map
+func readNDJSON(t *testing.T, s string) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+		var v map[string]any
+		if err := json.Unmarshal([]byte(line), &v); err != nil {
+			t.Fatalf("Line is not a JSON object: %q: %v", line, err)
+		}
+		lines = append(lines, v)
+	}
+	return lines
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	data := encoderTestData()
+	data.Station = "066062"
+
+	var buf bytes.Buffer
+	if err := NewConverterWithOptions(ConverterOptions{Format: FormatNDJSON}).Encode(&buf, data); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	lines := readNDJSON(t, buf.String())
+	if len(lines) != 4 {
+		t.Fatalf("Expected 2 month lines and 2 year lines, got %d: %s", len(lines), buf.String())
+	}
+	types := []string{"month", "month", "year", "year"}
+	for i, want := range types {
+		if lines[i]["Type"] != want || lines[i]["Station"] != "066062" {
+			t.Errorf("Line %d: expected a %s line for station 066062, got %v", i, want, lines[i])
+		}
+	}
+	if lines[0]["Year"] != "2019" || lines[0]["Month"] != "January" || lines[0]["TotalRainfall"] != "48.8" {
+		t.Errorf("Unexpected January line %v", lines[0])
+	}
+	if lines[2]["Year"] != "2019" || lines[2]["TotalRainfall"] != "374.2" {
+		t.Errorf("Unexpected 2019 line %v", lines[2])
+	}
+	if _, ok := lines[2]["MonthlyAggregates"]; ok {
+		t.Errorf("Expected year lines without their months, got %v", lines[2])
+	}
+}
+
+func TestWriteNDJSON_WritesEachYearAsItIsProduced(t *testing.T) {
+	data := encoderTestData()
+	var buf bytes.Buffer
+	var written []int
+	years := func(yield func(WeatherDataForYear) error) ([]CalendarMonthVariability, error) {
+		for _, year := range data.WeatherDataForYear {
+			if err := yield(year); err != nil {
+				return nil, err
+			}
+			written = append(written, strings.Count(buf.String(), "\n"))
+		}
+		return nil, nil
+	}
+	conv := NewConverterWithOptions(ConverterOptions{Format: FormatNDJSON})
+	if err := conv.EncodeStream(&buf, WeatherData{}, years); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if want := []int{3, 4}; !reflect.DeepEqual(written, want) {
+		t.Errorf("Expected %v lines written after each year, got %v", want, written)
+	}
+}
+
+func TestWriteNDJSON_V2(t *testing.T) {
+	data := encoderTestData()
+	data.WeatherDataForYear = data.WeatherDataForYear[:1]
+	year := &data.WeatherDataForYear[0]
+	year.RecordedDays, year.PercentComplete = "109", "100.0"
+	for i := range year.MonthlyAggregates.WeatherDataForMonth {
+		m := &year.MonthlyAggregates.WeatherDataForMonth[i]
+		m.ExpectedDays, m.RecordedDays, m.PercentComplete = "31", "31", "100.0"
+	}
+
+	var buf bytes.Buffer
+	conv := NewConverterWithOptions(ConverterOptions{Format: FormatNDJSON, Schema: SchemaV2})
+	if err := conv.Encode(&buf, data); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	lines := readNDJSON(t, buf.String())
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %d: %s", len(lines), buf.String())
+	}
+	if _, ok := lines[0]["Station"]; ok {
+		t.Errorf("Expected no station when it is unknown, got %v", lines[0])
+	}
+	if lines[1]["Year"] != float64(2019) || lines[1]["Month"] != "2019-02" || lines[1]["TotalRainfall"] != nil {
+		t.Errorf("Unexpected February line %v", lines[1])
+	}
+	if lines[2]["Type"] != "year" || lines[2]["TotalRainfall"] != 374.2 {
+		t.Errorf("Unexpected year line %v", lines[2])
+	}
+	if _, ok := lines[2]["MonthlyAggregates"]; ok {
+		t.Errorf("Expected year lines without their months, got %v", lines[2])
+	}
+}
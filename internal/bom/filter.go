@@ -0,0 +1,244 @@
+package bom
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Predicate reports whether a daily record should be kept
+type Predicate func(DailyRecord) bool
+
+// All returns a Predicate that accepts a record only if every predicate does.
+// With no predicates it accepts every record.
+func All(predicates ...Predicate) Predicate {
+	return func(rec DailyRecord) bool {
+		for _, p := range predicates {
+			if !p(rec) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Any returns a Predicate that accepts a record if at least one predicate does
+func Any(predicates ...Predicate) Predicate {
+	return func(rec DailyRecord) bool {
+		for _, p := range predicates {
+			if p(rec) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not returns a Predicate that accepts the records p rejects
+func Not(p Predicate) Predicate {
+	return func(rec DailyRecord) bool {
+		return !p(rec)
+	}
+}
+
+// DateRange accepts records dated from from to to inclusive. A zero time
+// leaves that end of the range open.
+func DateRange(from, to time.Time) Predicate {
+	from = truncateToDay(from)
+	to = truncateToDay(to)
+	return func(rec DailyRecord) bool {
+		date := truncateToDay(rec.Date)
+		if !from.IsZero() && date.Before(from) {
+			return false
+		}
+		if !to.IsZero() && date.After(to) {
+			return false
+		}
+		return true
+	}
+}
+
+// InYears accepts records from any of the given years
+func InYears(years ...int) Predicate {
+	set := make(map[int]bool, len(years))
+	for _, y := range years {
+		set[y] = true
+	}
+	return func(rec DailyRecord) bool {
+		return set[rec.Date.Year()]
+	}
+}
+
+// InMonths accepts records from any of the given calendar months
+func InMonths(months ...time.Month) Predicate {
+	var set [13]bool
+	for _, m := range months {
+		set[m] = true
+	}
+	return func(rec DailyRecord) bool {
+		return set[rec.Date.Month()]
+	}
+}
+
+// FilterRecords returns the records accepted by the predicate
+func FilterRecords(records []DailyRecord, keep Predicate) []DailyRecord {
+	var filtered []DailyRecord
+	for _, rec := range records {
+		if keep(rec) {
+			filtered = append(filtered, rec)
+		}
+	}
+	return filtered
+}
+
+// Filter is a declarative combination of the date filters exposed by the CLI.
+// Zero-valued fields do not restrict the records.
+type Filter struct {
+	From   time.Time
+	To     time.Time
+	Years  []int
+	Months []time.Month
+}
+
+// IsZero reports whether the filter accepts every record
+func (f Filter) IsZero() bool {
+	return f.From.IsZero() && f.To.IsZero() && len(f.Years) == 0 && len(f.Months) == 0
+}
+
+// Predicate returns the Predicate equivalent of the filter
+func (f Filter) Predicate() Predicate {
+	var predicates []Predicate
+	if !f.From.IsZero() || !f.To.IsZero() {
+		predicates = append(predicates, DateRange(f.From, f.To))
+	}
+	if len(f.Years) > 0 {
+		predicates = append(predicates, InYears(f.Years...))
+	}
+	if len(f.Months) > 0 {
+		predicates = append(predicates, InMonths(f.Months...))
+	}
+	return All(predicates...)
+}
+
+// Metadata describes the filter for inclusion in the output
+func (f Filter) Metadata() *FilterMetadata {
+	if f.IsZero() {
+		return nil
+	}
+	meta := &FilterMetadata{}
+	if !f.From.IsZero() {
+		meta.From = f.From.Format("2006-01-02")
+	}
+	if !f.To.IsZero() {
+		meta.To = f.To.Format("2006-01-02")
+	}
+	for _, y := range f.Years {
+		meta.Years = append(meta.Years, strconv.Itoa(y))
+	}
+	for _, m := range f.Months {
+		meta.Months = append(meta.Months, m.String())
+	}
+	return meta
+}
+
+// maxFilterYear is the latest year accepted by ParseYears, the last with
+// four digits
+const maxFilterYear = 9999
+
+// ParseYears parses a comma-separated list of years and inclusive year
+// ranges, such as "1961-1990,2019". Years must be between 1 and 9999.
+func ParseYears(value string) ([]int, error) {
+	var selected [maxFilterYear + 1]bool
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		startStr, endStr, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(startStr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid year '%s'", part)
+		}
+		end := start
+		if isRange {
+			end, err = strconv.Atoi(strings.TrimSpace(endStr))
+			if err != nil {
+				return nil, fmt.Errorf("invalid year range '%s'", part)
+			}
+		}
+		if start < 1 || end > maxFilterYear {
+			return nil, fmt.Errorf("year out of range in '%s' (expected 1 to %d)", part, maxFilterYear)
+		}
+		if end < start {
+			return nil, fmt.Errorf("invalid year range '%s': end is before start", part)
+		}
+		for y := start; y <= end; y++ {
+			selected[y] = true
+		}
+	}
+
+	var years []int
+	for y, ok := range selected {
+		if ok {
+			years = append(years, y)
+		}
+	}
+	return years, nil
+}
+
+// ParseMonths parses a comma-separated list of months and inclusive month
+// ranges given as numbers or names, such as "1,2" or "nov-mar". Ranges wrap
+// around the end of the year.
+func ParseMonths(value string) ([]time.Month, error) {
+	var selected [13]bool
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		startStr, endStr, isRange := strings.Cut(part, "-")
+		start, err := parseMonth(startStr)
+		if err != nil {
+			return nil, err
+		}
+		end := start
+		if isRange {
+			if end, err = parseMonth(endStr); err != nil {
+				return nil, err
+			}
+		}
+		for m := start; ; m = m%12 + 1 {
+			selected[m] = true
+			if m == end {
+				break
+			}
+		}
+	}
+
+	var months []time.Month
+	for m := time.January; m <= time.December; m++ {
+		if selected[m] {
+			months = append(months, m)
+		}
+	}
+	return months, nil
+}
+
+// parseMonth parses a month number (1-12), full name or three-letter abbreviation
+func parseMonth(value string) (time.Month, error) {
+	value = strings.TrimSpace(value)
+	if n, err := strconv.Atoi(value); err == nil {
+		if n < 1 || n > 12 {
+			return 0, fmt.Errorf("month out of range: %d", n)
+		}
+		return time.Month(n), nil
+	}
+	for m := time.January; m <= time.December; m++ {
+		name := m.String()
+		if strings.EqualFold(value, name) || strings.EqualFold(value, name[:3]) {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid month '%s'", value)
+}
@@ -0,0 +1,134 @@
+package bom
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPredicates(t *testing.T) {
+	jan := DailyRecord{Date: day(2020, 1, 15)}
+	jun := DailyRecord{Date: day(2020, 6, 15)}
+	dec := DailyRecord{Date: day(2021, 12, 15)}
+
+	testCases := []struct {
+		name     string
+		pred     Predicate
+		expected []bool
+	}{
+		{"date range", DateRange(day(2020, 1, 15), day(2020, 6, 15)), []bool{true, true, false}},
+		{"open start", DateRange(time.Time{}, day(2020, 1, 15)), []bool{true, false, false}},
+		{"open end", DateRange(day(2020, 6, 1), time.Time{}), []bool{false, true, true}},
+		{"years", InYears(2021), []bool{false, false, true}},
+		{"months", InMonths(time.December, time.January), []bool{true, false, true}},
+		{"all", All(InYears(2020), InMonths(time.June)), []bool{false, true, false}},
+		{"all empty", All(), []bool{true, true, true}},
+		{"any", Any(InYears(2021), InMonths(time.January)), []bool{true, false, true}},
+		{"not", Not(InYears(2020)), []bool{false, false, true}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for i, rec := range []DailyRecord{jan, jun, dec} {
+				if got := tc.pred(rec); got != tc.expected[i] {
+					t.Errorf("Record %s: expected %v, got %v", rec.Date.Format("2006-01-02"), tc.expected[i], got)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterRecords(t *testing.T) {
+	records := []DailyRecord{
+		{Date: day(2019, 12, 31)},
+		{Date: day(2020, 1, 1)},
+		{Date: day(2020, 2, 1)},
+	}
+	filtered := FilterRecords(records, InYears(2020))
+	if len(filtered) != 2 || !filtered[0].Date.Equal(day(2020, 1, 1)) {
+		t.Errorf("Expected the two 2020 records, got %+v", filtered)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	var zero Filter
+	if !zero.IsZero() || zero.Metadata() != nil {
+		t.Error("Expected zero filter to be empty with no metadata")
+	}
+	if !zero.Predicate()(DailyRecord{Date: day(1858, 1, 1)}) {
+		t.Error("Expected zero filter to accept every record")
+	}
+
+	f := Filter{From: day(2000, 1, 1), Years: []int{2000, 2001}, Months: []time.Month{time.November, time.December}}
+	if f.IsZero() {
+		t.Error("Expected non-zero filter")
+	}
+	keep := f.Predicate()
+	if !keep(DailyRecord{Date: day(2001, 11, 5)}) || keep(DailyRecord{Date: day(2001, 10, 5)}) || keep(DailyRecord{Date: day(1999, 11, 5)}) {
+		t.Error("Filter predicate did not combine its parts")
+	}
+
+	meta := f.Metadata()
+	expected := &FilterMetadata{From: "2000-01-01", Years: []string{"2000", "2001"}, Months: []string{"November", "December"}}
+	if !reflect.DeepEqual(meta, expected) {
+		t.Errorf("Expected metadata %+v, got %+v", expected, meta)
+	}
+}
+
+func TestParseYears(t *testing.T) {
+	years, err := ParseYears("2019, 1961-1963,1962")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !reflect.DeepEqual(years, []int{1961, 1962, 1963, 2019}) {
+		t.Errorf("Unexpected years %v", years)
+	}
+
+	// Ranges are bounded so that they cannot allocate a year per integer
+	for _, value := range []string{"abc", "1990-abc", "1999-1990", "0", "1-999999999", "10000"} {
+		if _, err := ParseYears(value); err == nil {
+			t.Errorf("Expected error for %q", value)
+		}
+	}
+}
+
+func TestParseMonths(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected []time.Month
+	}{
+		{"1,2", []time.Month{time.January, time.February}},
+		{"nov-mar", []time.Month{time.January, time.February, time.March, time.November, time.December}},
+		{"June", []time.Month{time.June}},
+		{"3-5", []time.Month{time.March, time.April, time.May}},
+	}
+	for _, tc := range testCases {
+		months, err := ParseMonths(tc.value)
+		if err != nil {
+			t.Errorf("%q: expected no error, got: %v", tc.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(months, tc.expected) {
+			t.Errorf("%q: expected %v, got %v", tc.value, tc.expected, months)
+		}
+	}
+
+	for _, value := range []string{"13", "smarch", "jan-foo"} {
+		if _, err := ParseMonths(value); err == nil {
+			t.Errorf("Expected error for %q", value)
+		}
+	}
+}
+
+func TestAggregate_DayFilterExpectedDays(t *testing.T) {
+	options := DefaultAggregatorOptions()
+	options.DayFilter = InMonths(time.January)
+	agg := NewAggregatorWithOptions(options)
+
+	records := []DailyRecord{{Date: day(2020, 1, 1), Rainfall: mm(1.0), HasData: true}}
+	yearData := agg.Aggregate(records).WeatherDataForYear[0]
+
+	if yearData.ExpectedDays != "31" {
+		t.Errorf("Expected only January to count towards expected days, got %s", yearData.ExpectedDays)
+	}
+}
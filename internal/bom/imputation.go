@@ -0,0 +1,204 @@
+package bom
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ImputationMethod records how the reading of an imputed day was estimated
+type ImputationMethod string
+
+const (
+	// ImputeNeighbour estimates a day from a neighbouring station's reading,
+	// scaled by the ratio of the two stations' totals over their overlap
+	ImputeNeighbour ImputationMethod = "neighbour"
+	// ImputeClimatology estimates a day as the station's mean daily rainfall
+	// for that calendar month
+	ImputeClimatology ImputationMethod = "climatology"
+)
+
+// ImputationOptions controls how missing days are filled
+type ImputationOptions struct {
+	// Neighbour is the daily record of a nearby station. A nil Neighbour
+	// disables neighbour imputation.
+	Neighbour []DailyRecord
+	// Climatology fills the days the neighbour cannot, or every missing day
+	// when there is no neighbour
+	Climatology bool
+}
+
+// IsZero reports whether no imputation method is enabled
+func (o ImputationOptions) IsZero() bool {
+	return o.Neighbour == nil && !o.Climatology
+}
+
+// ImputationSummary describes the days filled by Impute
+type ImputationSummary struct {
+	// NeighbourScale is the ratio of the station's total to the neighbour's
+	// over the days both recorded. It is zero when no neighbour was used.
+	NeighbourScale float64
+	NeighbourDays  int
+	// ClimatologyDays is the number of days filled from climatology
+	ClimatologyDays int
+}
+
+// Metadata returns the output metadata of the imputation
+func (s ImputationSummary) Metadata(opts ImputationOptions) *ImputationMetadata {
+	if opts.IsZero() {
+		return nil
+	}
+	meta := &ImputationMetadata{}
+	if opts.Neighbour != nil {
+		meta.Methods = append(meta.Methods, string(ImputeNeighbour))
+		meta.NeighbourScale = formatFloat(s.NeighbourScale, 4)
+		meta.NeighbourDays = strconv.Itoa(s.NeighbourDays)
+	}
+	if opts.Climatology {
+		meta.Methods = append(meta.Methods, string(ImputeClimatology))
+		meta.ClimatologyDays = strconv.Itoa(s.ClimatologyDays)
+	}
+	return meta
+}
+
+// Impute fills the days without a reading between the first and last
+// records, whether the record has a blank row for the day or no row at all.
+// Each filled day has HasData set and Imputed set to the method used, and
+// keeps the quality flag, period and station of its blank row. The neighbour
+// is tried first and climatology second; days that neither can estimate are
+// left missing. Observed days are returned unchanged.
+func Impute(records []DailyRecord, opts ImputationOptions) ([]DailyRecord, ImputationSummary, error) {
+	var summary ImputationSummary
+	if len(records) == 0 || opts.IsZero() {
+		return records, summary, nil
+	}
+
+	observed := recordedDays(records)
+	var neighbour map[time.Time]DailyRecord
+	if opts.Neighbour != nil {
+		neighbour = recordedDays(opts.Neighbour)
+		scale, err := neighbourScale(observed, neighbour)
+		if err != nil {
+			return nil, summary, err
+		}
+		summary.NeighbourScale = scale
+	}
+	var climatology [13]Amount
+	var hasClimatology [13]bool
+	if opts.Climatology {
+		climatology, hasClimatology = monthlyClimatology(observed)
+	}
+
+	// Blank rows keep their quality flag, period and station when filled
+	blank := make(map[time.Time]DailyRecord)
+	first, last := truncateToDay(records[0].Date), truncateToDay(records[0].Date)
+	for _, rec := range records {
+		date := truncateToDay(rec.Date)
+		if !rec.HasData {
+			rec.Date = date
+			blank[date] = rec
+		}
+		if date.Before(first) {
+			first = date
+		}
+		if date.After(last) {
+			last = date
+		}
+	}
+
+	filled := make([]DailyRecord, 0, len(records))
+	for date := first; !date.After(last); date = date.AddDate(0, 0, 1) {
+		if rec, ok := observed[date]; ok {
+			filled = append(filled, rec)
+			continue
+		}
+		estimate, ok := blank[date]
+		if !ok {
+			estimate = DailyRecord{Date: date}
+		}
+		if n, ok := neighbour[date]; ok {
+			estimate.Rainfall = AmountFromFloat(n.Rainfall.Float64() * summary.NeighbourScale)
+			estimate.HasData = true
+			estimate.Imputed = ImputeNeighbour
+			summary.NeighbourDays++
+		} else if hasClimatology[date.Month()] {
+			estimate.Rainfall = climatology[date.Month()]
+			estimate.HasData = true
+			estimate.Imputed = ImputeClimatology
+			summary.ClimatologyDays++
+		}
+		filled = append(filled, estimate)
+	}
+	return filled, summary, nil
+}
+
+// neighbourScale returns the ratio of the station's total to the
+// neighbour's over the days both recorded
+func neighbourScale(observed, neighbour map[time.Time]DailyRecord) (float64, error) {
+	var total, neighbourTotal Amount
+	common := 0
+	for date, rec := range observed {
+		if n, ok := neighbour[date]; ok {
+			total += rec.Rainfall
+			neighbourTotal += n.Rainfall
+			common++
+		}
+	}
+	if common == 0 {
+		return 0, fmt.Errorf("the neighbour station has no days recorded in common")
+	}
+	if neighbourTotal == 0 {
+		return 0, fmt.Errorf("the neighbour station recorded no rain over the %d days in common", common)
+	}
+	return float64(total) / float64(neighbourTotal), nil
+}
+
+// monthlyClimatology returns the mean daily rainfall of each calendar month
+// over the observed days, rounded to the resolution of an Amount
+func monthlyClimatology(observed map[time.Time]DailyRecord) ([13]Amount, [13]bool) {
+	var totals [13]Amount
+	var days [13]int
+	for date, rec := range observed {
+		totals[date.Month()] += rec.Rainfall
+		days[date.Month()]++
+	}
+	var means [13]Amount
+	var ok [13]bool
+	for m := time.January; m <= time.December; m++ {
+		if days[m] > 0 {
+			means[m] = AmountFromFloat(totals[m].Float64() / float64(days[m]))
+			ok[m] = true
+		}
+	}
+	return means, ok
+}
+
+// observed reports whether the record holds a reading taken at the station
+// rather than an estimate
+func (r DailyRecord) observed() bool {
+	return r.HasData && r.Imputed == ""
+}
+
+// imputedTally counts the imputed days of a period and their rainfall
+type imputedTally struct {
+	days  int
+	total Amount
+}
+
+// add counts rec if it was imputed
+func (t *imputedTally) add(rec DailyRecord) {
+	if rec.Imputed != "" {
+		t.days++
+		t.total += rec.Rainfall
+	}
+}
+
+// apply sets the imputed output fields of a period, which are left empty
+// when no day was imputed
+func (t imputedTally) apply(days, rainfall *string, p Precision) {
+	if t.days == 0 {
+		return
+	}
+	*days = strconv.Itoa(t.days)
+	*rainfall = p.FormatAmount(t.total)
+}
@@ -0,0 +1,185 @@
+package bom
+
+import (
+	"testing"
+	"time"
+)
+
+func TestImpute(t *testing.T) {
+	records := []DailyRecord{
+		{Date: day(2020, 1, 1), Rainfall: mm(2.0), HasData: true},
+		{Date: day(2020, 1, 2), HasData: false},
+		{Date: day(2020, 1, 4), Rainfall: mm(4.0), HasData: true},
+		{Date: day(2020, 1, 5), Rainfall: mm(0.0), HasData: true},
+	}
+	neighbour := []DailyRecord{
+		{Date: day(2020, 1, 1), Rainfall: mm(1.0), HasData: true},
+		{Date: day(2020, 1, 2), Rainfall: mm(1.5), HasData: true},
+		{Date: day(2020, 1, 4), Rainfall: mm(2.0), HasData: true},
+		{Date: day(2020, 1, 5), Rainfall: mm(0.0), HasData: true},
+	}
+
+	filled, summary, err := Impute(records, ImputationOptions{Neighbour: neighbour, Climatology: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if summary.NeighbourScale != 2 || summary.NeighbourDays != 1 || summary.ClimatologyDays != 1 {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+
+	want := []DailyRecord{
+		{Date: day(2020, 1, 1), Rainfall: mm(2.0), HasData: true},
+		{Date: day(2020, 1, 2), Rainfall: mm(3.0), HasData: true, Imputed: ImputeNeighbour},
+		// The January mean over observed days is 6.0 / 3
+		{Date: day(2020, 1, 3), Rainfall: mm(2.0), HasData: true, Imputed: ImputeClimatology},
+		{Date: day(2020, 1, 4), Rainfall: mm(4.0), HasData: true},
+		{Date: day(2020, 1, 5), Rainfall: mm(0.0), HasData: true},
+	}
+	if len(filled) != len(want) {
+		t.Fatalf("Expected %d records, got %+v", len(want), filled)
+	}
+	for i := range want {
+		if filled[i] != want[i] {
+			t.Errorf("Record %d: expected %+v, got %+v", i, want[i], filled[i])
+		}
+	}
+}
+
+func TestImpute_KeepsBlankRowFields(t *testing.T) {
+	records := []DailyRecord{
+		{Date: day(2020, 1, 1), Rainfall: mm(2.0), HasData: true, Quality: "Y", Period: 1, Station: "066062"},
+		{Date: day(2020, 1, 2), Quality: "N", Period: 1, Station: "066062"},
+		{Date: day(2020, 1, 4), Rainfall: mm(4.0), HasData: true, Quality: "Y", Period: 1, Station: "066062"},
+	}
+
+	filled, _, err := Impute(records, ImputationOptions{Climatology: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	want := []DailyRecord{
+		records[0],
+		{Date: day(2020, 1, 2), Rainfall: mm(3.0), HasData: true, Imputed: ImputeClimatology, Quality: "N", Period: 1, Station: "066062"},
+		// No row at all, so there is nothing to keep
+		{Date: day(2020, 1, 3), Rainfall: mm(3.0), HasData: true, Imputed: ImputeClimatology},
+		records[2],
+	}
+	if len(filled) != len(want) {
+		t.Fatalf("Expected %d records, got %+v", len(want), filled)
+	}
+	for i := range want {
+		if filled[i] != want[i] {
+			t.Errorf("Record %d: expected %+v, got %+v", i, want[i], filled[i])
+		}
+	}
+}
+
+func TestImpute_LeavesDaysWithoutEstimateMissing(t *testing.T) {
+	records := []DailyRecord{
+		{Date: day(2020, 1, 1), Rainfall: mm(2.0), HasData: true},
+		{Date: day(2020, 1, 3), Rainfall: mm(4.0), HasData: true},
+	}
+	neighbour := []DailyRecord{
+		{Date: day(2020, 1, 1), Rainfall: mm(2.0), HasData: true},
+		{Date: day(2020, 1, 2), HasData: false},
+	}
+
+	filled, summary, err := Impute(records, ImputationOptions{Neighbour: neighbour})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(filled) != 3 || filled[1].HasData || filled[1].Imputed != "" || summary.NeighbourDays != 0 {
+		t.Errorf("Expected 2020-01-02 to stay missing, got %+v", filled)
+	}
+}
+
+func TestImpute_Disabled(t *testing.T) {
+	records := []DailyRecord{{Date: day(2020, 1, 1), HasData: false}}
+	filled, summary, err := Impute(records, ImputationOptions{})
+	if err != nil || len(filled) != 1 || summary != (ImputationSummary{}) {
+		t.Errorf("Expected records unchanged, got %+v, %+v, %v", filled, summary, err)
+	}
+	if summary.Metadata(ImputationOptions{}) != nil {
+		t.Error("Expected no metadata without imputation")
+	}
+}
+
+func TestImpute_InvalidNeighbour(t *testing.T) {
+	records := []DailyRecord{{Date: day(2020, 1, 1), Rainfall: mm(2.0), HasData: true}}
+	for name, neighbour := range map[string][]DailyRecord{
+		"no overlap": {{Date: day(2020, 1, 2), Rainfall: mm(1.0), HasData: true}},
+		"no rain":    {{Date: day(2020, 1, 1), Rainfall: mm(0.0), HasData: true}},
+	} {
+		if _, _, err := Impute(records, ImputationOptions{Neighbour: neighbour}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestAggregator_ReportsImputedDays(t *testing.T) {
+	records := []DailyRecord{
+		{Date: day(2020, 1, 1), Rainfall: mm(2.0), HasData: true},
+		{Date: day(2020, 1, 2), Rainfall: mm(3.0), HasData: true, Imputed: ImputeNeighbour},
+		{Date: day(2020, 2, 1), Rainfall: mm(1.5), HasData: true, Imputed: ImputeClimatology},
+		{Date: day(2020, 3, 1), Rainfall: mm(1.0), HasData: true},
+	}
+	options := DefaultAggregatorOptions()
+	options.Clock = FixedClock{Time: time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC)}
+	options.Precision = &BOMPrecision
+
+	year := NewAggregatorWithOptions(options).Aggregate(records).WeatherDataForYear[0]
+	// Imputed days add to the total but are not recorded or wet days
+	if year.ImputedDays != "2" || year.ImputedRainfall != "4.5" || year.RecordedDays != "2" {
+		t.Errorf("Unexpected year imputation %q days, %q mm, %q recorded", year.ImputedDays, year.ImputedRainfall, year.RecordedDays)
+	}
+	if year.TotalRainfall != "7.5" || year.DaysWithRainfall != "2" || year.DaysWithNoRainfall != "0" {
+		t.Errorf("Unexpected year totals %q mm, %q wet, %q dry", year.TotalRainfall, year.DaysWithRainfall, year.DaysWithNoRainfall)
+	}
+	months := year.MonthlyAggregates.WeatherDataForMonth
+	if months[0].ImputedDays != "1" || months[0].ImputedRainfall != "3.0" {
+		t.Errorf("Unexpected January imputation %q days, %q mm", months[0].ImputedDays, months[0].ImputedRainfall)
+	}
+	if months[2].ImputedDays != "" || months[2].ImputedRainfall != "" {
+		t.Errorf("Expected no imputation fields for March, got %q and %q", months[2].ImputedDays, months[2].ImputedRainfall)
+	}
+}
+
+func TestAggregator_ClimatologyFilledDaysAreNotRainDays(t *testing.T) {
+	// January 2020 has four observed days, two of them wet, and 27 missing
+	records := []DailyRecord{
+		{Date: day(2020, 1, 1), Rainfall: mm(4.0), HasData: true},
+		{Date: day(2020, 1, 2), Rainfall: mm(0.0), HasData: true},
+		{Date: day(2020, 1, 3), Rainfall: mm(0.0), HasData: true},
+		{Date: day(2020, 1, 31), Rainfall: mm(2.0), HasData: true},
+	}
+	filled, summary, err := Impute(records, ImputationOptions{Climatology: true})
+	if err != nil {
+		t.Fatalf("Impute failed: %v", err)
+	}
+	if summary.ClimatologyDays != 27 {
+		t.Fatalf("Expected 27 days filled, got %d", summary.ClimatologyDays)
+	}
+
+	options := DefaultAggregatorOptions()
+	options.Clock = FixedClock{Time: time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC)}
+	options.Precision = &BOMPrecision
+	options.Statistics = AllStatistics()
+	year := NewAggregatorWithOptions(options).Aggregate(filled).WeatherDataForYear[0]
+	jan := year.MonthlyAggregates.WeatherDataForMonth[0]
+
+	// Each filled day holds the mean of 1.5 mm, yet only observed days count
+	if jan.DaysWithRainfall != "2" || jan.DaysWithNoRainfall != "2" || year.LongestDaysRaining != "1" {
+		t.Errorf("Expected 2 wet and 2 dry days and a longest run of 1, got %s, %s and %s",
+			jan.DaysWithRainfall, jan.DaysWithNoRainfall, year.LongestDaysRaining)
+	}
+	if jan.MedianDailyRainfall != "3.0" {
+		t.Errorf("Expected the median over observed wet days 3.0, got %s", jan.MedianDailyRainfall)
+	}
+	if jan.RecordedDays != "4" || jan.PercentComplete != "12.9" || jan.ImputedDays != "27" {
+		t.Errorf("Expected completeness of observed days only, got %s recorded, %s%%, %s imputed",
+			jan.RecordedDays, jan.PercentComplete, jan.ImputedDays)
+	}
+	if jan.TotalRainfall != "46.5" {
+		t.Errorf("Expected the filled days in the total 46.5, got %s", jan.TotalRainfall)
+	}
+}
@@ -0,0 +1,390 @@
+package bom
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONSchemaDialect is the JSON Schema draft of generated schemas
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// WeatherDataJSONSchema returns the JSON Schema of weather data JSON in the
+// given schema version. It is generated from the output types, following
+// encoding/json: fields without omitempty are required, and pointers, slices
+// and maps without omitempty may be null.
+func WeatherDataJSONSchema(version SchemaVersion) []byte {
+	root := reflect.TypeOf(WeatherData{})
+	if version == SchemaV2 {
+		root = reflect.TypeOf(WeatherDataV2{})
+	}
+	g := &jsonSchemaGenerator{defs: &schemaObject{}}
+	doc := &schemaObject{}
+	doc.set("$schema", JSONSchemaDialect)
+	doc.set("title", fmt.Sprintf("BOM weather data, schema %s", schemaVersionOrDefault(version)))
+	object := g.object(root)
+	for _, key := range object.keys {
+		doc.set(key, object.values[key])
+	}
+	doc.set("$defs", g.defs)
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		// The schema holds only strings, booleans and nested objects
+		panic(fmt.Sprintf("failed to marshal JSON Schema: %v", err))
+	}
+	return append(out, '\n')
+}
+
+func schemaVersionOrDefault(version SchemaVersion) SchemaVersion {
+	if version == "" {
+		return SchemaV1
+	}
+	return version
+}
+
+// schemaObject is a JSON object that keeps its keys in insertion order, so
+// that schemas list properties in the order they are output
+type schemaObject struct {
+	keys   []string
+	values map[string]any
+}
+
+func (o *schemaObject) set(key string, value any) {
+	if o.values == nil {
+		o.values = make(map[string]any)
+	}
+	if _, exists := o.values[key]; !exists {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// MarshalJSON writes the object's keys in insertion order
+func (o *schemaObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+var (
+	jsonNumberType       = reflect.TypeOf(json.Number(""))
+	nullNumberType       = reflect.TypeOf(NullNumber{})
+	nullIntType          = reflect.TypeOf(NullInt{})
+	statisticValueV2Type = reflect.TypeOf(StatisticValueV2{})
+)
+
+// jsonSchemaGenerator builds schemas from Go types, collecting named
+// structs under $defs
+type jsonSchemaGenerator struct {
+	defs *schemaObject
+}
+
+// schema returns the schema of values of type t
+func (g *jsonSchemaGenerator) schema(t reflect.Type) *schemaObject {
+	s := &schemaObject{}
+	switch {
+	case t == jsonNumberType:
+		s.set("type", "number")
+	case t == nullNumberType:
+		s.set("type", []string{"number", "null"})
+	case t == nullIntType:
+		s.set("type", []string{"integer", "null"})
+	case t == statisticValueV2Type:
+		s.set("type", []string{"number", "string", "null"})
+	case t.Kind() == reflect.String:
+		s.set("type", "string")
+	case t.Kind() == reflect.Bool:
+		s.set("type", "boolean")
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		s.set("type", "integer")
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		s.set("type", "number")
+	case t.Kind() == reflect.Pointer:
+		return g.schema(t.Elem())
+	case t.Kind() == reflect.Slice:
+		s.set("type", "array")
+		s.set("items", g.schema(t.Elem()))
+	case t.Kind() == reflect.Map:
+		s.set("type", "object")
+		s.set("additionalProperties", g.schema(t.Elem()))
+	case t.Kind() == reflect.Struct:
+		if _, done := g.defs.values[t.Name()]; !done {
+			// Reserve the name first so that recursive types terminate
+			g.defs.set(t.Name(), nil)
+			g.defs.set(t.Name(), g.object(t))
+		}
+		s.set("$ref", "#/$defs/"+t.Name())
+	default:
+		panic(fmt.Sprintf("no JSON Schema for type %s", t))
+	}
+	return s
+}
+
+// object returns the schema of a struct's JSON object
+func (g *jsonSchemaGenerator) object(t reflect.Type) *schemaObject {
+	properties := &schemaObject{}
+	var required []string
+	g.fields(t, properties, &required)
+
+	s := &schemaObject{}
+	s.set("type", "object")
+	s.set("properties", properties)
+	if len(required) > 0 {
+		s.set("required", required)
+	}
+	s.set("additionalProperties", false)
+	return s
+}
+
+// fields adds the JSON properties of a struct's fields, flattening embedded
+// structs as encoding/json does
+func (g *jsonSchemaGenerator) fields(t reflect.Type, properties *schemaObject, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		omitempty := strings.Contains(","+options+",", ",omitempty,")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.fields(embedded, properties, required)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		s := g.schema(field.Type)
+		switch field.Type.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map:
+			if !omitempty {
+				s = nullable(s)
+			}
+		}
+		properties.set(name, s)
+		if !omitempty {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// nullable allows a schema's values to also be null
+func nullable(s *schemaObject) *schemaObject {
+	if t, ok := s.values["type"].(string); ok {
+		s.set("type", []string{t, "null"})
+		return s
+	}
+	null := &schemaObject{}
+	null.set("type", "null")
+	out := &schemaObject{}
+	out.set("anyOf", []any{s, null})
+	return out
+}
+
+// jsonSchemaValidator validates decoded JSON against a decoded schema. It
+// supports the keywords that WeatherDataJSONSchema generates: $ref to
+// $defs, anyOf, type, properties, required, additionalProperties and items.
+// Other keywords are ignored.
+type jsonSchemaValidator struct {
+	root map[string]any
+}
+
+// validate returns the problems of instance at path against schema
+func (v jsonSchemaValidator) validate(schema any, instance any, path string) []OutputProblem {
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			return []OutputProblem{{Path: path, Message: "value is not allowed"}}
+		}
+		return nil
+	case map[string]any:
+		return v.validateObject(s, instance, path)
+	}
+	return []OutputProblem{{Path: path, Message: "invalid schema"}}
+}
+
+func (v jsonSchemaValidator) validateObject(s map[string]any, instance any, path string) []OutputProblem {
+	if ref, ok := s["$ref"].(string); ok {
+		target, err := v.resolve(ref)
+		if err != nil {
+			return []OutputProblem{{Path: path, Message: err.Error()}}
+		}
+		if problems := v.validate(target, instance, path); len(problems) > 0 {
+			return problems
+		}
+	}
+
+	if anyOf, ok := s["anyOf"].([]any); ok && len(anyOf) > 0 {
+		// Report the branch that the value came closest to matching,
+		// preferring branches of the value's type
+		var closest []OutputProblem
+		for i, branch := range anyOf {
+			problems := v.validate(branch, instance, path)
+			if len(problems) == 0 {
+				closest = nil
+				break
+			}
+			if i == 0 || closer(problems, closest, path) {
+				closest = problems
+			}
+		}
+		if len(closest) > 0 {
+			return closest
+		}
+	}
+
+	if types := schemaTypes(s["type"]); len(types) > 0 {
+		actual := jsonType(instance)
+		matched := false
+		for _, t := range types {
+			matched = matched || t == actual || (t == "number" && actual == "integer")
+		}
+		if !matched {
+			return []OutputProblem{{Path: path, Message: fmt.Sprintf("expected %s, got %s", strings.Join(types, " or "), actual)}}
+		}
+	}
+
+	var problems []OutputProblem
+	switch value := instance.(type) {
+	case map[string]any:
+		properties, _ := s["properties"].(map[string]any)
+		if required, ok := s["required"].([]any); ok {
+			for _, r := range required {
+				if name, ok := r.(string); ok {
+					if _, present := value[name]; !present {
+						problems = append(problems, OutputProblem{Path: path, Message: "missing required property " + name})
+					}
+				}
+			}
+		}
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			child := path + "/" + jsonPointerEscape(name)
+			if property, ok := properties[name]; ok {
+				problems = append(problems, v.validate(property, value[name], child)...)
+			} else if additional, ok := s["additionalProperties"]; ok {
+				if allowed, isBool := additional.(bool); isBool && !allowed {
+					problems = append(problems, OutputProblem{Path: child, Message: "unexpected property " + name})
+				} else {
+					problems = append(problems, v.validate(additional, value[name], child)...)
+				}
+			}
+		}
+	case []any:
+		if items, ok := s["items"]; ok {
+			for i, item := range value {
+				problems = append(problems, v.validate(items, item, path+"/"+strconv.Itoa(i))...)
+			}
+		}
+	}
+	return problems
+}
+
+// closer reports whether the problems of one anyOf branch are closer to a
+// match than those of another. Failing on the value's type is furthest.
+func closer(problems, than []OutputProblem, path string) bool {
+	wrongType := func(p []OutputProblem) bool {
+		return len(p) == 1 && p[0].Path == path && strings.HasPrefix(p[0].Message, "expected ")
+	}
+	if wrongType(problems) != wrongType(than) {
+		return wrongType(than)
+	}
+	return len(problems) < len(than)
+}
+
+// resolve returns the schema that a local reference such as
+// "#/$defs/Metadata" points to
+func (v jsonSchemaValidator) resolve(ref string) (any, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported schema reference %s", ref)
+	}
+	var node any = v.root
+	for _, part := range strings.Split(ref[2:], "/") {
+		object, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unresolved schema reference %s", ref)
+		}
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		if node, ok = object[part]; !ok {
+			return nil, fmt.Errorf("unresolved schema reference %s", ref)
+		}
+	}
+	return node, nil
+}
+
+// schemaTypes returns the types named by a type keyword
+func schemaTypes(value any) []string {
+	switch t := value.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		var types []string
+		for _, name := range t {
+			if s, ok := name.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// jsonType returns the JSON Schema type of a value decoded with UseNumber
+func jsonType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// jsonPointerEscape escapes a key for use in a JSON Pointer
+func jsonPointerEscape(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
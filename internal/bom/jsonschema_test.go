@@ -0,0 +1,162 @@
+package bom
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// decodeSchema decodes a generated schema as the validator reads it
+func decodeSchema(t *testing.T, version SchemaVersion) map[string]any {
+	t.Helper()
+	var schema map[string]any
+	if err := json.Unmarshal(WeatherDataJSONSchema(version), &schema); err != nil {
+		t.Fatalf("Schema is not valid JSON: %v", err)
+	}
+	return schema
+}
+
+func schemaDef(t *testing.T, schema map[string]any, name string) map[string]any {
+	t.Helper()
+	def, ok := schema["$defs"].(map[string]any)[name].(map[string]any)
+	if !ok {
+		t.Fatalf("Expected a definition of %s", name)
+	}
+	return def
+}
+
+func schemaRequired(def map[string]any) []string {
+	var names []string
+	for _, name := range def["required"].([]any) {
+		names = append(names, name.(string))
+	}
+	return names
+}
+
+func TestWeatherDataJSONSchema(t *testing.T) {
+	schema := decodeSchema(t, SchemaV1)
+	if schema["$schema"] != JSONSchemaDialect || schema["title"] != "BOM weather data, schema v1" {
+		t.Errorf("Unexpected schema header %v %v", schema["$schema"], schema["title"])
+	}
+	properties := schema["properties"].(map[string]any)
+	if _, ok := properties["Station"]; ok {
+		t.Error("Expected Station, which is not part of the JSON, to be left out")
+	}
+
+	year := schemaDef(t, schema, "WeatherDataForYear")
+	required := schemaRequired(year)
+	want := []string{"Year", "FirstRecordedDate", "LastRecordedDate", "ExpectedDays", "RecordedDays", "PercentComplete", "MonthlyAggregates"}
+	if !slices.Equal(required, want) {
+		t.Errorf("Expected required %v, got %v", want, required)
+	}
+	if year["additionalProperties"] != false {
+		t.Error("Expected unknown properties to be rejected")
+	}
+
+	// Properties are listed in output order
+	raw := string(WeatherDataJSONSchema(SchemaV1))
+	if strings.Index(raw, `"FirstRecordedDate"`) > strings.Index(raw, `"TotalRainfall"`) {
+		t.Error("Expected properties in the order of the output")
+	}
+
+	day := schemaDef(t, schema, "DailyValue")
+	rainfall := day["properties"].(map[string]any)["Rainfall"].(map[string]any)
+	if types := rainfall["type"].([]any); len(types) != 2 || types[0] != "string" || types[1] != "null" {
+		t.Errorf("Expected a nullable string rainfall, got %v", rainfall)
+	}
+}
+
+func TestWeatherDataJSONSchema_V2(t *testing.T) {
+	schema := decodeSchema(t, SchemaV2)
+	if schema["title"] != "BOM weather data, schema v2" {
+		t.Errorf("Unexpected title %v", schema["title"])
+	}
+	if !slices.Contains(schemaRequired(schema), "schemaVersion") {
+		t.Error("Expected schemaVersion to be required")
+	}
+
+	year := schemaDef(t, schema, "WeatherDataForYearV2")
+	properties := year["properties"].(map[string]any)
+	for name, want := range map[string]string{
+		"Year":             `"integer"`,
+		"TotalRainfall":    `["number","null"]`,
+		"DaysWithRainfall": `["integer","null"]`,
+		"ImputedDays":      `"integer"`,
+		"Unreliable":       `"boolean"`,
+	} {
+		got, _ := json.Marshal(properties[name].(map[string]any)["type"])
+		if string(got) != want {
+			t.Errorf("%s: expected type %s, got %s", name, want, got)
+		}
+	}
+	// Statistics that were not computed are omitted
+	if slices.Contains(schemaRequired(year), "TotalRainfall") {
+		t.Error("Expected TotalRainfall to be optional")
+	}
+	additional, _ := json.Marshal(properties["AdditionalStatistics"].(map[string]any)["additionalProperties"].(map[string]any)["type"])
+	if string(additional) != `["number","string","null"]` {
+		t.Errorf("Expected additional statistics of numbers, strings or null, got %s", additional)
+	}
+	median := schemaDef(t, schema, "MedianMetadataV2")["properties"].(map[string]any)
+	if got, _ := json.Marshal(median["RainDayThreshold"].(map[string]any)["type"]); string(got) != `"number"` {
+		t.Errorf("Expected a numeric rain-day threshold, got %s", got)
+	}
+}
+
+func TestJSONSchemaValidator(t *testing.T) {
+	var schema map[string]any
+	err := json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string"},
+			"count": {"type": "integer"},
+			"child": {"anyOf": [{"$ref": "#/$defs/Child"}, {"type": "null"}]},
+			"values": {"type": "array", "items": {"type": "number"}},
+			"extra": {"type": "object", "additionalProperties": {"type": ["string", "null"]}}
+		},
+		"required": ["name"],
+		"additionalProperties": false,
+		"$defs": {
+			"Child": {"type": "object", "properties": {"a/b": {"type": "boolean"}}, "required": ["a/b"], "additionalProperties": false}
+		}
+	}`), &schema)
+	if err != nil {
+		t.Fatalf("Invalid test schema: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		instance string
+		want     []string
+	}{
+		{"valid", `{"name": "x", "count": 3, "child": {"a/b": true}, "values": [1, 2.5], "extra": {"k": null}}`, nil},
+		{"null child", `{"name": "x", "child": null}`, nil},
+		{"missing", `{}`, []string{": missing required property name"}},
+		{"unexpected", `{"name": "x", "other": 1}`, []string{"/other: unexpected property other"}},
+		{"types", `{"name": 1, "count": 1.5, "values": [1, "2"], "extra": {"k": 1}}`, []string{
+			"/count: expected integer, got number",
+			"/extra/k: expected string or null, got integer",
+			"/name: expected string, got integer",
+			"/values/1: expected number, got string",
+		}},
+		// The child's own problem is reported rather than that it is not null
+		{"bad child", `{"name": "x", "child": {"a/b": "yes"}}`, []string{"/child/a~1b: expected boolean, got string"}},
+		{"not an object", `[]`, []string{": expected object, got array"}},
+	}
+	for _, tt := range tests {
+		var instance any
+		decoder := json.NewDecoder(strings.NewReader(tt.instance))
+		decoder.UseNumber()
+		if err := decoder.Decode(&instance); err != nil {
+			t.Fatalf("%s: invalid test instance: %v", tt.name, err)
+		}
+		var got []string
+		for _, p := range (jsonSchemaValidator{root: schema}).validate(schema, instance, "") {
+			got = append(got, p.Path+": "+p.Message)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package bom
+
+import "fmt"
+
+// MedianMode selects which days the median daily rainfall is taken over
+type MedianMode string
+
+const (
+	// MedianAll takes the median over every recorded day, including dry
+	// days, as in BOM's published monthly statistics
+	MedianAll MedianMode = "all"
+	// MedianWet takes the median over days with any rainfall. This is the
+	// original behaviour.
+	MedianWet MedianMode = "wet"
+	// MedianThreshold takes the median over rain days, the days with
+	// rainfall at or above the rain-day threshold
+	MedianThreshold MedianMode = "threshold"
+)
+
+// DefaultRainDayThreshold is BOM's rain-day threshold of 1.0 mm
+const DefaultRainDayThreshold Amount = 100
+
+// ParseMedianMode converts a CLI value into a MedianMode
+func ParseMedianMode(value string) (MedianMode, error) {
+	switch mode := MedianMode(value); mode {
+	case MedianAll, MedianWet, MedianThreshold:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown median mode '%s' (expected all, wet or threshold)", value)
+	}
+}
+
+// MedianOptions configures the MedianDailyRainfall statistic
+type MedianOptions struct {
+	// Mode selects the days included. An empty Mode uses MedianWet.
+	Mode MedianMode
+	// RainDayThreshold is the minimum rainfall of a rain day in
+	// MedianThreshold mode. Zero uses DefaultRainDayThreshold.
+	RainDayThreshold Amount
+}
+
+// IsZero reports whether the options are unset
+func (o MedianOptions) IsZero() bool {
+	return o.Mode == "" && o.RainDayThreshold == 0
+}
+
+// mode returns the configured mode, defaulting to MedianWet
+func (o MedianOptions) mode() MedianMode {
+	if o.Mode == "" {
+		return MedianWet
+	}
+	return o.Mode
+}
+
+// threshold returns the configured rain-day threshold
+func (o MedianOptions) threshold() Amount {
+	if o.RainDayThreshold == 0 {
+		return DefaultRainDayThreshold
+	}
+	return o.RainDayThreshold
+}
+
+// includes reports whether a day counts towards the median. Imputed days
+// never do.
+func (o MedianOptions) includes(rec DailyRecord) bool {
+	if !rec.observed() {
+		return false
+	}
+	switch o.mode() {
+	case MedianAll:
+		return true
+	case MedianThreshold:
+		return rec.Rainfall >= o.threshold()
+	default:
+		return rec.Rainfall > 0
+	}
+}
+
+// Metadata describes the median definition for inclusion in the output. It
+// is given for the default definition too, so that the output always says
+// which days its medians were taken over.
+func (o MedianOptions) Metadata() *MedianMetadata {
+	meta := &MedianMetadata{Mode: string(o.mode())}
+	if o.mode() == MedianThreshold {
+		meta.RainDayThreshold = o.threshold().Format(1)
+	}
+	return meta
+}
+
+// MedianDailyRainfall returns a factory for the MedianDailyRainfall
+// statistic using the given median definition. It applies to both years
+// and months.
+func MedianDailyRainfall(options MedianOptions) StatisticFactory {
+	return func(Period) Statistic {
+		return &medianDailyRainfall{options: options}
+	}
+}
+
+// medianDailyRainfall is the median over the days selected by its options
+type medianDailyRainfall struct {
+	options MedianOptions
+	days    []Amount
+}
+
+func (s *medianDailyRainfall) Name() string { return "MedianDailyRainfall" }
+func (s *medianDailyRainfall) Level() Level { return LevelYear | LevelMonth }
+
+func (s *medianDailyRainfall) Accumulate(rec DailyRecord) {
+	if s.options.includes(rec) {
+		s.days = append(s.days, rec.Rainfall)
+	}
+}
+
+func (s *medianDailyRainfall) Result(p Precision) string {
+	return formatMedian(s.days, p)
+}
@@ -0,0 +1,94 @@
+package bom
+
+import (
+	"testing"
+	"time"
+)
+
+// medianTestRecords has three dry days and wet days of 0.4, 1.0, 2.0 and 6.0 mm
+func medianTestRecords() []DailyRecord {
+	var records []DailyRecord
+	for i, rainfall := range []float64{0, 0.4, 0, 1.0, 2.0, 0, 6.0} {
+		records = append(records, DailyRecord{
+			Date:     time.Date(2020, 3, i+1, 0, 0, 0, 0, time.UTC),
+			Rainfall: mm(rainfall),
+			HasData:  true,
+		})
+	}
+	return records
+}
+
+func TestParseMedianMode(t *testing.T) {
+	for _, mode := range []MedianMode{MedianAll, MedianWet, MedianThreshold} {
+		got, err := ParseMedianMode(string(mode))
+		if err != nil || got != mode {
+			t.Errorf("ParseMedianMode(%q) = %q, %v", mode, got, err)
+		}
+	}
+	if _, err := ParseMedianMode("dry"); err == nil {
+		t.Error("Expected error for unknown median mode")
+	}
+}
+
+func TestMedianDailyRainfall_Modes(t *testing.T) {
+	tests := []struct {
+		options MedianOptions
+		want    string
+	}{
+		// All days: 0, 0, 0, 0.4, 1.0, 2.0, 6.0
+		{MedianOptions{Mode: MedianAll}, "0.4"},
+		// Wet days: 0.4, 1.0, 2.0, 6.0
+		{MedianOptions{}, "1.5"},
+		{MedianOptions{Mode: MedianWet}, "1.5"},
+		// Rain days of at least 1.0 mm: 1.0, 2.0, 6.0
+		{MedianOptions{Mode: MedianThreshold}, "2.0"},
+		// Rain days of at least 2.0 mm: 2.0, 6.0
+		{MedianOptions{Mode: MedianThreshold, RainDayThreshold: 200}, "4.0"},
+		// No rain days at all
+		{MedianOptions{Mode: MedianThreshold, RainDayThreshold: 1000}, "0.0"},
+	}
+
+	for _, tt := range tests {
+		s := MedianDailyRainfall(tt.options)(Period{Level: LevelMonth, Year: 2020, Month: time.March})
+		for _, rec := range medianTestRecords() {
+			s.Accumulate(rec)
+		}
+		if got := s.Result(BOMPrecision); got != tt.want {
+			t.Errorf("Median with %+v = %s, want %s", tt.options, got, tt.want)
+		}
+	}
+}
+
+func TestAggregate_MedianOptions(t *testing.T) {
+	options := DefaultAggregatorOptions()
+	options.Clock = FixedClock{Time: time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC)}
+	options.Median = MedianOptions{Mode: MedianAll}
+	result := NewAggregatorWithOptions(options).Aggregate(medianTestRecords())
+
+	year := result.WeatherDataForYear[0]
+	if year.MedianDailyRainfall != "0.400000000000" {
+		t.Errorf("Expected year median 0.400000000000, got %s", year.MedianDailyRainfall)
+	}
+	march := year.MonthlyAggregates.WeatherDataForMonth[0]
+	if march.MedianDailyRainfall != "0.400000000000" {
+		t.Errorf("Expected March median 0.400000000000, got %s", march.MedianDailyRainfall)
+	}
+}
+
+func TestMedianOptions_Metadata(t *testing.T) {
+	// The default definition is recorded too
+	meta := MedianOptions{}.Metadata()
+	if meta == nil || meta.Mode != "wet" || meta.RainDayThreshold != "" {
+		t.Errorf("Unexpected metadata for default options %+v", meta)
+	}
+
+	meta = MedianOptions{Mode: MedianAll}.Metadata()
+	if meta == nil || meta.Mode != "all" || meta.RainDayThreshold != "" {
+		t.Errorf("Unexpected metadata %+v", meta)
+	}
+
+	meta = MedianOptions{Mode: MedianThreshold}.Metadata()
+	if meta == nil || meta.Mode != "threshold" || meta.RainDayThreshold != "1.0" {
+		t.Errorf("Unexpected metadata %+v", meta)
+	}
+}
@@ -0,0 +1,124 @@
+package bom
+
+import "time"
+
+// MonthlyTotal is the rainfall total of one month of the record
+type MonthlyTotal struct {
+	Year         int
+	Month        time.Month
+	Total        Amount
+	Completeness Completeness
+}
+
+// IsComplete reports whether at least minCompleteness percent of the
+// month's days have a reading. With a minimum of zero any month with at
+// least one reading is complete.
+func (m MonthlyTotal) IsComplete(minCompleteness float64) bool {
+	if m.Completeness.RecordedDays == 0 {
+		return false
+	}
+	return m.Completeness.Percent() >= minCompleteness
+}
+
+// MonthlyTotals returns the total of every month from the first to the last
+// month with a record. Months without any record are included, so that the
+// series is contiguous. Months that have not finished as of today are
+// excluded, since their totals are still accumulating.
+func MonthlyTotals(records []DailyRecord, today time.Time) []MonthlyTotal {
+	today = truncateToDay(today)
+	type monthKey struct {
+		year  int
+		month time.Month
+	}
+	byMonth := make(map[monthKey]*MonthlyTotal)
+	var first, last time.Time
+	for _, rec := range records {
+		if !rec.HasData {
+			continue
+		}
+		date := truncateToDay(rec.Date)
+		start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+		if start.AddDate(0, 1, -1).After(today) {
+			continue
+		}
+		key := monthKey{date.Year(), date.Month()}
+		m, ok := byMonth[key]
+		if !ok {
+			m = &MonthlyTotal{Year: key.year, Month: key.month}
+			byMonth[key] = m
+		}
+		m.Total += rec.Rainfall
+		if rec.observed() {
+			m.Completeness.RecordedDays++
+		}
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+		if start.After(last) {
+			last = start
+		}
+	}
+	if len(byMonth) == 0 {
+		return nil
+	}
+
+	var totals []MonthlyTotal
+	for start := first; !start.After(last); start = start.AddDate(0, 1, 0) {
+		total := MonthlyTotal{Year: start.Year(), Month: start.Month()}
+		if m, ok := byMonth[monthKey{start.Year(), start.Month()}]; ok {
+			total = *m
+		}
+		total.Completeness.ExpectedDays = daysIn(total.Year, total.Month)
+		totals = append(totals, total)
+	}
+	return totals
+}
+
+// MonthlyAccumulation is the rainfall total over consecutive months ending
+// in a month
+type MonthlyAccumulation struct {
+	// Year and Month identify the final month
+	Year  int
+	Month time.Month
+	// Months is the number of months accumulated
+	Months int
+	Total  Amount
+	// Complete is false when any of the months fails the completeness
+	// check or the accumulation would start before the series, in which
+	// case Total is not set
+	Complete bool
+}
+
+// Start returns the first day of the first month accumulated
+func (a MonthlyAccumulation) Start() time.Time {
+	return time.Date(a.Year, a.Month-time.Month(a.Months-1), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// AccumulateMonths returns the total over the given number of months ending
+// in each month of a contiguous monthly series
+func AccumulateMonths(totals []MonthlyTotal, months int, minCompleteness float64) []MonthlyAccumulation {
+	accumulations := make([]MonthlyAccumulation, len(totals))
+	missing := 0
+	var sum Amount
+	for i, m := range totals {
+		if m.IsComplete(minCompleteness) {
+			sum += m.Total
+		} else {
+			missing++
+		}
+		if i >= months {
+			out := totals[i-months]
+			if out.IsComplete(minCompleteness) {
+				sum -= out.Total
+			} else {
+				missing--
+			}
+		}
+		accumulations[i] = MonthlyAccumulation{Year: m.Year, Month: m.Month, Months: months}
+		if i >= months-1 && missing == 0 {
+			accumulations[i].Total = sum
+			accumulations[i].Complete = true
+		}
+	}
+	return accumulations
+}
@@ -0,0 +1,45 @@
+package bom
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMonthlyTotals(t *testing.T) {
+	records := []DailyRecord{
+		{Date: day(2020, time.January, 1), Rainfall: mm(1.5), HasData: true},
+		{Date: day(2020, time.January, 2), Rainfall: mm(2.5), HasData: true},
+		{Date: day(2020, time.January, 3), HasData: false},
+		{Date: day(2020, time.March, 31), Rainfall: mm(7), HasData: true},
+		{Date: day(2020, time.April, 1), Rainfall: mm(9), HasData: true},
+	}
+	totals := MonthlyTotals(records, day(2020, time.April, 15))
+
+	// April has not finished; February has no records but is still included
+	if len(totals) != 3 {
+		t.Fatalf("Expected 3 months, got %+v", totals)
+	}
+	jan, feb, mar := totals[0], totals[1], totals[2]
+	if jan.Month != time.January || jan.Total != 400 || jan.Completeness != (Completeness{ExpectedDays: 31, RecordedDays: 2}) {
+		t.Errorf("Unexpected January total %+v", jan)
+	}
+	if feb.Month != time.February || feb.Total != 0 || feb.Completeness != (Completeness{ExpectedDays: 29, RecordedDays: 0}) {
+		t.Errorf("Unexpected February total %+v", feb)
+	}
+	if mar.Month != time.March || mar.Total != 700 {
+		t.Errorf("Unexpected March total %+v", mar)
+	}
+
+	if feb.IsComplete(0) {
+		t.Error("Expected a month without readings to be incomplete")
+	}
+	if !jan.IsComplete(0) || jan.IsComplete(90) {
+		t.Error("Expected January to be complete only without a minimum")
+	}
+}
+
+func TestMonthlyTotals_Empty(t *testing.T) {
+	if totals := MonthlyTotals(nil, day(2020, time.January, 1)); totals != nil {
+		t.Errorf("Expected no totals, got %+v", totals)
+	}
+}
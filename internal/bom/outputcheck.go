@@ -0,0 +1,223 @@
+package bom
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// OutputProblem is one way a weather data document fails CheckOutput
+type OutputProblem struct {
+	// Path is a JSON Pointer to the value, empty for the whole document
+	Path    string
+	Message string
+}
+
+func (p OutputProblem) String() string {
+	if p.Path == "" {
+		return p.Message
+	}
+	return p.Path + ": " + p.Message
+}
+
+// CheckOutput validates weather data JSON against the JSON Schema of its
+// schema version. An empty version is detected from the document: v2 when
+// it has a schemaVersion, v1 otherwise. A document that conforms is then
+// checked for consistency: the wet and dry days of each year and month
+// must add up to its recorded days, and the totals of a year's months to
+// the year's total. It returns the version checked and the problems found;
+// an error means the input is not a JSON document.
+func CheckOutput(r io.Reader, version SchemaVersion) (SchemaVersion, []OutputProblem, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read output: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var instance any
+	if err := decoder.Decode(&instance); err != nil {
+		return "", nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if decoder.More() {
+		return "", nil, fmt.Errorf("invalid JSON: more than one value")
+	}
+
+	if version == "" {
+		version = SchemaV1
+		if object, ok := instance.(map[string]any); ok {
+			if _, ok := object["schemaVersion"]; ok {
+				version = SchemaV2
+			}
+		}
+	}
+
+	var schema map[string]any
+	if err := json.Unmarshal(WeatherDataJSONSchema(version), &schema); err != nil {
+		return "", nil, fmt.Errorf("failed to read JSON Schema: %w", err)
+	}
+	problems := jsonSchemaValidator{root: schema}.validate(schema, instance, "")
+	if version == SchemaV2 {
+		if n, ok := instance.(map[string]any)["schemaVersion"].(json.Number); ok && n.String() != strconv.Itoa(schemaV2Number) {
+			problems = append(problems, OutputProblem{Path: "/schemaVersion", Message: fmt.Sprintf("expected %d, got %s", schemaV2Number, n)})
+		}
+	}
+	if len(problems) > 0 {
+		return version, problems, nil
+	}
+
+	years, err := outputPeriods(data, version)
+	if err != nil {
+		return version, nil, fmt.Errorf("failed to decode output: %w", err)
+	}
+	for _, y := range years {
+		problems = append(problems, y.checkDays()...)
+		for _, m := range y.months {
+			problems = append(problems, m.checkDays()...)
+		}
+		problems = append(problems, y.checkMonthlyTotals()...)
+	}
+	return version, problems, nil
+}
+
+// outputPeriod holds the values of a year or month that are checked for
+// consistency. Values that are unavailable are empty.
+type outputPeriod struct {
+	path     string
+	total    string
+	wet      string
+	dry      string
+	recorded string
+	months   []outputPeriod
+}
+
+// outputPeriods decodes the years and months of a document that conforms
+// to the schema
+func outputPeriods(data []byte, version SchemaVersion) ([]outputPeriod, error) {
+	var years []outputPeriod
+	if version == SchemaV2 {
+		var doc WeatherDataV2
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		for i, y := range doc.WeatherDataForYear {
+			path := "/WeatherData/" + strconv.Itoa(i)
+			year := outputPeriod{path: path, total: nullNumberString(y.TotalRainfall), wet: nullIntString(y.DaysWithRainfall),
+				dry: nullIntString(y.DaysWithNoRainfall), recorded: strconv.Itoa(y.RecordedDays)}
+			for j, m := range y.MonthlyAggregates {
+				year.months = append(year.months, outputPeriod{path: path + "/MonthlyAggregates/" + strconv.Itoa(j),
+					total: nullNumberString(m.TotalRainfall), wet: nullIntString(m.DaysWithRainfall),
+					dry: nullIntString(m.DaysWithNoRainfall), recorded: strconv.Itoa(m.RecordedDays)})
+			}
+			years = append(years, year)
+		}
+		return years, nil
+	}
+
+	var doc WeatherData
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	for i, y := range doc.WeatherDataForYear {
+		path := "/WeatherData/" + strconv.Itoa(i)
+		year := outputPeriod{path: path, total: y.TotalRainfall, wet: y.DaysWithRainfall,
+			dry: y.DaysWithNoRainfall, recorded: y.RecordedDays}
+		for j, m := range y.MonthlyAggregates.WeatherDataForMonth {
+			year.months = append(year.months, outputPeriod{path: path + "/MonthlyAggregates/WeatherDataForMonth/" + strconv.Itoa(j),
+				total: m.TotalRainfall, wet: m.DaysWithRainfall, dry: m.DaysWithNoRainfall, recorded: m.RecordedDays})
+		}
+		years = append(years, year)
+	}
+	return years, nil
+}
+
+func nullNumberString(n *NullNumber) string {
+	if n == nil || !n.Valid {
+		return ""
+	}
+	return n.Number.String()
+}
+
+func nullIntString(n *NullInt) string {
+	if n == nil || !n.Valid {
+		return ""
+	}
+	return strconv.Itoa(n.Int)
+}
+
+// checkDays checks that the wet and dry days add up to the recorded days
+func (p outputPeriod) checkDays() []OutputProblem {
+	if p.wet == "" || p.dry == "" {
+		return nil
+	}
+	var problems []OutputProblem
+	values := make(map[string]int)
+	for _, field := range []struct{ name, value string }{
+		{"DaysWithRainfall", p.wet},
+		{"DaysWithNoRainfall", p.dry},
+		{"RecordedDays", p.recorded},
+	} {
+		n, err := strconv.Atoi(field.value)
+		if err != nil {
+			problems = append(problems, OutputProblem{Path: p.path, Message: fmt.Sprintf("%s is not a whole number: '%s'", field.name, field.value)})
+		}
+		values[field.name] = n
+	}
+	if len(problems) > 0 {
+		return problems
+	}
+	if wet, dry, recorded := values["DaysWithRainfall"], values["DaysWithNoRainfall"], values["RecordedDays"]; wet+dry != recorded {
+		return []OutputProblem{{Path: p.path, Message: fmt.Sprintf(
+			"DaysWithRainfall (%d) + DaysWithNoRainfall (%d) is %d, but RecordedDays is %d", wet, dry, wet+dry, recorded)}}
+	}
+	return nil
+}
+
+// checkMonthlyTotals checks that the totals of a year's months add up to
+// the year's total, allowing for each value's rounding. It is skipped when
+// any of the totals is unavailable.
+func (p outputPeriod) checkMonthlyTotals() []OutputProblem {
+	if p.total == "" || len(p.months) == 0 {
+		return nil
+	}
+	total, err := strconv.ParseFloat(p.total, 64)
+	if err != nil {
+		return []OutputProblem{{Path: p.path, Message: fmt.Sprintf("TotalRainfall is not a number: '%s'", p.total)}}
+	}
+	places := decimalPlaces(p.total)
+	sum := 0.0
+	for _, m := range p.months {
+		if m.total == "" {
+			return nil
+		}
+		value, err := strconv.ParseFloat(m.total, 64)
+		if err != nil {
+			return []OutputProblem{{Path: m.path, Message: fmt.Sprintf("TotalRainfall is not a number: '%s'", m.total)}}
+		}
+		sum += value
+		places = max(places, decimalPlaces(m.total))
+	}
+
+	// Each value may be off by half a unit in its last place
+	tolerance := float64(len(p.months)+1)*0.5*math.Pow(10, -float64(places)) + 1e-9
+	if math.Abs(sum-total) > tolerance {
+		return []OutputProblem{{Path: p.path, Message: fmt.Sprintf(
+			"monthly totals add up to %s, but TotalRainfall is %s", strconv.FormatFloat(sum, 'f', places, 64), p.total)}}
+	}
+	return nil
+}
+
+// decimalPlaces returns the number of digits after the decimal point
+func decimalPlaces(value string) int {
+	_, fraction, found := strings.Cut(value, ".")
+	if !found {
+		return 0
+	}
+	if i := strings.IndexAny(fraction, "eE"); i >= 0 {
+		fraction = fraction[:i]
+	}
+	return len(fraction)
+}
@@ -0,0 +1,126 @@
+package bom
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// checkOutputDocument encodes the aggregated report records as JSON
+func checkOutputDocument(t *testing.T, schema SchemaVersion) string {
+	t.Helper()
+	data := NewAggregator().Aggregate(reportRecords())
+	var buf bytes.Buffer
+	if err := NewConverterWithOptions(ConverterOptions{Schema: schema}).Encode(&buf, data); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	return buf.String()
+}
+
+func checkOutputProblems(t *testing.T, doc string, version SchemaVersion) (SchemaVersion, []string) {
+	t.Helper()
+	checked, problems, err := CheckOutput(strings.NewReader(doc), version)
+	if err != nil {
+		t.Fatalf("CheckOutput failed: %v", err)
+	}
+	var messages []string
+	for _, p := range problems {
+		messages = append(messages, p.String())
+	}
+	return checked, messages
+}
+
+func TestCheckOutput_Valid(t *testing.T) {
+	for _, schema := range []SchemaVersion{SchemaV1, SchemaV2} {
+		checked, problems := checkOutputProblems(t, checkOutputDocument(t, schema), "")
+		if checked != schema {
+			t.Errorf("Expected %s to be detected, got %s", schema, checked)
+		}
+		if len(problems) > 0 {
+			t.Errorf("%s: expected no problems, got %v", schema, problems)
+		}
+	}
+
+	// v1 output without weather data has a null WeatherData
+	if _, problems := checkOutputProblems(t, `{"WeatherData": null}`, ""); len(problems) > 0 {
+		t.Errorf("Expected null weather data to be valid, got %v", problems)
+	}
+}
+
+func TestCheckOutput_SchemaProblems(t *testing.T) {
+	doc := checkOutputDocument(t, SchemaV1)
+	doc = strings.Replace(doc, `"Year": "2018",`, `"Extra": true,`, 1)
+	doc = strings.Replace(doc, `"ExpectedDays": "365"`, `"ExpectedDays": 365`, 1)
+
+	_, problems := checkOutputProblems(t, doc, "")
+	want := []string{
+		"/WeatherData/0: missing required property Year",
+		"/WeatherData/0/ExpectedDays: expected string, got integer",
+		"/WeatherData/0/Extra: unexpected property Extra",
+	}
+	if !slices.Equal(problems, want) {
+		t.Errorf("Expected %v, got %v", want, problems)
+	}
+
+	// v2 output is checked against the v2 schema
+	if _, problems := checkOutputProblems(t, checkOutputDocument(t, SchemaV2), SchemaV1); len(problems) == 0 {
+		t.Error("Expected v2 output to fail the v1 schema")
+	}
+	v2 := strings.Replace(checkOutputDocument(t, SchemaV2), `"schemaVersion": 2`, `"schemaVersion": 3`, 1)
+	if _, problems := checkOutputProblems(t, v2, ""); !slices.Equal(problems, []string{"/schemaVersion: expected 2, got 3"}) {
+		t.Errorf("Expected a schema version problem, got %v", problems)
+	}
+}
+
+func TestCheckOutput_Consistency(t *testing.T) {
+	doc := checkOutputDocument(t, SchemaV1)
+	doc = strings.Replace(doc, `"DaysWithNoRainfall": "0"`, `"DaysWithNoRainfall": "1"`, 1)
+	doc = strings.Replace(doc, `"TotalRainfall": "730.000000000000"`, `"TotalRainfall": "731.000000000000"`, 1)
+
+	_, problems := checkOutputProblems(t, doc, "")
+	want := []string{
+		"/WeatherData/0: DaysWithRainfall (365) + DaysWithNoRainfall (1) is 366, but RecordedDays is 365",
+		"/WeatherData/1: monthly totals add up to 730.000000000000, but TotalRainfall is 731.000000000000",
+	}
+	if !slices.Equal(problems, want) {
+		t.Errorf("Expected %v, got %v", want, problems)
+	}
+}
+
+func TestOutputPeriod_CheckMonthlyTotals(t *testing.T) {
+	months := func(totals ...string) []outputPeriod {
+		var periods []outputPeriod
+		for _, total := range totals {
+			periods = append(periods, outputPeriod{total: total})
+		}
+		return periods
+	}
+	tests := []struct {
+		name   string
+		period outputPeriod
+		want   int
+	}{
+		// Three months rounded to one place may differ from the rounded
+		// year by up to two units
+		{"rounded", outputPeriod{total: "1.0", months: months("0.3", "0.3", "0.3")}, 0},
+		{"within tolerance", outputPeriod{total: "1.1", months: months("0.3", "0.3", "0.3")}, 0},
+		{"beyond tolerance", outputPeriod{total: "1.2", months: months("0.3", "0.3", "0.3")}, 1},
+		{"unavailable month", outputPeriod{total: "5", months: months("1", "")}, 0},
+		{"unavailable year", outputPeriod{months: months("1")}, 0},
+		{"not a number", outputPeriod{total: "x", months: months("1")}, 1},
+	}
+	for _, tt := range tests {
+		if got := tt.period.checkMonthlyTotals(); len(got) != tt.want {
+			t.Errorf("%s: expected %d problems, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestCheckOutput_InvalidJSON(t *testing.T) {
+	for _, doc := range []string{"", "{", `{} {}`} {
+		if _, _, err := CheckOutput(strings.NewReader(doc), ""); err == nil {
+			t.Errorf("Expected an error for %q", doc)
+		}
+	}
+}
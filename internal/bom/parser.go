@@ -170,26 +170,40 @@ func (p *Parser) parseRow(row []string) (DailyRecord, error) {
 		Date:     date,
 		Rainfall: rainfall,
 		HasData:  hasData,
+		Station:  strings.TrimSpace(row[1]),
+		Period:   p.parsePeriod(row[6]),
+		Quality:  strings.TrimSpace(row[7]),
 	}, nil
 }
 
+// parsePeriod parses the number of days a reading was accumulated over.
+// A blank or non-numeric period is recorded as zero rather than rejecting
+// the row, since the period does not affect any statistic.
+func (p *Parser) parsePeriod(value string) int {
+	period, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || period < 0 {
+		return 0
+	}
+	return period
+}
+
 // parseRainfall parses rainfall value, handling missing data indicators
-func (p *Parser) parseRainfall(value string) (float64, bool, error) {
+func (p *Parser) parseRainfall(value string) (Amount, bool, error) {
 	value = strings.TrimSpace(value)
 
 	// Handle missing data indicators (matching original logic)
 	if value == "" || value == "NA" || value == "N/A" || value == "-" {
-		return 0.0, false, nil
+		return 0, false, nil
 	}
 
-	// Parse numeric value
-	rainfall, err := strconv.ParseFloat(value, 64)
+	// Parse numeric value exactly, to hundredths of a millimetre
+	amount, err := ParseAmount(value)
 	if err != nil {
-		return 0.0, false, fmt.Errorf("cannot parse as float: %w", err)
+		return 0, false, fmt.Errorf("cannot parse as number: %w", err)
 	}
 
 	// Remove range validation to match original behavior
 	// Original silently accepted any numeric value
 
-	return rainfall, true, nil
+	return amount, true, nil
 }
@@ -47,16 +47,19 @@ IDCJAC0009,066062,2020,2,1,15.7,1,Y`
 	if !records[0].Date.Equal(expectedDate) {
 		t.Errorf("Expected date %v, got %v", expectedDate, records[0].Date)
 	}
-	if records[0].Rainfall != 10.5 {
-		t.Errorf("Expected rainfall 10.5, got %f", records[0].Rainfall)
+	if records[0].Rainfall != mm(10.5) {
+		t.Errorf("Expected rainfall 10.5, got %s", records[0].Rainfall.Format(2))
 	}
 	if !records[0].HasData {
 		t.Error("Expected HasData to be true")
 	}
+	if records[0].Period != 1 || records[0].Quality != "Y" {
+		t.Errorf("Expected period 1 and quality Y, got %d and %q", records[0].Period, records[0].Quality)
+	}
 
 	// Check record with zero rainfall
 	if records[1].Rainfall != 0.0 {
-		t.Errorf("Expected rainfall 0.0, got %f", records[1].Rainfall)
+		t.Errorf("Expected rainfall 0.0, got %s", records[1].Rainfall.Format(2))
 	}
 	if !records[1].HasData {
 		t.Error("Expected HasData to be true for zero rainfall")
@@ -90,7 +93,7 @@ IDCJAC0009,066062,2020,1,6,0.0,1,Y`
 			t.Errorf("Record %d: Expected HasData to be false for missing data", i)
 		}
 		if records[i].Rainfall != 0.0 {
-			t.Errorf("Record %d: Expected rainfall 0.0, got %f", i, records[i].Rainfall)
+			t.Errorf("Record %d: Expected rainfall 0.0, got %s", i, records[i].Rainfall.Format(2))
 		}
 	}
 
@@ -244,21 +247,21 @@ func TestParseRainfall(t *testing.T) {
 
 	testCases := []struct {
 		input     string
-		expected  float64
+		expected  Amount
 		hasData   bool
 		expectErr bool
 	}{
-		{"10.5", 10.5, true, false},
-		{"0.0", 0.0, true, false},
-		{"", 0.0, false, false},
-		{"NA", 0.0, false, false},
-		{"N/A", 0.0, false, false},
-		{"-", 0.0, false, false},
-		{"abc", 0.0, false, true},
-		{"-10.5", -10.5, true, false},     // Original accepts negative values
-		{"20000.0", 20000.0, true, false}, // Original accepts any numeric value
-		{"  10.5  ", 10.5, true, false},
-		{"  NA  ", 0.0, false, false},
+		{"10.5", 1050, true, false},
+		{"0.0", 0, true, false},
+		{"", 0, false, false},
+		{"NA", 0, false, false},
+		{"N/A", 0, false, false},
+		{"-", 0, false, false},
+		{"abc", 0, false, true},
+		{"-10.5", -1050, true, false},     // Original accepts negative values
+		{"20000.0", 2000000, true, false}, // Original accepts any numeric value
+		{"  10.5  ", 1050, true, false},
+		{"  NA  ", 0, false, false},
 	}
 
 	for _, tc := range testCases {
@@ -273,7 +276,7 @@ func TestParseRainfall(t *testing.T) {
 			}
 
 			if rainfall != tc.expected {
-				t.Errorf("Expected rainfall %f, got %f", tc.expected, rainfall)
+				t.Errorf("Expected rainfall %d, got %d", tc.expected, rainfall)
 			}
 
 			if hasData != tc.hasData {
@@ -307,3 +310,30 @@ func TestIsEmptyRow(t *testing.T) {
 		})
 	}
 }
+
+func TestParseCSV_PeriodAndQuality(t *testing.T) {
+	csvData := `Product code,Bureau of Meteorology station number,Year,Month,Day,Rainfall amount (millimetres),Period over which rainfall was measured (days),Quality
+IDCJAC0009,066062,2020,1,1,,,
+IDCJAC0009,066062,2020,1,2,12.0,2,N
+IDCJAC0009,066062,2020,1,3,1.0,x,Y`
+
+	records, err := NewParser(false).ParseCSV(strings.NewReader(csvData))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 records, got %d", len(records))
+	}
+	want := []struct {
+		period  int
+		quality string
+	}{{0, ""}, {2, "N"}, {0, "Y"}}
+	for i, w := range want {
+		if records[i].Period != w.period || records[i].Quality != w.quality {
+			t.Errorf("Record %d: expected period %d and quality %q, got %d and %q", i, w.period, w.quality, records[i].Period, records[i].Quality)
+		}
+		if records[i].Station != "066062" {
+			t.Errorf("Record %d: expected station 066062, got %q", i, records[i].Station)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package bom
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"slices"
 )
 
 // Processor orchestrates the parsing, aggregation, and conversion of weather data
@@ -11,23 +14,68 @@ type Processor struct {
 	parser     *Parser
 	aggregator *Aggregator
 	converter  *Converter
+	filter     Filter
+	median     MedianOptions
+	imputation ImputationOptions
+	input      string
+}
+
+// Input formats of the weather data read by ProcessReport and
+// ProcessComparison
+const (
+	InputCSV  = "csv"
+	InputJSON = "json"
+)
+
+// ProcessorOptions configures the components created by a Processor
+type ProcessorOptions struct {
+	Verbose    bool
+	Aggregator AggregatorOptions
+	// Filter selects the records passed from the parser to the aggregator
+	Filter Filter
+	// Imputation fills missing days before the filter is applied
+	Imputation ImputationOptions
+	// Schema selects the layout of weather data JSON
+	Schema SchemaVersion
+	// Format names the output format of the weather data, FormatJSON when
+	// empty
+	Format string
+	// Width is the line width of FormatTable output, DefaultTableWidth when
+	// zero
+	Width int
+	// InputFormat names the format read by ProcessReport and
+	// ProcessComparison: InputCSV, the default when empty, or InputJSON for
+	// v1 weather data JSON as written by convert
+	InputFormat string
 }
 
 // NewProcessor creates a new Processor with all required components
 func NewProcessor() *Processor {
-	return &Processor{
-		parser:     NewParser(false), // Default to non-verbose
-		aggregator: NewAggregator(),
-		converter:  NewConverter(),
-	}
+	return NewProcessorWithVerbose(false) // Default to non-verbose
 }
 
 // NewProcessorWithVerbose creates a new Processor with verbose parsing
 func NewProcessorWithVerbose(verbose bool) *Processor {
+	return NewProcessorWithOptions(ProcessorOptions{
+		Verbose:    verbose,
+		Aggregator: DefaultAggregatorOptions(),
+	})
+}
+
+// NewProcessorWithOptions creates a new Processor configured by options
+func NewProcessorWithOptions(options ProcessorOptions) *Processor {
+	aggregatorOptions := options.Aggregator
+	if !options.Filter.IsZero() {
+		aggregatorOptions.DayFilter = options.Filter.Predicate()
+	}
 	return &Processor{
-		parser:     NewParser(verbose),
-		aggregator: NewAggregator(),
-		converter:  NewConverter(),
+		parser:     NewParser(options.Verbose),
+		aggregator: NewAggregatorWithOptions(aggregatorOptions),
+		converter:  NewConverterWithOptions(ConverterOptions{Schema: options.Schema, Format: options.Format, Width: options.Width}),
+		filter:     options.Filter,
+		median:     options.Aggregator.Median,
+		imputation: options.Imputation,
+		input:      options.InputFormat,
 	}
 }
 
@@ -39,24 +87,46 @@ func (p *Processor) ProcessWeatherData(input io.Reader, output io.Writer) error
 		return fmt.Errorf("failed to parse CSV: %w", err)
 	}
 
-	// Aggregate the records
-	weatherData := p.aggregator.Aggregate(records)
-
-	// Convert to JSON
-	jsonData, err := p.converter.ToJSON(weatherData)
+	// Fill missing days over the whole record, so that climatology and the
+	// neighbour scale do not depend on the filter
+	records, imputation, err := Impute(records, p.imputation)
 	if err != nil {
-		return fmt.Errorf("failed to convert weather data to JSON: %w", err)
+		return fmt.Errorf("failed to impute missing days: %w", err)
 	}
 
-	// Write to output
-	_, err = output.Write(jsonData)
-	if err != nil {
+	// Apply the date filter
+	if !p.filter.IsZero() {
+		records = FilterRecords(records, p.filter.Predicate())
+	}
+
+	// Aggregate the records a year at a time, encoding each year as it is
+	// finished where the output format allows
+	weatherData := WeatherData{Metadata: p.metadata(imputation), Station: recordStation(records)}
+	years := func(yield func(WeatherDataForYear) error) ([]CalendarMonthVariability, error) {
+		data, err := p.aggregator.AggregateYears(records, yield)
+		return data.InterannualVariability, err
+	}
+	if err := p.converter.EncodeStream(output, weatherData, years); err != nil {
 		return fmt.Errorf("failed to write output: %w", err)
 	}
 
 	return nil
 }
 
+// metadata describes the processing options behind the output. The median
+// definition is always given; the other options only when they were used.
+func (p *Processor) metadata(imputation ImputationSummary) *Metadata {
+	meta := &Metadata{
+		Filter:     p.filter.Metadata(),
+		Median:     p.median.Metadata(),
+		Imputation: imputation.Metadata(p.imputation),
+	}
+	if names := p.aggregator.statistics.Names(); !slices.Equal(names, DefaultStatistics().Names()) {
+		meta.Statistics = names
+	}
+	return meta
+}
+
 // ValidateCSVFile validates a CSV file by attempting to parse it
 // Returns error if the file is invalid, nil if valid
 func (p *Processor) ValidateCSVFile(inputPath string) error {
@@ -94,3 +164,346 @@ func (p *Processor) ProcessWeatherDataFile(inputPath, outputPath string) error {
 
 	return p.ProcessWeatherData(file, outFile)
 }
+
+// ProcessRollingTotals reads weather data from a CSV reader and writes the
+// rolling totals for each window as a daily CSV time series
+func (p *Processor) ProcessRollingTotals(input io.Reader, output io.Writer, windows []int, maxMissing int) error {
+	records, err := p.parser.ParseCSV(input)
+	if err != nil {
+		return fmt.Errorf("failed to parse CSV: %w", err)
+	}
+
+	series := NewDailySeries(records)
+	var totals [][]RollingTotal
+	for _, window := range windows {
+		if window < 1 {
+			return fmt.Errorf("invalid window %d: must be at least 1 day", window)
+		}
+		totals = append(totals, RollingTotals(series, window, maxMissing))
+	}
+
+	if err := p.converter.WriteRollingCSV(output, totals); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// ProcessExtremes reads weather data from a CSV reader and writes the
+// Rx1day, Rx3day, Rx5day and Rx7day indices for each year as JSON
+func (p *Processor) ProcessExtremes(input io.Reader, output io.Writer, maxMissing int) error {
+	records, err := p.parser.ParseCSV(input)
+	if err != nil {
+		return fmt.Errorf("failed to parse CSV: %w", err)
+	}
+
+	indices := AnnualExtremeIndices(NewDailySeries(records), maxMissing)
+	jsonData, err := p.converter.ExtremesToJSON(NewExtremesData(indices))
+	if err != nil {
+		return fmt.Errorf("failed to convert extremes to JSON: %w", err)
+	}
+
+	if _, err := output.Write(jsonData); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// ProcessReturnPeriods reads weather data from a CSV reader, fits extreme
+// value distributions to the annual maximum series and writes the estimated
+// return period depths as JSON
+func (p *Processor) ProcessReturnPeriods(input io.Reader, output io.Writer, opts ReturnPeriodOptions) error {
+	records, err := p.parser.ParseCSV(input)
+	if err != nil {
+		return fmt.Errorf("failed to parse CSV: %w", err)
+	}
+
+	analyses, err := AnalyseReturnPeriods(records, opts)
+	if err != nil {
+		return fmt.Errorf("failed to analyse return periods: %w", err)
+	}
+
+	jsonData, err := p.converter.ReturnPeriodsToJSON(NewReturnPeriodData(analyses, opts))
+	if err != nil {
+		return fmt.Errorf("failed to convert return periods to JSON: %w", err)
+	}
+
+	if _, err := output.Write(jsonData); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// ProcessSPI reads weather data from a CSV reader, computes the Standardised
+// Precipitation Index and writes the monthly SPI series as JSON
+func (p *Processor) ProcessSPI(input io.Reader, output io.Writer, opts SPIOptions) error {
+	records, err := p.parser.ParseCSV(input)
+	if err != nil {
+		return fmt.Errorf("failed to parse CSV: %w", err)
+	}
+
+	analysis, err := AnalyseSPI(records, opts)
+	if err != nil {
+		return fmt.Errorf("failed to compute SPI: %w", err)
+	}
+
+	jsonData, err := p.converter.SPIToJSON(NewSPIData(analysis, opts))
+	if err != nil {
+		return fmt.Errorf("failed to convert SPI to JSON: %w", err)
+	}
+
+	if _, err := output.Write(jsonData); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// ProcessDeficiencies reads weather data from a CSV reader, detects serious
+// and severe rainfall deficiencies and writes them as JSON
+func (p *Processor) ProcessDeficiencies(input io.Reader, output io.Writer, opts DeficiencyOptions) error {
+	records, err := p.parser.ParseCSV(input)
+	if err != nil {
+		return fmt.Errorf("failed to parse CSV: %w", err)
+	}
+
+	periods, err := DetectDeficiencies(records, opts)
+	if err != nil {
+		return fmt.Errorf("failed to detect deficiencies: %w", err)
+	}
+
+	jsonData, err := p.converter.DeficienciesToJSON(NewDeficiencyData(periods, opts))
+	if err != nil {
+		return fmt.Errorf("failed to convert deficiencies to JSON: %w", err)
+	}
+
+	if _, err := output.Write(jsonData); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// ProcessTrends reads weather data from a CSV reader, aggregates it into
+// yearly series and writes the results of the trend tests as JSON
+func (p *Processor) ProcessTrends(input io.Reader, output io.Writer, opts TrendOptions) error {
+	records, err := p.parser.ParseCSV(input)
+	if err != nil {
+		return fmt.Errorf("failed to parse CSV: %w", err)
+	}
+
+	// Completeness is judged against whole years, so that years cut by the
+	// date range are excluded rather than compared as partial totals
+	records = FilterRecords(records, DateRange(opts.From, opts.To))
+	weatherData := NewAggregatorWithOptions(TrendAggregatorOptions(opts)).Aggregate(records)
+
+	series, err := AnalyseTrends(weatherData, opts)
+	if err != nil {
+		return fmt.Errorf("failed to analyse trends: %w", err)
+	}
+
+	jsonData, err := p.converter.TrendsToJSON(NewTrendData(series, opts))
+	if err != nil {
+		return fmt.Errorf("failed to convert trends to JSON: %w", err)
+	}
+
+	if _, err := output.Write(jsonData); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// ProcessComparison parses the CSV data of two stations and writes how
+// station B compares with station A over the days both recorded
+func (p *Processor) ProcessComparison(inputA, inputB io.Reader, output io.Writer, nameA, nameB string) error {
+	recordsA, err := p.readRecords(inputA)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", nameA, err)
+	}
+	recordsB, err := p.readRecords(inputB)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", nameB, err)
+	}
+
+	comparison, err := CompareStations(recordsA, recordsB)
+	if err != nil {
+		return fmt.Errorf("failed to compare stations: %w", err)
+	}
+
+	jsonData, err := p.converter.ComparisonToJSON(NewComparisonData(comparison, nameA, nameB))
+	if err != nil {
+		return fmt.Errorf("failed to convert comparison to JSON: %w", err)
+	}
+
+	if _, err := output.Write(jsonData); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// ProcessReport reads weather data in the Processor's input format and
+// writes a self-contained HTML report with inline SVG charts. JSON input is
+// reported as it was aggregated, using BuildReportFromWeatherData.
+func (p *Processor) ProcessReport(input io.Reader, output io.Writer, opts ReportOptions) error {
+	var report Report
+	switch p.inputFormat() {
+	case InputCSV:
+		records, err := p.parser.ParseCSV(input)
+		if err != nil {
+			return fmt.Errorf("failed to parse CSV: %w", err)
+		}
+		report, err = BuildReport(records, opts)
+		if err != nil {
+			return fmt.Errorf("failed to build report: %w", err)
+		}
+	case InputJSON:
+		data, err := DecodeWeatherData(input)
+		if err != nil {
+			return err
+		}
+		report, err = BuildReportFromWeatherData(data, opts)
+		if err != nil {
+			return fmt.Errorf("failed to build report: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown input format '%s'", p.input)
+	}
+
+	if err := WriteReportHTML(output, report, opts.Chart); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// inputFormat returns the format of the weather data read by the Processor
+func (p *Processor) inputFormat() string {
+	if p.input == "" {
+		return InputCSV
+	}
+	return p.input
+}
+
+// readRecords reads daily records in the Processor's input format. JSON
+// input must include its daily records.
+func (p *Processor) readRecords(input io.Reader) ([]DailyRecord, error) {
+	switch p.inputFormat() {
+	case InputCSV:
+		records, err := p.parser.ParseCSV(input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CSV: %w", err)
+		}
+		return records, nil
+	case InputJSON:
+		data, err := DecodeWeatherData(input)
+		if err != nil {
+			return nil, err
+		}
+		records, err := RecordsFromDailyValues(data)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, fmt.Errorf("weather data has no daily records: convert it with daily records included")
+		}
+		return records, nil
+	default:
+		return nil, fmt.Errorf("unknown input format '%s'", p.input)
+	}
+}
+
+// ProcessChart reads CSV data and writes the chart selected by opts as SVG
+func (p *Processor) ProcessChart(input io.Reader, output io.Writer, opts ChartOptions) error {
+	records, err := p.parser.ParseCSV(input)
+	if err != nil {
+		return fmt.Errorf("failed to parse CSV: %w", err)
+	}
+
+	chart, err := NewChart(records, opts)
+	if err != nil {
+		return fmt.Errorf("failed to build chart: %w", err)
+	}
+
+	if err := chart.WriteSVG(output, opts.Style); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// LoadStateFile reads a saved Accumulator. A state file that does not exist
+// yet yields an empty Accumulator.
+func (p *Processor) LoadStateFile(statePath string) (*Accumulator, error) {
+	file, err := os.Open(statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewAccumulator(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state file %s: %w", statePath, err)
+	}
+	defer file.Close()
+
+	acc, err := LoadAccumulator(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load state file %s: %w", statePath, err)
+	}
+	return acc, nil
+}
+
+// SaveStateFile writes an Accumulator to a state file. The state is written
+// to a temporary file first so an interrupted save never corrupts it.
+func (p *Processor) SaveStateFile(statePath string, acc *Accumulator) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(statePath), filepath.Base(statePath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary state file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := tmpFile.Chmod(0o644); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to set state file permissions: %w", err)
+	}
+	if err := acc.Save(tmpFile); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	if err := os.Rename(tmpFile.Name(), statePath); err != nil {
+		return fmt.Errorf("failed to replace state file %s: %w", statePath, err)
+	}
+	return nil
+}
+
+// ProcessUpdate applies the rows of a CSV reader to the saved state, saves
+// it and, when output is not nil, writes the JSON for the updated state.
+// Only the months and years that the rows change are aggregated again; the
+// aggregates of the others are kept in the state, which is refreshed
+// whether or not output is written. It returns the number of
+// records applied.
+func (p *Processor) ProcessUpdate(statePath string, input io.Reader, output io.Writer) (int, error) {
+	acc, err := p.LoadStateFile(statePath)
+	if err != nil {
+		return 0, err
+	}
+
+	records, err := p.parser.ParseCSV(input)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	for _, rec := range records {
+		acc.Add(rec)
+	}
+
+	// Aggregate before saving, even without output, so that the state
+	// keeps the aggregates of finished periods for the next update
+	weatherData := p.aggregator.AggregateAccumulator(acc)
+	weatherData.Metadata = p.metadata(ImputationSummary{})
+
+	if err := p.SaveStateFile(statePath, acc); err != nil {
+		return 0, err
+	}
+
+	if output != nil {
+		if err := p.converter.Encode(output, weatherData); err != nil {
+			return 0, fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+	return len(records), nil
+}
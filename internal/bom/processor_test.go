@@ -1,9 +1,12 @@
 package bom
 
 import (
+	"encoding/json"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestProcessorValidCSV(t *testing.T) {
@@ -130,3 +133,223 @@ func TestProcessorEmptyCSV(t *testing.T) {
 		t.Errorf("Expected JSON output to contain 'WeatherData', got: %s", outputStr)
 	}
 }
+
+func TestProcessorRollingTotals(t *testing.T) {
+	csvContent := `Product code,Bureau of Meteorology station number,Year,Month,Day,Rainfall amount (millimetres),Period over which rainfall was measured (days),Quality
+IDCJAC0009,066062,2020,1,1,5.2,1,Y
+IDCJAC0009,066062,2020,1,2,0.0,1,Y
+IDCJAC0009,066062,2020,1,3,1.8,1,Y`
+
+	var out strings.Builder
+	processor := NewProcessor()
+	if err := processor.ProcessRollingTotals(strings.NewReader(csvContent), &out, []int{1, 2}, 0); err != nil {
+		t.Fatalf("Rolling totals failed: %v", err)
+	}
+
+	expected := "Date,Rainfall1Day,Rainfall2Day\n2020-01-01,5.2,\n2020-01-02,0.0,5.2\n2020-01-03,1.8,1.8\n"
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+
+	if err := processor.ProcessRollingTotals(strings.NewReader(csvContent), &out, []int{0}, 0); err == nil {
+		t.Error("Expected error for zero-length window")
+	}
+}
+
+func TestProcessorExtremes(t *testing.T) {
+	csvContent := `Product code,Bureau of Meteorology station number,Year,Month,Day,Rainfall amount (millimetres),Period over which rainfall was measured (days),Quality
+IDCJAC0009,066062,2020,1,1,5.2,1,Y
+IDCJAC0009,066062,2020,1,2,0.0,1,Y
+IDCJAC0009,066062,2020,1,3,1.8,1,Y`
+
+	var out strings.Builder
+	processor := NewProcessor()
+	if err := processor.ProcessExtremes(strings.NewReader(csvContent), &out, 0); err != nil {
+		t.Fatalf("Extremes failed: %v", err)
+	}
+
+	outputStr := out.String()
+	for _, expected := range []string{`"Extremes"`, `"Rx1day"`, `"TotalRainfall": "7.0"`, `"Rx5day": null`, `"Rx7day": null`} {
+		if !strings.Contains(outputStr, expected) {
+			t.Errorf("Expected output to contain %s, got: %s", expected, outputStr)
+		}
+	}
+}
+
+func TestProcessorJSONInput(t *testing.T) {
+	options := DefaultAggregatorOptions()
+	options.IncludeDaily = true
+	daily := decodeTestDocument(t, options)
+	plain := decodeTestDocument(t, DefaultAggregatorOptions())
+	processor := NewProcessorWithOptions(ProcessorOptions{Aggregator: DefaultAggregatorOptions(), InputFormat: InputJSON})
+
+	var report strings.Builder
+	if err := processor.ProcessReport(strings.NewReader(string(daily)), &report, DefaultReportOptions()); err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+	for _, expected := range []string{"Wettest days", "2020-01-15"} {
+		if !strings.Contains(report.String(), expected) {
+			t.Errorf("Expected the report to contain %s", expected)
+		}
+	}
+
+	var comparison strings.Builder
+	if err := processor.ProcessComparison(strings.NewReader(string(daily)), strings.NewReader(string(daily)), &comparison, "a", "b"); err != nil {
+		t.Fatalf("Comparison failed: %v", err)
+	}
+	if !strings.Contains(comparison.String(), `"CommonDays": "761"`) {
+		t.Errorf("Expected every day in common, got: %s", comparison.String())
+	}
+	err := processor.ProcessComparison(strings.NewReader(string(plain)), strings.NewReader(string(daily)), &comparison, "a", "b")
+	if err == nil || !strings.Contains(err.Error(), "failed to read a: weather data has no daily records") {
+		t.Errorf("Expected an error for JSON without daily records, got: %v", err)
+	}
+
+	processor = NewProcessorWithOptions(ProcessorOptions{InputFormat: "xml"})
+	if err := processor.ProcessReport(strings.NewReader(string(daily)), &report, DefaultReportOptions()); err == nil || !strings.Contains(err.Error(), "unknown input format 'xml'") {
+		t.Errorf("Expected an unknown input format error, got: %v", err)
+	}
+}
+
+func TestProcessorComparisonSkipsImputedDays(t *testing.T) {
+	csvContent := `Product code,Bureau of Meteorology station number,Year,Month,Day,Rainfall amount (millimetres),Period over which rainfall was measured (days),Quality
+IDCJAC0009,066062,2020,1,1,5.0,1,Y
+IDCJAC0009,066062,2020,1,2,1.0,1,Y
+IDCJAC0009,066062,2020,1,3,,,
+IDCJAC0009,066062,2020,1,4,3.0,1,Y`
+	options := ProcessorOptions{Aggregator: DefaultAggregatorOptions(), Imputation: ImputationOptions{Climatology: true}}
+	options.Aggregator.IncludeDaily = true
+	var imputed strings.Builder
+	if err := NewProcessorWithOptions(options).ProcessWeatherData(strings.NewReader(csvContent), &imputed); err != nil {
+		t.Fatalf("Processing failed: %v", err)
+	}
+	if !strings.Contains(imputed.String(), `"Imputed": "climatology"`) {
+		t.Fatalf("Expected an imputed day in the JSON, got: %s", imputed.String())
+	}
+
+	processor := NewProcessorWithOptions(ProcessorOptions{Aggregator: DefaultAggregatorOptions(), InputFormat: InputJSON})
+	var out strings.Builder
+	if err := processor.ProcessComparison(strings.NewReader(imputed.String()), strings.NewReader(imputed.String()), &out, "a", "b"); err != nil {
+		t.Fatalf("Comparison failed: %v", err)
+	}
+	for _, expected := range []string{`"CommonDays": "3"`, `"TotalRainfallA": "9.0"`} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected output to contain %s, got: %s", expected, out.String())
+		}
+	}
+}
+
+func TestProcessorFilter(t *testing.T) {
+	csvContent := `Product code,Bureau of Meteorology station number,Year,Month,Day,Rainfall amount (millimetres),Period over which rainfall was measured (days),Quality
+IDCJAC0009,066062,2019,12,31,9.9,1,Y
+IDCJAC0009,066062,2020,1,1,5.2,1,Y
+IDCJAC0009,066062,2020,2,1,1.0,1,Y`
+
+	var out strings.Builder
+	processor := NewProcessorWithOptions(ProcessorOptions{
+		Aggregator: DefaultAggregatorOptions(),
+		Filter:     Filter{Years: []int{2020}, Months: []time.Month{time.January}},
+	})
+	if err := processor.ProcessWeatherData(strings.NewReader(csvContent), &out); err != nil {
+		t.Fatalf("Processor failed: %v", err)
+	}
+
+	var data WeatherData
+	if err := json.Unmarshal([]byte(out.String()), &data); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	if len(data.WeatherDataForYear) != 1 || len(data.WeatherDataForYear[0].MonthlyAggregates.WeatherDataForMonth) != 1 {
+		t.Fatalf("Expected only January 2020, got %+v", data.WeatherDataForYear)
+	}
+	if data.Metadata == nil || data.Metadata.Filter == nil || data.Metadata.Filter.Months[0] != "January" {
+		t.Errorf("Expected filter recorded in metadata, got %+v", data.Metadata)
+	}
+	if data.WeatherDataForYear[0].ExpectedDays != "31" {
+		t.Errorf("Expected 31 expected days for a January-only filter, got %s", data.WeatherDataForYear[0].ExpectedDays)
+	}
+}
+
+func TestProcessorMedianMetadata(t *testing.T) {
+	csvContent := `Product code,Bureau of Meteorology station number,Year,Month,Day,Rainfall amount (millimetres),Period over which rainfall was measured (days),Quality
+IDCJAC0009,066062,2020,1,1,5.2,1,Y`
+
+	var out strings.Builder
+	if err := NewProcessor().ProcessWeatherData(strings.NewReader(csvContent), &out); err != nil {
+		t.Fatalf("Processor failed: %v", err)
+	}
+
+	var data WeatherData
+	if err := json.Unmarshal([]byte(out.String()), &data); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	// The default median definition is recorded, and nothing else
+	if data.Metadata == nil || data.Metadata.Median == nil || data.Metadata.Median.Mode != "wet" {
+		t.Errorf("Expected the median mode in metadata, got %+v", data.Metadata)
+	}
+	if data.Metadata != nil && (data.Metadata.Filter != nil || data.Metadata.Imputation != nil || data.Metadata.Statistics != nil) {
+		t.Errorf("Expected only the median in metadata, got %+v", data.Metadata)
+	}
+
+	// Statistics other than the standard ones are listed
+	options := DefaultAggregatorOptions()
+	options.Statistics, _ = DefaultStatistics().Select([]string{"TotalRainfall"})
+	out.Reset()
+	processor := NewProcessorWithOptions(ProcessorOptions{Aggregator: options})
+	if err := processor.ProcessWeatherData(strings.NewReader(csvContent), &out); err != nil {
+		t.Fatalf("Processor failed: %v", err)
+	}
+	data = WeatherData{}
+	if err := json.Unmarshal([]byte(out.String()), &data); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	if data.Metadata == nil || len(data.Metadata.Statistics) != 1 || data.Metadata.Statistics[0] != "TotalRainfall" {
+		t.Errorf("Expected the selected statistics in metadata, got %+v", data.Metadata)
+	}
+}
+
+func TestProcessorUpdate(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	header := "Product code,Bureau of Meteorology station number,Year,Month,Day,Rainfall amount (millimetres),Period over which rainfall was measured (days),Quality\n"
+
+	processor := NewProcessor()
+	applied, err := processor.ProcessUpdate(statePath, strings.NewReader(header+"IDCJAC0009,066062,2020,1,1,5.2,1,Y\n"), nil)
+	if err != nil {
+		t.Fatalf("First update failed: %v", err)
+	}
+	if applied != 1 {
+		t.Errorf("Expected 1 record applied, got %d", applied)
+	}
+	state, err := os.ReadFile(statePath)
+	if err != nil {
+		t.Fatalf("Failed to read state: %v", err)
+	}
+	if !strings.Contains(string(state), `"Aggregates"`) {
+		t.Errorf("Expected the state saved without output to keep the finished aggregates, got: %s", state)
+	}
+
+	var out strings.Builder
+	if _, err := processor.ProcessUpdate(statePath, strings.NewReader(header+"IDCJAC0009,066062,2020,1,2,3.0,1,Y\n"), &out); err != nil {
+		t.Fatalf("Second update failed: %v", err)
+	}
+	if !strings.Contains(out.String(), `"TotalRainfall": "8.200000000000"`) {
+		t.Errorf("Expected output to include both days, got: %s", out.String())
+	}
+
+	acc, err := processor.LoadStateFile(statePath)
+	if err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+	if acc.Len() != 2 {
+		t.Errorf("Expected 2 days in saved state, got %d", acc.Len())
+	}
+}
+
+func TestProcessorLoadStateFileInvalid(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(statePath, []byte("not json"), 0o644); err != nil {
+		t.Fatalf("Failed to write state file: %v", err)
+	}
+	if _, err := NewProcessor().LoadStateFile(statePath); err == nil {
+		t.Error("Expected error for corrupt state file")
+	}
+}
@@ -0,0 +1,407 @@
+package bom
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ReportOptions configures a rainfall report
+type ReportOptions struct {
+	// Aggregator configures the aggregation the report is built from. Years
+	// and months below its MinCompleteness are shown as incomplete and left
+	// out of the long-term means.
+	Aggregator AggregatorOptions
+	// Title heads the report. An empty Title names the station.
+	Title string
+	// Year selects the year of the recent-year table, the latest year when
+	// zero
+	Year int
+	// WettestDays is the number of wettest days listed
+	WettestDays int
+	// Chart styles the report's SVG charts
+	Chart ChartStyle
+}
+
+// DefaultReportOptions returns options for a report at BOM's published
+// precision that treats years and months under 90% complete as incomplete
+func DefaultReportOptions() ReportOptions {
+	aggregator := DefaultAggregatorOptions()
+	aggregator.MinCompleteness = 90
+	precision := BOMPrecision
+	aggregator.Precision = &precision
+	return ReportOptions{
+		Aggregator:  aggregator,
+		WettestDays: 10,
+		Chart:       DefaultChartStyle(),
+	}
+}
+
+// Report summarises a station's rainfall record
+type Report struct {
+	Title   string
+	Station string
+	Summary ReportSummary
+	// Years holds every year of the record, oldest first
+	Years []ReportYear
+	// Climatology is the mean total of each calendar month over the years
+	// in which that month was complete
+	Climatology []CalendarMonthVariability
+	RecentYear  ReportRecentYear
+	WettestDays []ReportDay
+}
+
+// ReportSummary describes the extent of the record and its long-term figures
+type ReportSummary struct {
+	FirstRecordedDate string
+	LastRecordedDate  string
+	Years             int
+	CompleteYears     int
+	RecordedDays      int
+	ExpectedDays      int
+	PercentComplete   string
+	// MeanAnnualRainfall is taken over complete years, and is empty when
+	// there are none
+	MeanAnnualRainfall string
+	// WettestYear and DriestYear are the complete years with the highest and
+	// lowest totals, or nil when there are none
+	WettestYear *ReportYear
+	DriestYear  *ReportYear
+}
+
+// ReportYear is one year of the annual totals. Incomplete is set for years
+// under the minimum completeness and for years that have not yet finished.
+type ReportYear struct {
+	Year             string
+	TotalRainfall    string
+	DaysWithRainfall string
+	PercentComplete  string
+	Incomplete       bool
+}
+
+// ReportRecentYear compares each month of a year with the climatology
+type ReportRecentYear struct {
+	Year          string
+	TotalRainfall string
+	// MeanTotalRainfall is the sum of the climatology of the year's months,
+	// so that a part year is compared with the same part of an average year
+	MeanTotalRainfall string
+	PercentOfMean     string
+	Months            []ReportMonth
+}
+
+// ReportMonth is one month of the recent-year table. MeanTotalRainfall and
+// PercentOfMean are empty when the month has no climatology.
+type ReportMonth struct {
+	Month             string
+	TotalRainfall     string
+	MeanTotalRainfall string
+	PercentOfMean     string
+	DaysWithRainfall  string
+	PercentComplete   string
+	Incomplete        bool
+}
+
+// ReportDay is one of the wettest days. Period is set when the reading
+// accumulated over more than one day.
+type ReportDay struct {
+	Rank     int
+	Date     string
+	Rainfall string
+	Period   string
+}
+
+// BuildReport aggregates the records and builds a report from the results
+func BuildReport(records []DailyRecord, options ReportOptions) (Report, error) {
+	data := NewAggregatorWithOptions(options.Aggregator).Aggregate(records)
+	return buildReport(data, records, options)
+}
+
+// BuildReportFromWeatherData builds a report from weather data that has
+// already been aggregated, such as decoded convert output. Its years,
+// completeness and climatology are used as they are, so only the clock and
+// precision of options.Aggregator apply. The wettest days are listed when
+// the data includes its daily records.
+func BuildReportFromWeatherData(data WeatherData, options ReportOptions) (Report, error) {
+	records, err := RecordsFromDailyValues(data)
+	if err != nil {
+		return Report{}, err
+	}
+	return buildReport(data, records, options)
+}
+
+// buildReport builds a report from aggregated weather data and the records
+// whose wettest days are listed
+func buildReport(data WeatherData, records []DailyRecord, options ReportOptions) (Report, error) {
+	if len(data.WeatherDataForYear) == 0 {
+		return Report{}, fmt.Errorf("no records to report")
+	}
+	p := LegacyPrecision
+	if options.Aggregator.Precision != nil {
+		p = *options.Aggregator.Precision
+	}
+
+	report := Report{
+		Title:       options.Title,
+		Station:     data.Station,
+		Climatology: data.InterannualVariability,
+	}
+	if report.Title == "" {
+		report.Title = "Rainfall report"
+		if report.Station != "" {
+			report.Title += " for station " + report.Station
+		}
+	}
+
+	clock := options.Aggregator.Clock
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	today := truncateToDay(clock.Now())
+	for _, y := range data.WeatherDataForYear {
+		year, err := newReportYear(y, today)
+		if err != nil {
+			return Report{}, err
+		}
+		report.Years = append(report.Years, year)
+	}
+	summary, err := newReportSummary(data, report.Years, p)
+	if err != nil {
+		return Report{}, err
+	}
+	report.Summary = summary
+
+	recent, err := newReportRecentYear(data, options.Year, p)
+	if err != nil {
+		return Report{}, err
+	}
+	report.RecentYear = recent
+	report.WettestDays = wettestDays(records, options.WettestDays, p)
+	return report, nil
+}
+
+// newReportYear describes a year, which is incomplete until it has finished
+// as of today
+func newReportYear(y WeatherDataForYear, today time.Time) (ReportYear, error) {
+	year, err := strconv.Atoi(y.Year)
+	if err != nil {
+		return ReportYear{}, fmt.Errorf("invalid year '%s': %w", y.Year, err)
+	}
+	return ReportYear{
+		Year:             y.Year,
+		TotalRainfall:    y.TotalRainfall,
+		DaysWithRainfall: y.DaysWithRainfall,
+		PercentComplete:  y.PercentComplete,
+		Incomplete:       y.Unreliable == "true" || !monthFinished(year, time.December, today),
+	}, nil
+}
+
+// newReportSummary summarises the aggregated years, whose report rows in
+// years say which are complete
+func newReportSummary(data WeatherData, years []ReportYear, p Precision) (ReportSummary, error) {
+	var summary ReportSummary
+	var completeTotals RunningMoments
+	var wettest, driest float64
+	for i, y := range data.WeatherDataForYear {
+		if y.FirstRecordedDate != "" && summary.FirstRecordedDate == "" {
+			summary.FirstRecordedDate = y.FirstRecordedDate
+		}
+		if y.LastRecordedDate != "" {
+			summary.LastRecordedDate = y.LastRecordedDate
+		}
+		summary.Years++
+		expected, err := strconv.Atoi(y.ExpectedDays)
+		if err != nil {
+			return ReportSummary{}, fmt.Errorf("invalid expected days for %s: %w", y.Year, err)
+		}
+		recorded, err := strconv.Atoi(y.RecordedDays)
+		if err != nil {
+			return ReportSummary{}, fmt.Errorf("invalid recorded days for %s: %w", y.Year, err)
+		}
+		summary.ExpectedDays += expected
+		summary.RecordedDays += recorded
+
+		year := years[i]
+		if year.Incomplete || y.TotalRainfall == "" {
+			continue
+		}
+		total, err := strconv.ParseFloat(y.TotalRainfall, 64)
+		if err != nil {
+			return ReportSummary{}, fmt.Errorf("invalid total rainfall for %s: %w", y.Year, err)
+		}
+		if completeTotals.Count() == 0 || total > wettest {
+			wettest, summary.WettestYear = total, &year
+		}
+		if completeTotals.Count() == 0 || total < driest {
+			driest, summary.DriestYear = total, &year
+		}
+		completeTotals.Add(total)
+	}
+
+	summary.CompleteYears = completeTotals.Count()
+	summary.PercentComplete = formatFloat(Completeness{ExpectedDays: summary.ExpectedDays, RecordedDays: summary.RecordedDays}.Percent(), 1)
+	if summary.CompleteYears > 0 {
+		summary.MeanAnnualRainfall = p.FormatDepthFloat(completeTotals.Mean())
+	}
+	return summary, nil
+}
+
+// newReportRecentYear compares the months of the given year, or the latest
+// year when year is zero, with the climatology
+func newReportRecentYear(data WeatherData, year int, p Precision) (ReportRecentYear, error) {
+	selected := &data.WeatherDataForYear[len(data.WeatherDataForYear)-1]
+	if year != 0 {
+		selected = nil
+		for i := range data.WeatherDataForYear {
+			if data.WeatherDataForYear[i].Year == strconv.Itoa(year) {
+				selected = &data.WeatherDataForYear[i]
+			}
+		}
+		if selected == nil {
+			return ReportRecentYear{}, fmt.Errorf("no records for year %d", year)
+		}
+	}
+
+	means := make(map[string]float64)
+	for _, v := range data.InterannualVariability {
+		mean, err := strconv.ParseFloat(v.MeanTotalRainfall, 64)
+		if err != nil {
+			return ReportRecentYear{}, fmt.Errorf("invalid mean total rainfall for %s: %w", v.Month, err)
+		}
+		means[v.Month] = mean
+	}
+
+	recent := ReportRecentYear{Year: selected.Year, TotalRainfall: selected.TotalRainfall}
+	var total, meanTotal float64
+	for _, m := range selected.MonthlyAggregates.WeatherDataForMonth {
+		month := ReportMonth{
+			Month:            m.Month,
+			TotalRainfall:    m.TotalRainfall,
+			DaysWithRainfall: m.DaysWithRainfall,
+			PercentComplete:  m.PercentComplete,
+			Incomplete:       m.Unreliable == "true",
+		}
+		mean, ok := means[m.Month]
+		if ok {
+			month.MeanTotalRainfall = p.FormatDepthFloat(mean)
+			meanTotal += mean
+		}
+		if m.TotalRainfall != "" {
+			value, err := strconv.ParseFloat(m.TotalRainfall, 64)
+			if err != nil {
+				return ReportRecentYear{}, fmt.Errorf("invalid total rainfall for %s %s: %w", m.Month, selected.Year, err)
+			}
+			total += value
+			if ok && mean > 0 {
+				month.PercentOfMean = formatFloat(100*value/mean, 0)
+			}
+		}
+		recent.Months = append(recent.Months, month)
+	}
+	if meanTotal > 0 {
+		recent.MeanTotalRainfall = p.FormatDepthFloat(meanTotal)
+		recent.PercentOfMean = formatFloat(100*total/meanTotal, 0)
+	}
+	return recent, nil
+}
+
+// wettestDays returns the n days with the highest readings, earliest first
+// among equal readings. Imputed days are not listed.
+func wettestDays(records []DailyRecord, n int, p Precision) []ReportDay {
+	var days []DailyRecord
+	for _, rec := range records {
+		if rec.HasData && rec.Imputed == "" {
+			days = append(days, rec)
+		}
+	}
+	sort.SliceStable(days, func(i, j int) bool {
+		if days[i].Rainfall != days[j].Rainfall {
+			return days[i].Rainfall > days[j].Rainfall
+		}
+		return days[i].Date.Before(days[j].Date)
+	})
+
+	var out []ReportDay
+	for i := 0; i < n && i < len(days); i++ {
+		day := ReportDay{
+			Rank:     i + 1,
+			Date:     days[i].Date.Format("2006-01-02"),
+			Rainfall: p.FormatAmount(days[i].Rainfall),
+		}
+		if days[i].Period > 1 {
+			day.Period = strconv.Itoa(days[i].Period)
+		}
+		out = append(out, day)
+	}
+	return out
+}
+
+// AnnualChart returns the bar chart of annual totals, with incomplete years
+// muted and the mean of complete years as a reference line
+func (r Report) AnnualChart() BarChart {
+	chart := BarChart{ChartLabels: ChartLabels{Title: "Annual rainfall", YLabel: "Rainfall (mm)"}}
+	for _, y := range r.Years {
+		chart.Bars = append(chart.Bars, Bar{Label: y.Year, Value: parseChartValue(y.TotalRainfall), Muted: y.Incomplete})
+	}
+	if r.Summary.MeanAnnualRainfall != "" {
+		chart.Reference = parseChartValue(r.Summary.MeanAnnualRainfall)
+		chart.ReferenceLabel = "Mean " + r.Summary.MeanAnnualRainfall + " mm"
+	}
+	return chart
+}
+
+// ClimatologyChart returns the bar chart of each calendar month's mean total
+func (r Report) ClimatologyChart() BarChart {
+	chart := BarChart{ChartLabels: ChartLabels{Title: "Mean monthly rainfall", YLabel: "Rainfall (mm)"}}
+	for _, v := range r.Climatology {
+		label := v.Month
+		if month, err := parseMonth(v.Month); err == nil {
+			label = month.String()[:3]
+		}
+		chart.Bars = append(chart.Bars, Bar{Label: label, Value: parseChartValue(v.MeanTotalRainfall)})
+	}
+	return chart
+}
+
+// parseChartValue parses an output value for a chart, treating a missing
+// value as zero
+func parseChartValue(value string) float64 {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
+
+//go:embed report.html.tmpl
+var reportHTML string
+
+var reportTemplate = template.Must(template.New("report").Parse(reportHTML))
+
+// reportView is the data of the HTML report template
+type reportView struct {
+	Report
+	AnnualChart      template.HTML
+	ClimatologyChart template.HTML
+	Incomplete       string
+}
+
+// WriteReportHTML writes the report as a single HTML file with inline CSS
+// and SVG charts, so that it can be viewed offline
+func WriteReportHTML(w io.Writer, report Report, style ChartStyle) error {
+	view := reportView{
+		Report: report,
+		// The charts escape their own text
+		AnnualChart:      template.HTML(report.AnnualChart().SVG(style)),
+		ClimatologyChart: template.HTML(report.ClimatologyChart().SVG(style)),
+		Incomplete:       style.MutedColour,
+	}
+	if err := reportTemplate.Execute(w, view); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package bom
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// reportRecords returns complete years of 1 mm a day in 2018 and 2 mm a day
+// in 2019, then January 2020 at 3 mm a day with 50 mm over two days on the
+// 15th
+func reportRecords() []DailyRecord {
+	var records []DailyRecord
+	for d := day(2018, 1, 1); d.Before(day(2020, 2, 1)); d = d.AddDate(0, 0, 1) {
+		rec := DailyRecord{Date: d, Rainfall: mm(float64(d.Year() - 2017)), HasData: true, Station: "066062"}
+		if d.Equal(day(2020, 1, 15)) {
+			rec.Rainfall, rec.Period = mm(50), 2
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func reportTestOptions() ReportOptions {
+	options := DefaultReportOptions()
+	options.Aggregator.Clock = FixedClock{Time: time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC)}
+	options.WettestDays = 2
+	return options
+}
+
+func TestBuildReport(t *testing.T) {
+	report, err := BuildReport(reportRecords(), reportTestOptions())
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if report.Title != "Rainfall report for station 066062" {
+		t.Errorf("Unexpected title %q", report.Title)
+	}
+	s := report.Summary
+	if s.FirstRecordedDate != "2018-01-01" || s.LastRecordedDate != "2020-01-31" || s.Years != 3 || s.CompleteYears != 2 {
+		t.Errorf("Unexpected summary %+v", s)
+	}
+	if s.MeanAnnualRainfall != "547.5" || s.WettestYear.Year != "2019" || s.DriestYear.Year != "2018" {
+		t.Errorf("Expected a mean of 547.5 mm with 2019 wettest and 2018 driest, got %+v", s)
+	}
+	if len(report.Years) != 3 || !report.Years[2].Incomplete || report.Years[0].Incomplete {
+		t.Errorf("Expected only 2020 to be incomplete, got %+v", report.Years)
+	}
+
+	// January is complete in all three years: (31 + 62 + 140) / 3
+	if report.Climatology[0].Month != "January" || report.Climatology[0].MeanTotalRainfall != "77.7" {
+		t.Errorf("Unexpected January climatology %+v", report.Climatology[0])
+	}
+
+	recent := report.RecentYear
+	if recent.Year != "2020" || len(recent.Months) != 1 {
+		t.Fatalf("Expected the months of 2020, got %+v", recent)
+	}
+	if m := recent.Months[0]; m.TotalRainfall != "140.0" || m.MeanTotalRainfall != "77.7" || m.PercentOfMean != "180" {
+		t.Errorf("Unexpected January 2020 row %+v", m)
+	}
+	if recent.MeanTotalRainfall != "77.7" || recent.PercentOfMean != "180" {
+		t.Errorf("Unexpected 2020 totals %+v", recent)
+	}
+
+	want := []ReportDay{
+		{Rank: 1, Date: "2020-01-15", Rainfall: "50.0", Period: "2"},
+		{Rank: 2, Date: "2020-01-01", Rainfall: "3.0"},
+	}
+	if len(report.WettestDays) != 2 || report.WettestDays[0] != want[0] || report.WettestDays[1] != want[1] {
+		t.Errorf("Expected wettest days %+v, got %+v", want, report.WettestDays)
+	}
+}
+
+func TestBuildReport_Options(t *testing.T) {
+	options := reportTestOptions()
+	options.Title = "Sydney"
+	options.Year = 2019
+	report, err := BuildReport(reportRecords(), options)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if report.Title != "Sydney" || report.RecentYear.Year != "2019" || len(report.RecentYear.Months) != 12 {
+		t.Errorf("Expected the 2019 table titled Sydney, got %q and %+v", report.Title, report.RecentYear)
+	}
+
+	options.Year = 2000
+	if _, err := BuildReport(reportRecords(), options); err == nil {
+		t.Error("Expected error for a year without records")
+	}
+	if _, err := BuildReport(nil, reportTestOptions()); err == nil {
+		t.Error("Expected error for no records")
+	}
+}
+
+func TestBuildReport_UnfinishedYearIsIncomplete(t *testing.T) {
+	options := reportTestOptions()
+	options.Aggregator.Clock = FixedClock{Time: time.Date(2019, 3, 15, 0, 0, 0, 0, time.UTC)}
+	report, err := BuildReport(reportRecords(), options)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(report.Years) != 2 || report.Years[1].Year != "2019" || !report.Years[1].Incomplete {
+		t.Fatalf("Expected 2019 to be incomplete, got %+v", report.Years)
+	}
+	if report.Years[1].PercentComplete != "100.0" {
+		t.Errorf("Expected every day of 2019 so far to be recorded, got %s%%", report.Years[1].PercentComplete)
+	}
+	summary := report.Summary
+	if summary.CompleteYears != 1 || summary.MeanAnnualRainfall != "365.0" {
+		t.Errorf("Expected only 2018 to be complete, got %d years with mean %s", summary.CompleteYears, summary.MeanAnnualRainfall)
+	}
+	if summary.DriestYear == nil || summary.DriestYear.Year != "2018" || summary.WettestYear == nil || summary.WettestYear.Year != "2018" {
+		t.Errorf("Expected 2018 to be the driest and wettest year, got %+v and %+v", summary.DriestYear, summary.WettestYear)
+	}
+	if bars := report.AnnualChart().Bars; !bars[1].Muted {
+		t.Errorf("Expected the 2019 bar to be muted, got %+v", bars)
+	}
+}
+
+func TestBuildReportFromWeatherData(t *testing.T) {
+	options := reportTestOptions()
+	want, err := BuildReport(reportRecords(), options)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	aggregator := options.Aggregator
+	aggregator.IncludeDaily = true
+	data := NewAggregatorWithOptions(aggregator).Aggregate(reportRecords())
+	got, err := BuildReportFromWeatherData(data, options)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the report of the records\nwant: %+v\ngot:  %+v", want, got)
+	}
+
+	data = NewAggregatorWithOptions(options.Aggregator).Aggregate(reportRecords())
+	got, err = BuildReportFromWeatherData(data, options)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if got.WettestDays != nil || !reflect.DeepEqual(got.Summary, want.Summary) {
+		t.Errorf("Expected the summary without wettest days, got %+v and %+v", got.Summary, got.WettestDays)
+	}
+}
+
+func TestReport_Charts(t *testing.T) {
+	report, err := BuildReport(reportRecords(), reportTestOptions())
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	annual := report.AnnualChart()
+	if len(annual.Bars) != 3 || annual.Bars[1].Value != 730 || !annual.Bars[2].Muted {
+		t.Errorf("Unexpected annual bars %+v", annual.Bars)
+	}
+	if annual.Reference != 547.5 || annual.ReferenceLabel != "Mean 547.5 mm" {
+		t.Errorf("Expected the mean as a reference line, got %g %q", annual.Reference, annual.ReferenceLabel)
+	}
+
+	climatology := report.ClimatologyChart()
+	if len(climatology.Bars) != 12 || climatology.Bars[0].Label != "Jan" || climatology.Bars[1].Value != 42 {
+		t.Errorf("Unexpected climatology bars %+v", climatology.Bars)
+	}
+}
+
+func TestWriteReportHTML(t *testing.T) {
+	options := reportTestOptions()
+	options.Title = "Rain <&> report"
+	report, err := BuildReport(reportRecords(), options)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReportHTML(&buf, report, DefaultChartStyle()); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	html := buf.String()
+
+	if !strings.HasPrefix(html, "<!DOCTYPE html>") || !strings.Contains(html, "<title>Rain &lt;&amp;&gt; report</title>") {
+		t.Errorf("Expected an escaped title, got: %.300s", html)
+	}
+	if strings.Count(html, "<svg ") != 2 {
+		t.Errorf("Expected 2 inline SVG charts, got %d", strings.Count(html, "<svg "))
+	}
+	for _, want := range []string{"Mean annual rainfall</dt><dd>547.5 mm", "<h2>2020</h2>", "Accumulated over 2 days", "Mean 547.5 mm"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Expected report to contain %q", want)
+		}
+	}
+	for _, external := range []string{"<script", "<link", "src=", "href="} {
+		if strings.Contains(html, external) {
+			t.Errorf("Expected no external resources, found %q", external)
+		}
+	}
+}
@@ -0,0 +1,389 @@
+package bom
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// StandardARIs are the average recurrence intervals, in years, reported by default
+var StandardARIs = []float64{2, 5, 10, 20, 50, 100}
+
+// eulerGamma is the Euler–Mascheroni constant used by the Gumbel fit
+const eulerGamma = 0.5772156649015329
+
+// ReturnPeriodOptions controls annual maximum extraction and distribution fitting
+type ReturnPeriodOptions struct {
+	// Durations are the N-day accumulation lengths to analyse
+	Durations []int
+	// ARIs are the average recurrence intervals, in years, to estimate
+	ARIs []float64
+	// MinCompleteness is the minimum percentage of days in a calendar year
+	// that must have a reading for that year's maximum to be used
+	MinCompleteness float64
+	// Confidence is the two-sided confidence level of the intervals (e.g. 0.9)
+	Confidence float64
+	// Resamples is the number of bootstrap resamples used for the intervals
+	Resamples int
+	// Seed makes the bootstrap deterministic
+	Seed int64
+}
+
+// DefaultReturnPeriodOptions returns the options used by the CLI by default
+func DefaultReturnPeriodOptions() ReturnPeriodOptions {
+	return ReturnPeriodOptions{
+		Durations:       []int{1},
+		ARIs:            StandardARIs,
+		MinCompleteness: 90,
+		Confidence:      0.9,
+		Resamples:       1000,
+		Seed:            1,
+	}
+}
+
+// LMoments holds the first two sample L-moments and the L-skewness ratio
+type LMoments struct {
+	L1 float64
+	L2 float64
+	T3 float64
+}
+
+// SampleLMoments computes unbiased sample L-moments from probability
+// weighted moments. At least three values are required.
+func SampleLMoments(values []float64) (LMoments, error) {
+	n := len(values)
+	if n < 3 {
+		return LMoments{}, fmt.Errorf("at least 3 values are required for L-moments, got %d", n)
+	}
+
+	x := make([]float64, n)
+	copy(x, values)
+	sort.Float64s(x)
+
+	var b0, b1, b2 float64
+	fn := float64(n)
+	for i, v := range x {
+		fi := float64(i)
+		b0 += v
+		b1 += v * fi / (fn - 1)
+		b2 += v * fi * (fi - 1) / ((fn - 1) * (fn - 2))
+	}
+	b0 /= fn
+	b1 /= fn
+	b2 /= fn
+
+	l1 := b0
+	l2 := 2*b1 - b0
+	l3 := 6*b2 - 6*b1 + b0
+	if l2 <= 0 {
+		return LMoments{}, fmt.Errorf("sample has no dispersion")
+	}
+	return LMoments{L1: l1, L2: l2, T3: l3 / l2}, nil
+}
+
+// Distribution is a fitted extreme value distribution
+type Distribution interface {
+	Name() string
+	// Quantile returns the value with non-exceedance probability p
+	Quantile(p float64) float64
+}
+
+// Gumbel is the extreme value type I distribution
+type Gumbel struct {
+	Location float64
+	Scale    float64
+}
+
+// FitGumbel fits a Gumbel distribution by the method of L-moments
+func FitGumbel(lm LMoments) Gumbel {
+	scale := lm.L2 / math.Ln2
+	return Gumbel{Location: lm.L1 - eulerGamma*scale, Scale: scale}
+}
+
+// Name returns the distribution name
+func (g Gumbel) Name() string {
+	return "Gumbel"
+}
+
+// Quantile returns the value with non-exceedance probability p
+func (g Gumbel) Quantile(p float64) float64 {
+	return g.Location - g.Scale*math.Log(-math.Log(p))
+}
+
+// GEV is the generalised extreme value distribution in Hosking's
+// parameterisation, where a positive Shape gives an upper-bounded tail
+type GEV struct {
+	Location float64
+	Scale    float64
+	Shape    float64
+}
+
+// FitGEV fits a GEV distribution by the method of L-moments using Hosking's
+// rational approximation for the shape parameter
+func FitGEV(lm LMoments) (GEV, error) {
+	c := 2/(3+lm.T3) - math.Ln2/math.Log(3)
+	k := 7.8590*c + 2.9554*c*c
+	if math.Abs(k) < 1e-6 {
+		g := FitGumbel(lm)
+		return GEV{Location: g.Location, Scale: g.Scale}, nil
+	}
+	if k <= -1 {
+		return GEV{}, fmt.Errorf("L-skewness %.3f is outside the range supported by the GEV fit", lm.T3)
+	}
+
+	gamma := math.Gamma(1 + k)
+	scale := lm.L2 * k / ((1 - math.Pow(2, -k)) * gamma)
+	location := lm.L1 - scale*(1-gamma)/k
+	return GEV{Location: location, Scale: scale, Shape: k}, nil
+}
+
+// Name returns the distribution name
+func (g GEV) Name() string {
+	return "GEV"
+}
+
+// Quantile returns the value with non-exceedance probability p
+func (g GEV) Quantile(p float64) float64 {
+	y := -math.Log(p)
+	if g.Shape == 0 {
+		return g.Location - g.Scale*math.Log(y)
+	}
+	return g.Location + g.Scale/g.Shape*(1-math.Pow(y, g.Shape))
+}
+
+// ariProbability converts an average recurrence interval in years to the
+// annual non-exceedance probability
+func ariProbability(ari float64) float64 {
+	return 1 - 1/ari
+}
+
+// ReturnPeriodEstimate is the estimated depth for one recurrence interval
+type ReturnPeriodEstimate struct {
+	ARI   float64
+	Depth float64
+	Lower float64
+	Upper float64
+}
+
+// DistributionFit holds a fitted distribution and its return period estimates
+type DistributionFit struct {
+	Distribution Distribution
+	Estimates    []ReturnPeriodEstimate
+}
+
+// ReturnPeriodAnalysis is the result of fitting one annual maximum series
+type ReturnPeriodAnalysis struct {
+	Duration      int
+	Maxima        []MaxAccumulation
+	ExcludedYears []int
+	Fits          []DistributionFit
+}
+
+// AnnualMaximumSeries extracts the annual maximum N-day totals from the
+// series, excluding calendar years where fewer than minCompleteness percent
+// of days have a reading. It returns the maxima used and the excluded years.
+func AnnualMaximumSeries(series DailySeries, days int, minCompleteness float64) ([]MaxAccumulation, []int) {
+	recorded := make(map[int]int)
+	for i := 0; i < series.Len(); i++ {
+		if series.Valid[i] {
+			recorded[series.Date(i).Year()]++
+		}
+	}
+
+	var maxima []MaxAccumulation
+	var excluded []int
+	for _, m := range AnnualMaxAccumulations(series, days, 0) {
+		percent := 100 * float64(recorded[m.Year]) / float64(daysInYear(m.Year))
+		if percent < minCompleteness {
+			excluded = append(excluded, m.Year)
+			continue
+		}
+		maxima = append(maxima, m)
+	}
+	return maxima, excluded
+}
+
+// AnalyseReturnPeriods fits Gumbel and GEV distributions to the annual
+// maximum series for each duration and estimates depths for each ARI with
+// bootstrap confidence intervals
+func AnalyseReturnPeriods(records []DailyRecord, opts ReturnPeriodOptions) ([]ReturnPeriodAnalysis, error) {
+	series := NewDailySeries(records)
+	rng := rand.New(rand.NewSource(opts.Seed))
+
+	var analyses []ReturnPeriodAnalysis
+	for _, days := range opts.Durations {
+		if days < 1 {
+			return nil, fmt.Errorf("invalid duration %d: must be at least 1 day", days)
+		}
+
+		maxima, excluded := AnnualMaximumSeries(series, days, opts.MinCompleteness)
+		values := make([]float64, len(maxima))
+		for i, m := range maxima {
+			values[i] = m.Total.Float64()
+		}
+
+		fits, err := fitReturnPeriods(values, opts, rng)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fit %d-day annual maxima: %w", days, err)
+		}
+
+		analyses = append(analyses, ReturnPeriodAnalysis{
+			Duration:      days,
+			Maxima:        maxima,
+			ExcludedYears: excluded,
+			Fits:          fits,
+		})
+	}
+	return analyses, nil
+}
+
+// fitReturnPeriods fits both distributions to the annual maxima
+func fitReturnPeriods(values []float64, opts ReturnPeriodOptions, rng *rand.Rand) ([]DistributionFit, error) {
+	lm, err := SampleLMoments(values)
+	if err != nil {
+		return nil, err
+	}
+
+	fitters := []func(LMoments) (Distribution, error){
+		func(lm LMoments) (Distribution, error) { return FitGumbel(lm), nil },
+		func(lm LMoments) (Distribution, error) { return FitGEV(lm) },
+	}
+
+	var fits []DistributionFit
+	for _, fitter := range fitters {
+		dist, err := fitter(lm)
+		if err != nil {
+			return nil, err
+		}
+		lower, upper := bootstrapIntervals(values, fitter, opts, rng)
+		fit := DistributionFit{Distribution: dist}
+		for j, ari := range opts.ARIs {
+			fit.Estimates = append(fit.Estimates, ReturnPeriodEstimate{
+				ARI:   ari,
+				Depth: dist.Quantile(ariProbability(ari)),
+				Lower: lower[j],
+				Upper: upper[j],
+			})
+		}
+		fits = append(fits, fit)
+	}
+	return fits, nil
+}
+
+// bootstrapIntervals resamples the annual maxima with replacement, refits
+// the distribution and returns percentile intervals for each ARI. Resamples
+// that cannot be fitted are skipped; intervals are NaN if none succeed.
+func bootstrapIntervals(values []float64, fit func(LMoments) (Distribution, error), opts ReturnPeriodOptions, rng *rand.Rand) ([]float64, []float64) {
+	quantiles := make([][]float64, len(opts.ARIs))
+	sample := make([]float64, len(values))
+	for r := 0; r < opts.Resamples; r++ {
+		for i := range sample {
+			sample[i] = values[rng.Intn(len(values))]
+		}
+		lm, err := SampleLMoments(sample)
+		if err != nil {
+			continue
+		}
+		dist, err := fit(lm)
+		if err != nil {
+			continue
+		}
+		for j, ari := range opts.ARIs {
+			quantiles[j] = append(quantiles[j], dist.Quantile(ariProbability(ari)))
+		}
+	}
+
+	alpha := (1 - opts.Confidence) / 2
+	lower := make([]float64, len(opts.ARIs))
+	upper := make([]float64, len(opts.ARIs))
+	for j, q := range quantiles {
+		lower[j] = percentile(q, alpha)
+		upper[j] = percentile(q, 1-alpha)
+	}
+	return lower, upper
+}
+
+// percentile returns the p-th quantile (0..1) of values by linear
+// interpolation between closest ranks, or NaN for an empty slice
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	pos := p * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	frac := pos - float64(lo)
+	return sorted[lo] + (sorted[hi]-sorted[lo])*frac
+}
+
+// daysInYear returns the number of days in the calendar year
+func daysInYear(year int) int {
+	start := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	return daysBetween(start, start.AddDate(1, 0, 0))
+}
+
+// NewReturnPeriodData converts return period analyses into their JSON output structure
+func NewReturnPeriodData(analyses []ReturnPeriodAnalysis, opts ReturnPeriodOptions) ReturnPeriodData {
+	data := ReturnPeriodData{
+		Confidence:      formatFloat(opts.Confidence, 2),
+		MinCompleteness: formatFloat(opts.MinCompleteness, 1),
+	}
+	for _, a := range analyses {
+		out := ReturnPeriodsForDuration{
+			Duration:  strconv.Itoa(a.Duration),
+			YearsUsed: strconv.Itoa(len(a.Maxima)),
+		}
+		for _, y := range a.ExcludedYears {
+			out.ExcludedYears = append(out.ExcludedYears, strconv.Itoa(y))
+		}
+		for _, m := range a.Maxima {
+			out.AnnualMaxima = append(out.AnnualMaxima, AnnualMaximum{
+				Year:          strconv.Itoa(m.Year),
+				TotalRainfall: m.Total.Format(1),
+				StartDate:     m.StartDate.Format("2006-01-02"),
+			})
+		}
+		for _, fit := range a.Fits {
+			out.Fits = append(out.Fits, newDistributionFitData(fit))
+		}
+		data.ReturnPeriodsForDuration = append(data.ReturnPeriodsForDuration, out)
+	}
+	return data
+}
+
+func newDistributionFitData(fit DistributionFit) DistributionFitData {
+	out := DistributionFitData{Distribution: fit.Distribution.Name()}
+	switch d := fit.Distribution.(type) {
+	case Gumbel:
+		out.Location = formatFloat(d.Location, 4)
+		out.Scale = formatFloat(d.Scale, 4)
+	case GEV:
+		out.Location = formatFloat(d.Location, 4)
+		out.Scale = formatFloat(d.Scale, 4)
+		out.Shape = formatFloat(d.Shape, 4)
+	}
+	for _, e := range fit.Estimates {
+		out.Estimates = append(out.Estimates, ReturnPeriodEstimateData{
+			ARI:   strconv.FormatFloat(e.ARI, 'f', -1, 64),
+			Depth: formatFloat(e.Depth, 1),
+			Lower: formatOptionalFloat(e.Lower, 1),
+			Upper: formatOptionalFloat(e.Upper, 1),
+		})
+	}
+	return out
+}
+
+// formatOptionalFloat formats f like formatFloat, or returns an empty string for NaN
+func formatOptionalFloat(f float64, precision int) string {
+	if math.IsNaN(f) {
+		return ""
+	}
+	return formatFloat(f, precision)
+}
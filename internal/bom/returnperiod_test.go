@@ -0,0 +1,148 @@
+package bom
+
+import (
+	"math"
+	"testing"
+)
+
+func approxEqual(a, b, tol float64) bool {
+	return math.Abs(a-b) <= tol
+}
+
+func TestSampleLMoments(t *testing.T) {
+	lm, err := SampleLMoments([]float64{5, 3, 1, 4, 2})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !approxEqual(lm.L1, 3, 1e-12) || !approxEqual(lm.L2, 1, 1e-12) || !approxEqual(lm.T3, 0, 1e-12) {
+		t.Errorf("Expected L1=3, L2=1, T3=0, got %+v", lm)
+	}
+}
+
+func TestSampleLMoments_Errors(t *testing.T) {
+	if _, err := SampleLMoments([]float64{1, 2}); err == nil {
+		t.Error("Expected error for fewer than 3 values")
+	}
+	if _, err := SampleLMoments([]float64{2, 2, 2}); err == nil {
+		t.Error("Expected error for a sample with no dispersion")
+	}
+}
+
+func TestFitGumbel(t *testing.T) {
+	g := FitGumbel(LMoments{L1: 100, L2: 20})
+
+	expectedScale := 20 / math.Ln2
+	if !approxEqual(g.Scale, expectedScale, 1e-9) {
+		t.Errorf("Expected scale %v, got %v", expectedScale, g.Scale)
+	}
+	if !approxEqual(g.Location, 100-eulerGamma*expectedScale, 1e-9) {
+		t.Errorf("Unexpected location %v", g.Location)
+	}
+	// The 2-year ARI of a Gumbel is location - scale*ln(ln 2)
+	expected := g.Location - g.Scale*math.Log(math.Ln2)
+	if !approxEqual(g.Quantile(ariProbability(2)), expected, 1e-9) {
+		t.Errorf("Expected 2-year depth %v, got %v", expected, g.Quantile(0.5))
+	}
+}
+
+func TestFitGEV_GumbelLimit(t *testing.T) {
+	// The L-skewness of a Gumbel distribution gives a shape of (nearly) zero
+	lm := LMoments{L1: 100, L2: 20, T3: 0.1699}
+	gev, err := FitGEV(lm)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	gumbel := FitGumbel(lm)
+
+	if !approxEqual(gev.Shape, 0, 1e-3) {
+		t.Errorf("Expected shape near 0, got %v", gev.Shape)
+	}
+	for _, ari := range StandardARIs {
+		p := ariProbability(ari)
+		if !approxEqual(gev.Quantile(p), gumbel.Quantile(p), 0.5) {
+			t.Errorf("ARI %v: expected GEV %v to match Gumbel %v", ari, gev.Quantile(p), gumbel.Quantile(p))
+		}
+	}
+}
+
+func TestFitGEV_HeavyTail(t *testing.T) {
+	gev, err := FitGEV(LMoments{L1: 100, L2: 20, T3: 0.3})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if gev.Shape >= 0 {
+		t.Errorf("Expected negative shape for a heavy upper tail, got %v", gev.Shape)
+	}
+	if gev.Quantile(ariProbability(100)) <= gev.Quantile(ariProbability(10)) {
+		t.Error("Expected depths to increase with ARI")
+	}
+}
+
+func TestAnnualMaximumSeries_ExcludesIncompleteYears(t *testing.T) {
+	var records []DailyRecord
+	for d := day(2019, 1, 1); d.Year() == 2019; d = d.AddDate(0, 0, 1) {
+		records = append(records, DailyRecord{Date: d, Rainfall: mm(float64(d.YearDay() % 7)), HasData: true})
+	}
+	// Only a week of 2020 is recorded
+	for d := day(2020, 1, 1); d.Before(day(2020, 1, 8)); d = d.AddDate(0, 0, 1) {
+		records = append(records, DailyRecord{Date: d, Rainfall: mm(50), HasData: true})
+	}
+
+	maxima, excluded := AnnualMaximumSeries(NewDailySeries(records), 1, 90)
+
+	if len(maxima) != 1 || maxima[0].Year != 2019 || maxima[0].Total != mm(6) {
+		t.Errorf("Expected only 2019 with maximum 6, got %+v", maxima)
+	}
+	if len(excluded) != 1 || excluded[0] != 2020 {
+		t.Errorf("Expected 2020 to be excluded, got %v", excluded)
+	}
+}
+
+func TestAnalyseReturnPeriods(t *testing.T) {
+	var records []DailyRecord
+	for year := 2000; year < 2020; year++ {
+		for d := day(year, 1, 1); d.Year() == year; d = d.AddDate(0, 0, 1) {
+			rain := 0.0
+			if d.YearDay() == 100 {
+				rain = float64(40 + (year*37)%60)
+			}
+			records = append(records, DailyRecord{Date: d, Rainfall: mm(rain), HasData: true})
+		}
+	}
+
+	opts := DefaultReturnPeriodOptions()
+	opts.Resamples = 200
+	analyses, err := AnalyseReturnPeriods(records, opts)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(analyses) != 1 || len(analyses[0].Maxima) != 20 {
+		t.Fatalf("Expected one analysis of 20 years, got %+v", analyses)
+	}
+	if len(analyses[0].Fits) != 2 {
+		t.Fatalf("Expected Gumbel and GEV fits, got %d", len(analyses[0].Fits))
+	}
+	for _, fit := range analyses[0].Fits {
+		if len(fit.Estimates) != len(StandardARIs) {
+			t.Fatalf("%s: expected %d estimates, got %d", fit.Distribution.Name(), len(StandardARIs), len(fit.Estimates))
+		}
+		for _, e := range fit.Estimates {
+			if !(e.Lower <= e.Depth && e.Depth <= e.Upper) {
+				t.Errorf("%s ARI %v: expected %v within [%v, %v]", fit.Distribution.Name(), e.ARI, e.Depth, e.Lower, e.Upper)
+			}
+		}
+	}
+
+	data := NewReturnPeriodData(analyses, opts)
+	if data.ReturnPeriodsForDuration[0].YearsUsed != "20" || data.ReturnPeriodsForDuration[0].Fits[0].Distribution != "Gumbel" {
+		t.Errorf("Unexpected output data: %+v", data.ReturnPeriodsForDuration[0])
+	}
+}
+
+func TestAnalyseReturnPeriods_InsufficientYears(t *testing.T) {
+	records := []DailyRecord{{Date: day(2020, 1, 1), Rainfall: mm(10), HasData: true}}
+	if _, err := AnalyseReturnPeriods(records, DefaultReturnPeriodOptions()); err == nil {
+		t.Error("Expected error when there are too few complete years")
+	}
+}
@@ -0,0 +1,241 @@
+package bom
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DailySeries is a calendar-contiguous view of daily records.
+// Days that are absent from the input or have no rainfall reading are kept
+// as gaps so that windows over the series can account for them.
+type DailySeries struct {
+	Start time.Time
+	// Values are the daily readings, held exactly
+	Values []Amount
+	Valid  []bool
+}
+
+// NewDailySeries builds a contiguous daily series spanning the first to the
+// last record. Duplicate dates keep the last reading seen.
+func NewDailySeries(records []DailyRecord) DailySeries {
+	if len(records) == 0 {
+		return DailySeries{}
+	}
+
+	sorted := make([]DailyRecord, len(records))
+	copy(sorted, records)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	start := truncateToDay(sorted[0].Date)
+	end := truncateToDay(sorted[len(sorted)-1].Date)
+	n := daysBetween(start, end) + 1
+
+	series := DailySeries{
+		Start:  start,
+		Values: make([]Amount, n),
+		Valid:  make([]bool, n),
+	}
+	for _, rec := range sorted {
+		i := daysBetween(start, truncateToDay(rec.Date))
+		series.Values[i] = rec.Rainfall
+		series.Valid[i] = rec.HasData
+	}
+	return series
+}
+
+// Len returns the number of calendar days covered by the series
+func (s DailySeries) Len() int {
+	return len(s.Values)
+}
+
+// Date returns the calendar date of the i-th day of the series
+func (s DailySeries) Date(i int) time.Time {
+	return s.Start.AddDate(0, 0, i)
+}
+
+// RollingTotal is the accumulated rainfall over a window ending on Date
+type RollingTotal struct {
+	Date        time.Time
+	Window      int
+	Total       Amount
+	MissingDays int
+	// Valid is false when the window starts before the series or has more
+	// missing days than allowed, in which case Total should not be used.
+	Valid bool
+}
+
+// RollingTotals computes the rainfall total over the trailing window of the
+// given number of days for every day in the series. A window is valid when
+// it lies entirely within the series and has at most maxMissing days
+// without a reading; the total is the sum of the days that were recorded.
+func RollingTotals(series DailySeries, window, maxMissing int) []RollingTotal {
+	if window < 1 || series.Len() == 0 {
+		return nil
+	}
+
+	totals := make([]RollingTotal, series.Len())
+	var sum Amount
+	var missing int
+	for i := 0; i < series.Len(); i++ {
+		if series.Valid[i] {
+			sum += series.Values[i]
+		} else {
+			missing++
+		}
+		if i >= window {
+			out := i - window
+			if series.Valid[out] {
+				sum -= series.Values[out]
+			} else {
+				missing--
+			}
+		}
+
+		totals[i] = RollingTotal{
+			Date:        series.Date(i),
+			Window:      window,
+			Total:       sum,
+			MissingDays: missing,
+			Valid:       i >= window-1 && missing <= maxMissing,
+		}
+	}
+	return totals
+}
+
+// MaxAccumulation is the largest N-day rainfall total within a year
+type MaxAccumulation struct {
+	Year      int
+	Days      int
+	Total     Amount
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+// AnnualMaxAccumulations returns, for each year in the series, the largest
+// valid N-day total. A window is attributed to the year in which it starts.
+// Years without any valid window are omitted.
+func AnnualMaxAccumulations(series DailySeries, days, maxMissing int) []MaxAccumulation {
+	byYear := make(map[int]*MaxAccumulation)
+	for _, rt := range RollingTotals(series, days, maxMissing) {
+		if !rt.Valid {
+			continue
+		}
+		start := rt.Date.AddDate(0, 0, -(days - 1))
+		year := start.Year()
+		best, ok := byYear[year]
+		if !ok || rt.Total > best.Total {
+			byYear[year] = &MaxAccumulation{
+				Year:      year,
+				Days:      days,
+				Total:     rt.Total,
+				StartDate: start,
+				EndDate:   rt.Date,
+			}
+		}
+	}
+
+	var years []int
+	for y := range byYear {
+		years = append(years, y)
+	}
+	sort.Ints(years)
+
+	result := make([]MaxAccumulation, 0, len(years))
+	for _, y := range years {
+		result = append(result, *byYear[y])
+	}
+	return result
+}
+
+// ExtremeIndices holds the Rx1day, Rx3day, Rx5day and Rx7day indices for a
+// year.
+// An index is nil when no valid window of that length exists in the year.
+type ExtremeIndices struct {
+	Year   int
+	Rx1day *MaxAccumulation
+	Rx3day *MaxAccumulation
+	Rx5day *MaxAccumulation
+	Rx7day *MaxAccumulation
+}
+
+// AnnualExtremeIndices computes Rx1day, Rx3day, Rx5day and Rx7day for each
+// year
+func AnnualExtremeIndices(series DailySeries, maxMissing int) []ExtremeIndices {
+	byYear := make(map[int]*ExtremeIndices)
+	get := func(year int) *ExtremeIndices {
+		if e, ok := byYear[year]; ok {
+			return e
+		}
+		e := &ExtremeIndices{Year: year}
+		byYear[year] = e
+		return e
+	}
+
+	for _, days := range []int{1, 3, 5, 7} {
+		for _, m := range AnnualMaxAccumulations(series, days, maxMissing) {
+			m := m
+			e := get(m.Year)
+			switch days {
+			case 1:
+				e.Rx1day = &m
+			case 3:
+				e.Rx3day = &m
+			case 5:
+				e.Rx5day = &m
+			case 7:
+				e.Rx7day = &m
+			}
+		}
+	}
+
+	var years []int
+	for y := range byYear {
+		years = append(years, y)
+	}
+	sort.Ints(years)
+
+	result := make([]ExtremeIndices, 0, len(years))
+	for _, y := range years {
+		result = append(result, *byYear[y])
+	}
+	return result
+}
+
+// truncateToDay drops the time-of-day component of t in UTC
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// daysBetween returns the number of whole days from a to b
+func daysBetween(a, b time.Time) int {
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// NewExtremesData converts extreme indices into their JSON output structure
+func NewExtremesData(indices []ExtremeIndices) ExtremesData {
+	years := make([]ExtremesForYear, 0, len(indices))
+	for _, e := range indices {
+		years = append(years, ExtremesForYear{
+			Year:   strconv.Itoa(e.Year),
+			Rx1day: newAccumulation(e.Rx1day),
+			Rx3day: newAccumulation(e.Rx3day),
+			Rx5day: newAccumulation(e.Rx5day),
+			Rx7day: newAccumulation(e.Rx7day),
+		})
+	}
+	return ExtremesData{ExtremesForYear: years}
+}
+
+func newAccumulation(m *MaxAccumulation) *Accumulation {
+	if m == nil {
+		return nil
+	}
+	return &Accumulation{
+		TotalRainfall: m.Total.Format(1),
+		StartDate:     m.StartDate.Format("2006-01-02"),
+		EndDate:       m.EndDate.Format("2006-01-02"),
+	}
+}
@@ -0,0 +1,195 @@
+package bom
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestNewDailySeries_FillsGaps(t *testing.T) {
+	records := []DailyRecord{
+		{Date: day(2020, 1, 3), Rainfall: mm(3.0), HasData: true},
+		{Date: day(2020, 1, 1), Rainfall: mm(1.0), HasData: true},
+		{Date: day(2020, 1, 5), Rainfall: mm(0.0), HasData: false},
+	}
+
+	series := NewDailySeries(records)
+
+	if series.Len() != 5 {
+		t.Fatalf("Expected 5 days, got %d", series.Len())
+	}
+	if !series.Start.Equal(day(2020, 1, 1)) {
+		t.Errorf("Expected start 2020-01-01, got %v", series.Start)
+	}
+	expectedValid := []bool{true, false, true, false, false}
+	for i, want := range expectedValid {
+		if series.Valid[i] != want {
+			t.Errorf("Day %d: expected valid=%v, got %v", i, want, series.Valid[i])
+		}
+	}
+	if !series.Date(4).Equal(day(2020, 1, 5)) {
+		t.Errorf("Expected last date 2020-01-05, got %v", series.Date(4))
+	}
+}
+
+func TestNewDailySeries_Empty(t *testing.T) {
+	series := NewDailySeries(nil)
+	if series.Len() != 0 {
+		t.Errorf("Expected empty series, got %d days", series.Len())
+	}
+}
+
+func TestRollingTotals(t *testing.T) {
+	records := []DailyRecord{
+		{Date: day(2020, 1, 1), Rainfall: mm(1.1), HasData: true},
+		{Date: day(2020, 1, 2), Rainfall: mm(2.2), HasData: true},
+		{Date: day(2020, 1, 3), Rainfall: mm(3.3), HasData: true},
+		{Date: day(2020, 1, 4), Rainfall: mm(4.4), HasData: true},
+	}
+
+	totals := RollingTotals(NewDailySeries(records), 3, 0)
+
+	if len(totals) != 4 {
+		t.Fatalf("Expected 4 totals, got %d", len(totals))
+	}
+	if totals[0].Valid || totals[1].Valid {
+		t.Error("Expected windows starting before the series to be invalid")
+	}
+	if !totals[2].Valid || totals[2].Total != mm(6.6) {
+		t.Errorf("Expected valid total 6.6, got %.2f (valid=%v)", totals[2].Total.Float64(), totals[2].Valid)
+	}
+	if !totals[3].Valid || totals[3].Total != mm(9.9) {
+		t.Errorf("Expected valid total 9.9, got %.2f (valid=%v)", totals[3].Total.Float64(), totals[3].Valid)
+	}
+}
+
+func TestRollingTotals_Exact(t *testing.T) {
+	records := []DailyRecord{
+		{Date: day(2020, 1, 1), Rainfall: mm(0.05), HasData: true},
+		{Date: day(2020, 1, 2), Rainfall: mm(0.1), HasData: true},
+	}
+	totals := RollingTotals(NewDailySeries(records), 2, 0)
+	if totals[1].Total != mm(0.15) {
+		t.Errorf("Expected an exact total of 0.15, got %d hundredths", totals[1].Total)
+	}
+
+	var buf bytes.Buffer
+	if err := NewConverter().WriteRollingCSV(&buf, [][]RollingTotal{totals}); err != nil {
+		t.Fatalf("WriteRollingCSV failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "2020-01-02,0.2") {
+		t.Errorf("Expected 0.15 to round half away from zero, got: %s", buf.String())
+	}
+}
+
+func TestRollingTotals_MissingDays(t *testing.T) {
+	records := []DailyRecord{
+		{Date: day(2020, 1, 1), Rainfall: mm(1.0), HasData: true},
+		{Date: day(2020, 1, 2), Rainfall: mm(0.0), HasData: false},
+		{Date: day(2020, 1, 3), Rainfall: mm(3.0), HasData: true},
+		{Date: day(2020, 1, 4), Rainfall: mm(4.0), HasData: true},
+		{Date: day(2020, 1, 5), Rainfall: mm(5.0), HasData: true},
+	}
+	series := NewDailySeries(records)
+
+	strict := RollingTotals(series, 3, 0)
+	if strict[2].Valid || strict[3].Valid {
+		t.Error("Expected windows containing a missing day to be invalid")
+	}
+	if !strict[4].Valid || strict[4].Total != mm(12.0) {
+		t.Errorf("Expected valid total 12.0, got %.2f (valid=%v)", strict[4].Total.Float64(), strict[4].Valid)
+	}
+
+	lenient := RollingTotals(series, 3, 1)
+	if !lenient[2].Valid || lenient[2].Total != mm(4.0) || lenient[2].MissingDays != 1 {
+		t.Errorf("Expected total 4.0 with 1 missing day, got %v with %d (valid=%v)",
+			lenient[2].Total.Float64(), lenient[2].MissingDays, lenient[2].Valid)
+	}
+}
+
+func TestRollingTotals_InvalidWindow(t *testing.T) {
+	records := []DailyRecord{{Date: day(2020, 1, 1), Rainfall: mm(1.0), HasData: true}}
+	if totals := RollingTotals(NewDailySeries(records), 0, 0); totals != nil {
+		t.Errorf("Expected nil totals for zero window, got %v", totals)
+	}
+}
+
+func TestAnnualExtremeIndices(t *testing.T) {
+	records := []DailyRecord{
+		{Date: day(2019, 12, 30), Rainfall: mm(10.0), HasData: true},
+		{Date: day(2019, 12, 31), Rainfall: mm(20.0), HasData: true},
+		{Date: day(2020, 1, 1), Rainfall: mm(30.0), HasData: true},
+		{Date: day(2020, 1, 2), Rainfall: mm(0.0), HasData: true},
+		{Date: day(2020, 1, 3), Rainfall: mm(5.0), HasData: true},
+		{Date: day(2020, 1, 4), Rainfall: mm(0.0), HasData: true},
+	}
+
+	indices := AnnualExtremeIndices(NewDailySeries(records), 0)
+
+	if len(indices) != 2 {
+		t.Fatalf("Expected 2 years, got %d", len(indices))
+	}
+
+	y2019 := indices[0]
+	if y2019.Year != 2019 || y2019.Rx1day == nil || y2019.Rx1day.Total != mm(20.0) {
+		t.Errorf("Expected 2019 Rx1day 20.0, got %+v", y2019.Rx1day)
+	}
+	// The 3-day window starting 2019-12-30 crosses into 2020 but belongs to 2019
+	if y2019.Rx3day == nil || y2019.Rx3day.Total != mm(60.0) || !y2019.Rx3day.EndDate.Equal(day(2020, 1, 1)) {
+		t.Errorf("Expected 2019 Rx3day 60.0 ending 2020-01-01, got %+v", y2019.Rx3day)
+	}
+
+	y2020 := indices[1]
+	if y2020.Rx1day == nil || y2020.Rx1day.Total != mm(30.0) || !y2020.Rx1day.StartDate.Equal(day(2020, 1, 1)) {
+		t.Errorf("Expected 2020 Rx1day 30.0 on 2020-01-01, got %+v", y2020.Rx1day)
+	}
+	if y2020.Rx5day != nil {
+		t.Errorf("Expected no 2020 Rx5day with only 4 days of record, got %+v", y2020.Rx5day)
+	}
+}
+
+func TestAnnualExtremeIndices_Rx7day(t *testing.T) {
+	var records []DailyRecord
+	for d := 1; d <= 8; d++ {
+		records = append(records, DailyRecord{Date: day(2020, 1, d), Rainfall: mm(float64(d)), HasData: true})
+	}
+
+	indices := AnnualExtremeIndices(NewDailySeries(records), 0)
+	if len(indices) != 1 || indices[0].Rx7day == nil {
+		t.Fatalf("Expected a 2020 Rx7day, got %+v", indices)
+	}
+	rx7 := indices[0].Rx7day
+	if rx7.Days != 7 || rx7.Total != mm(35.0) || !rx7.StartDate.Equal(day(2020, 1, 2)) || !rx7.EndDate.Equal(day(2020, 1, 8)) {
+		t.Errorf("Expected Rx7day 35.0 from 2020-01-02 to 2020-01-08, got %+v", rx7)
+	}
+	if got := NewExtremesData(indices).ExtremesForYear[0].Rx7day; got == nil || got.TotalRainfall != "35.0" {
+		t.Errorf("Expected Rx7day 35.0 in the output, got %+v", got)
+	}
+}
+
+func TestNewExtremesData(t *testing.T) {
+	indices := []ExtremeIndices{
+		{
+			Year:   2020,
+			Rx1day: &MaxAccumulation{Year: 2020, Days: 1, Total: mm(30.0), StartDate: day(2020, 1, 1), EndDate: day(2020, 1, 1)},
+		},
+	}
+
+	data := NewExtremesData(indices)
+
+	if len(data.ExtremesForYear) != 1 {
+		t.Fatalf("Expected 1 year, got %d", len(data.ExtremesForYear))
+	}
+	year := data.ExtremesForYear[0]
+	if year.Year != "2020" || year.Rx1day.TotalRainfall != "30.0" || year.Rx1day.StartDate != "2020-01-01" {
+		t.Errorf("Unexpected extremes output: %+v", year.Rx1day)
+	}
+	if year.Rx3day != nil {
+		t.Errorf("Expected nil Rx3day, got %+v", year.Rx3day)
+	}
+}
@@ -0,0 +1,377 @@
+package bom
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaVersion selects the layout of the weather data JSON output
+type SchemaVersion string
+
+const (
+	// SchemaV1 serialises every value as a string and omits unavailable
+	// statistics. It is the original output.
+	SchemaV1 SchemaVersion = "v1"
+	// SchemaV2 uses JSON numbers and booleans, null for unavailable
+	// statistics, ISO 8601 months and a top-level schemaVersion. Statistics
+	// that were not computed are omitted.
+	SchemaV2 SchemaVersion = "v2"
+)
+
+// schemaV2Number is the schemaVersion written in v2 output
+const schemaV2Number = 2
+
+// ParseSchemaVersion parses a schema version such as "v2" or "2"
+func ParseSchemaVersion(value string) (SchemaVersion, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "v1", "1":
+		return SchemaV1, nil
+	case "v2", "2":
+		return SchemaV2, nil
+	}
+	return "", fmt.Errorf("invalid schema version '%s' (expected v1 or v2)", value)
+}
+
+// NewWeatherDataV2 converts weather data into the v2 output structure.
+// Numbers keep the digits of their v1 strings, so the output precision is
+// unchanged. A statistic left empty is null when it was computed and
+// omitted when it was not; the statistics computed are those listed in the
+// metadata, or the standard ones.
+func NewWeatherDataV2(data WeatherData) (WeatherDataV2, error) {
+	out := WeatherDataV2{
+		SchemaVersion:      schemaV2Number,
+		WeatherDataForYear: []WeatherDataForYearV2{},
+	}
+	metadata, err := newMetadataV2(data.Metadata)
+	if err != nil {
+		return WeatherDataV2{}, fmt.Errorf("metadata: %w", err)
+	}
+	out.Metadata = metadata
+	computed := computedStatistics(data.Metadata)
+	for _, y := range data.WeatherDataForYear {
+		year, err := newWeatherDataForYearV2(y, computed)
+		if err != nil {
+			return WeatherDataV2{}, fmt.Errorf("year %s: %w", y.Year, err)
+		}
+		out.WeatherDataForYear = append(out.WeatherDataForYear, year)
+	}
+	for _, v := range data.InterannualVariability {
+		variability, err := newCalendarMonthVariabilityV2(v)
+		if err != nil {
+			return WeatherDataV2{}, fmt.Errorf("variability of %s: %w", v.Month, err)
+		}
+		out.InterannualVariability = append(out.InterannualVariability, variability)
+	}
+	return out, nil
+}
+
+// computedStatistics returns the names of the statistics computed for
+// weather data with the given metadata
+func computedStatistics(meta *Metadata) map[string]bool {
+	names := DefaultStatistics().Names()
+	if meta != nil && meta.Statistics != nil {
+		names = meta.Statistics
+	}
+	computed := make(map[string]bool, len(names))
+	for _, name := range names {
+		computed[name] = true
+	}
+	return computed
+}
+
+func newWeatherDataForYearV2(y WeatherDataForYear, computed map[string]bool) (WeatherDataForYearV2, error) {
+	p := v2Parser{computed: computed}
+	out := WeatherDataForYearV2{
+		Year:                   p.integer("Year", y.Year),
+		FirstRecordedDate:      y.FirstRecordedDate,
+		LastRecordedDate:       y.LastRecordedDate,
+		TotalRainfall:          p.statisticNumber("TotalRainfall", y.TotalRainfall),
+		AverageDailyRainfall:   p.statisticNumber("AverageDailyRainfall", y.AverageDailyRainfall),
+		MedianDailyRainfall:    p.statisticNumber("MedianDailyRainfall", y.MedianDailyRainfall),
+		DaysWithNoRainfall:     p.statisticInteger("DaysWithNoRainfall", y.DaysWithNoRainfall),
+		DaysWithRainfall:       p.statisticInteger("DaysWithRainfall", y.DaysWithRainfall),
+		LongestDaysRaining:     p.statisticInteger("LongestDaysRaining", y.LongestDaysRaining),
+		VarianceDailyRainfall:  p.statisticNumber("VarianceDailyRainfall", y.VarianceDailyRainfall),
+		StdDevDailyRainfall:    p.statisticNumber("StdDevDailyRainfall", y.StdDevDailyRainfall),
+		CoefficientOfVariation: p.statisticNumber("CoefficientOfVariation", y.CoefficientOfVariation),
+		ExpectedDays:           p.integer("ExpectedDays", y.ExpectedDays),
+		RecordedDays:           p.integer("RecordedDays", y.RecordedDays),
+		PercentComplete:        p.number("PercentComplete", y.PercentComplete),
+		ImputedDays:            p.optionalInteger("ImputedDays", y.ImputedDays),
+		ImputedRainfall:        p.optionalNumber("ImputedRainfall", y.ImputedRainfall),
+		Unreliable:             y.Unreliable == "true",
+		AdditionalStatistics:   newAdditionalStatisticsV2(y.AdditionalStatistics),
+		MonthlyAggregates:      []WeatherDataForMonthV2{},
+	}
+	if p.err != nil {
+		return WeatherDataForYearV2{}, p.err
+	}
+	for _, m := range y.MonthlyAggregates.WeatherDataForMonth {
+		month, err := newWeatherDataForMonthV2(out.Year, m, computed)
+		if err != nil {
+			return WeatherDataForYearV2{}, fmt.Errorf("month %s: %w", m.Month, err)
+		}
+		out.MonthlyAggregates = append(out.MonthlyAggregates, month)
+	}
+	return out, nil
+}
+
+func newWeatherDataForMonthV2(year int, m WeatherDataForMonth, computed map[string]bool) (WeatherDataForMonthV2, error) {
+	month, err := parseMonth(m.Month)
+	if err != nil {
+		return WeatherDataForMonthV2{}, err
+	}
+	p := v2Parser{computed: computed}
+	out := WeatherDataForMonthV2{
+		Month:                  time.Date(year, month, 1, 0, 0, 0, 0, time.UTC).Format("2006-01"),
+		FirstRecordedDate:      m.FirstRecordedDate,
+		LastRecordedDate:       m.LastRecordedDate,
+		TotalRainfall:          p.statisticNumber("TotalRainfall", m.TotalRainfall),
+		AverageDailyRainfall:   p.statisticNumber("AverageDailyRainfall", m.AverageDailyRainfall),
+		MedianDailyRainfall:    p.statisticNumber("MedianDailyRainfall", m.MedianDailyRainfall),
+		DaysWithNoRainfall:     p.statisticInteger("DaysWithNoRainfall", m.DaysWithNoRainfall),
+		DaysWithRainfall:       p.statisticInteger("DaysWithRainfall", m.DaysWithRainfall),
+		VarianceDailyRainfall:  p.statisticNumber("VarianceDailyRainfall", m.VarianceDailyRainfall),
+		StdDevDailyRainfall:    p.statisticNumber("StdDevDailyRainfall", m.StdDevDailyRainfall),
+		CoefficientOfVariation: p.statisticNumber("CoefficientOfVariation", m.CoefficientOfVariation),
+		ExpectedDays:           p.integer("ExpectedDays", m.ExpectedDays),
+		RecordedDays:           p.integer("RecordedDays", m.RecordedDays),
+		PercentComplete:        p.number("PercentComplete", m.PercentComplete),
+		ImputedDays:            p.optionalInteger("ImputedDays", m.ImputedDays),
+		ImputedRainfall:        p.optionalNumber("ImputedRainfall", m.ImputedRainfall),
+		Unreliable:             m.Unreliable == "true",
+		AdditionalStatistics:   newAdditionalStatisticsV2(m.AdditionalStatistics),
+	}
+	if p.err != nil {
+		return WeatherDataForMonthV2{}, p.err
+	}
+	out.DailyRecords, err = newDailyValuesV2(m.DailyRecords)
+	if err != nil {
+		return WeatherDataForMonthV2{}, err
+	}
+	return out, nil
+}
+
+func newCalendarMonthVariabilityV2(v CalendarMonthVariability) (CalendarMonthVariabilityV2, error) {
+	month, err := parseMonth(v.Month)
+	if err != nil {
+		return CalendarMonthVariabilityV2{}, err
+	}
+	var p v2Parser
+	out := CalendarMonthVariabilityV2{
+		Month:                  int(month),
+		Years:                  p.integer("Years", v.Years),
+		FirstYear:              p.integer("FirstYear", v.FirstYear),
+		LastYear:               p.integer("LastYear", v.LastYear),
+		MeanTotalRainfall:      p.number("MeanTotalRainfall", v.MeanTotalRainfall),
+		StdDevTotalRainfall:    p.optionalNumber("StdDevTotalRainfall", v.StdDevTotalRainfall),
+		CoefficientOfVariation: p.optionalNumber("CoefficientOfVariation", v.CoefficientOfVariation),
+	}
+	if p.err != nil {
+		return CalendarMonthVariabilityV2{}, p.err
+	}
+	return out, nil
+}
+
+// newMetadataV2 converts metadata into the v2 schema, or returns nil for
+// nil metadata
+func newMetadataV2(meta *Metadata) (*MetadataV2, error) {
+	if meta == nil {
+		return nil, nil
+	}
+	var p v2Parser
+	out := &MetadataV2{Statistics: meta.Statistics}
+	if f := meta.Filter; f != nil {
+		out.Filter = &FilterMetadataV2{From: f.From, To: f.To}
+		for _, year := range f.Years {
+			out.Filter.Years = append(out.Filter.Years, p.integer("Filter.Years", year))
+		}
+		for _, name := range f.Months {
+			month, err := parseMonth(name)
+			if err != nil {
+				return nil, err
+			}
+			out.Filter.Months = append(out.Filter.Months, int(month))
+		}
+	}
+	if m := meta.Median; m != nil {
+		out.Median = &MedianMetadataV2{Mode: m.Mode, RainDayThreshold: p.optionalNumber("RainDayThreshold", m.RainDayThreshold)}
+	}
+	if i := meta.Imputation; i != nil {
+		out.Imputation = &ImputationMetadataV2{
+			Methods:         i.Methods,
+			NeighbourScale:  p.optionalNumber("NeighbourScale", i.NeighbourScale),
+			NeighbourDays:   p.optionalInteger("NeighbourDays", i.NeighbourDays),
+			ClimatologyDays: p.optionalInteger("ClimatologyDays", i.ClimatologyDays),
+		}
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return out, nil
+}
+
+// newAdditionalStatisticsV2 converts the results of additional statistics,
+// which may be numbers or text
+func newAdditionalStatisticsV2(values map[string]string) map[string]StatisticValueV2 {
+	if values == nil {
+		return nil
+	}
+	out := make(map[string]StatisticValueV2, len(values))
+	for name, value := range values {
+		if isFiniteNumber(value) {
+			out[name] = StatisticValueV2{Number: json.Number(value)}
+		} else {
+			out[name] = StatisticValueV2{Text: value}
+		}
+	}
+	return out
+}
+
+// isFiniteNumber reports whether a string is a finite decimal number
+func isFiniteNumber(value string) bool {
+	f, err := strconv.ParseFloat(value, 64)
+	return err == nil && !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+// MarshalJSON writes the number, or null when it is not valid
+func (n NullNumber) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.Number)
+}
+
+// UnmarshalJSON reads a number or null
+func (n *NullNumber) UnmarshalJSON(data []byte) error {
+	*n = NullNumber{}
+	if string(data) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(data, &n.Number); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// MarshalJSON writes the integer, or null when it is not valid
+func (n NullInt) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.Int)
+}
+
+// UnmarshalJSON reads an integer or null
+func (n *NullInt) UnmarshalJSON(data []byte) error {
+	*n = NullInt{}
+	if string(data) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(data, &n.Int); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// MarshalJSON writes the number, the text, or null when there is neither
+func (v StatisticValueV2) MarshalJSON() ([]byte, error) {
+	switch {
+	case v.Number != "":
+		return json.Marshal(v.Number)
+	case v.Text != "":
+		return json.Marshal(v.Text)
+	}
+	return []byte("null"), nil
+}
+
+// UnmarshalJSON reads a number, a string or null
+func (v *StatisticValueV2) UnmarshalJSON(data []byte) error {
+	*v = StatisticValueV2{}
+	switch {
+	case string(data) == "null":
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		return json.Unmarshal(data, &v.Text)
+	}
+	return json.Unmarshal(data, &v.Number)
+}
+
+// v2Parser converts v1 string values, keeping the first error so that a
+// whole structure can be converted before checking
+type v2Parser struct {
+	err error
+	// computed names the statistics that were computed
+	computed map[string]bool
+}
+
+func (p *v2Parser) fail(field, value string, err error) {
+	if p.err == nil {
+		p.err = fmt.Errorf("invalid %s '%s': %w", field, value, err)
+	}
+}
+
+func (p *v2Parser) integer(field, value string) int {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		p.fail(field, value, err)
+	}
+	return n
+}
+
+func (p *v2Parser) optionalInteger(field, value string) *int {
+	if value == "" {
+		return nil
+	}
+	n := p.integer(field, value)
+	return &n
+}
+
+func (p *v2Parser) number(field, value string) json.Number {
+	f, err := strconv.ParseFloat(value, 64)
+	if err == nil && (math.IsNaN(f) || math.IsInf(f, 0)) {
+		err = fmt.Errorf("not a finite number")
+	}
+	if err != nil {
+		p.fail(field, value, err)
+		return "0"
+	}
+	return json.Number(value)
+}
+
+func (p *v2Parser) optionalNumber(field, value string) *json.Number {
+	if value == "" {
+		return nil
+	}
+	n := p.number(field, value)
+	return &n
+}
+
+// statisticNumber converts a standard statistic. It returns nil, so that
+// the statistic is omitted, when it was not computed.
+func (p *v2Parser) statisticNumber(name, value string) *NullNumber {
+	if value == "" {
+		if !p.computed[name] {
+			return nil
+		}
+		return &NullNumber{}
+	}
+	return &NullNumber{Number: p.number(name, value), Valid: true}
+}
+
+// statisticInteger converts a standard count like statisticNumber
+func (p *v2Parser) statisticInteger(name, value string) *NullInt {
+	if value == "" {
+		if !p.computed[name] {
+			return nil
+		}
+		return &NullInt{}
+	}
+	return &NullInt{Int: p.integer(name, value), Valid: true}
+}
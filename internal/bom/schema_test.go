@@ -0,0 +1,203 @@
+package bom
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseSchemaVersion(t *testing.T) {
+	for value, want := range map[string]SchemaVersion{"v1": SchemaV1, "1": SchemaV1, "V2": SchemaV2, " 2 ": SchemaV2} {
+		got, err := ParseSchemaVersion(value)
+		if err != nil || got != want {
+			t.Errorf("ParseSchemaVersion(%q) = %q, %v; want %q", value, got, err, want)
+		}
+	}
+	if _, err := ParseSchemaVersion("v3"); err == nil {
+		t.Error("Expected error for an unknown schema version")
+	}
+}
+
+func schemaTestData() WeatherData {
+	return WeatherData{
+		WeatherDataForYear: []WeatherDataForYear{{
+			Year:                 "2019",
+			FirstRecordedDate:    "2019-01-01",
+			LastRecordedDate:     "2019-04-19",
+			TotalRainfall:        "374.2",
+			AverageDailyRainfall: "3.433027523",
+			DaysWithRainfall:     "44",
+			ExpectedDays:         "109",
+			RecordedDays:         "109",
+			PercentComplete:      "100.0",
+			AdditionalStatistics: map[string]string{"Custom": "1.50", "Empty": ""},
+			MonthlyAggregates: MonthlyAggregates{WeatherDataForMonth: []WeatherDataForMonth{{
+				Month:           "February",
+				ExpectedDays:    "28",
+				RecordedDays:    "20",
+				PercentComplete: "71.4",
+				Unreliable:      "true",
+			}}},
+		}},
+		InterannualVariability: []CalendarMonthVariability{{
+			Month:             "February",
+			Years:             "1",
+			FirstYear:         "2019",
+			LastYear:          "2019",
+			MeanTotalRainfall: "12.0",
+		}},
+		Metadata: &Metadata{Median: &MedianMetadata{Mode: "all"}},
+	}
+}
+
+func TestNewWeatherDataV2(t *testing.T) {
+	v2, err := NewWeatherDataV2(schemaTestData())
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if v2.SchemaVersion != 2 || v2.Metadata == nil {
+		t.Errorf("Unexpected root %+v", v2)
+	}
+
+	year := v2.WeatherDataForYear[0]
+	if year.Year != 2019 || year.TotalRainfall == nil || year.TotalRainfall.Number != "374.2" {
+		t.Errorf("Unexpected year %+v", year)
+	}
+	if year.DaysWithRainfall == nil || year.DaysWithRainfall.Int != 44 || year.DaysWithNoRainfall == nil || year.DaysWithNoRainfall.Valid {
+		t.Errorf("Expected 44 rain days and a null dry day count, got %v and %v", year.DaysWithRainfall, year.DaysWithNoRainfall)
+	}
+	// The dispersion statistics are not standard, so they are omitted
+	if year.VarianceDailyRainfall != nil || year.StdDevDailyRainfall != nil || year.CoefficientOfVariation != nil {
+		t.Errorf("Expected dispersion statistics to be omitted, got %+v", year)
+	}
+	if year.Unreliable || year.RecordedDays != 109 {
+		t.Errorf("Unexpected completeness %+v", year)
+	}
+	if year.AdditionalStatistics["Custom"].Number != "1.50" || year.AdditionalStatistics["Empty"] != (StatisticValueV2{}) {
+		t.Errorf("Unexpected additional statistics %v", year.AdditionalStatistics)
+	}
+	if v2.Metadata.Median == nil || v2.Metadata.Median.Mode != "all" {
+		t.Errorf("Unexpected metadata %+v", v2.Metadata)
+	}
+
+	month := year.MonthlyAggregates[0]
+	if month.Month != "2019-02" || !month.Unreliable || month.TotalRainfall == nil || month.TotalRainfall.Valid || month.PercentComplete != "71.4" {
+		t.Errorf("Unexpected month %+v", month)
+	}
+
+	variability := v2.InterannualVariability[0]
+	if variability.Month != 2 || variability.FirstYear != 2019 || variability.StdDevTotalRainfall != nil {
+		t.Errorf("Unexpected variability %+v", variability)
+	}
+}
+
+func TestNewWeatherDataV2_InvalidValue(t *testing.T) {
+	data := schemaTestData()
+	data.WeatherDataForYear[0].MonthlyAggregates.WeatherDataForMonth[0].TotalRainfall = "NaN"
+	_, err := NewWeatherDataV2(data)
+	if err == nil || !strings.Contains(err.Error(), "TotalRainfall") {
+		t.Errorf("Expected an invalid TotalRainfall error, got %v", err)
+	}
+}
+
+func TestToJSON_SchemaV2(t *testing.T) {
+	out, err := NewConverterWithOptions(ConverterOptions{Schema: SchemaV2}).ToJSON(schemaTestData())
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	for _, expected := range []string{`"schemaVersion": 2`, `"TotalRainfall": 374.2`, `"DaysWithNoRainfall": null`, `"Month": "2019-02"`, `"Unreliable": true`} {
+		if !strings.Contains(string(out), expected) {
+			t.Errorf("Expected output to contain %s, got: %s", expected, out)
+		}
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatalf("Expected valid JSON, got: %v", err)
+	}
+
+	v1, err := NewConverter().ToJSON(schemaTestData())
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if strings.Contains(string(v1), "schemaVersion") || !strings.Contains(string(v1), `"TotalRainfall": "374.2"`) {
+		t.Errorf("Expected the v1 layout by default, got: %s", v1)
+	}
+}
+
+func TestNewWeatherDataV2_Statistics(t *testing.T) {
+	data := schemaTestData()
+	data.WeatherDataForYear[0].AverageDailyRainfall = ""
+	data.WeatherDataForYear[0].DaysWithRainfall = ""
+	data.WeatherDataForYear[0].AdditionalStatistics = map[string]string{"WettestDay": "2019-03-14", "Count": "3"}
+	data.Metadata.Statistics = []string{"TotalRainfall", "WettestDay", "Count"}
+
+	v2, err := NewWeatherDataV2(data)
+	if err != nil {
+		t.Fatalf("Expected a text statistic to convert, got: %v", err)
+	}
+	year := v2.WeatherDataForYear[0]
+	if year.AdditionalStatistics["WettestDay"].Text != "2019-03-14" || year.AdditionalStatistics["Count"].Number != "3" {
+		t.Errorf("Unexpected additional statistics %+v", year.AdditionalStatistics)
+	}
+	// Only TotalRainfall was computed among the standard statistics
+	if year.TotalRainfall == nil || year.DaysWithRainfall != nil || year.AverageDailyRainfall != nil {
+		t.Errorf("Expected only TotalRainfall, got %+v", year)
+	}
+	if month := year.MonthlyAggregates[0]; month.TotalRainfall == nil || month.TotalRainfall.Valid {
+		t.Errorf("Expected a null February total, got %+v", month.TotalRainfall)
+	}
+
+	out, err := NewConverterWithOptions(ConverterOptions{Schema: SchemaV2}).ToJSON(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	for _, expected := range []string{`"WettestDay": "2019-03-14"`, `"Count": 3`, `"TotalRainfall": null`, `"Statistics": [`} {
+		if !strings.Contains(string(out), expected) {
+			t.Errorf("Expected output to contain %s, got: %s", expected, out)
+		}
+	}
+	if strings.Contains(string(out), "DaysWithRainfall") {
+		t.Errorf("Expected statistics that were not computed to be omitted, got: %s", out)
+	}
+
+	// The typed values read back
+	var decoded WeatherDataV2
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatalf("Expected v2 output to decode, got: %v", err)
+	}
+	if got := decoded.WeatherDataForYear[0]; got.TotalRainfall.Number != "374.2" || got.AdditionalStatistics["WettestDay"].Text != "2019-03-14" {
+		t.Errorf("Unexpected decoded year %+v", got)
+	}
+	if got := decoded.WeatherDataForYear[0].MonthlyAggregates[0]; got.TotalRainfall != nil && got.TotalRainfall.Valid {
+		t.Errorf("Expected no decoded February total, got %+v", got.TotalRainfall)
+	}
+}
+
+func TestNewWeatherDataV2_Metadata(t *testing.T) {
+	data := schemaTestData()
+	data.Metadata = &Metadata{
+		Filter:     &FilterMetadata{From: "2019-01-01", Years: []string{"2019"}, Months: []string{"February"}},
+		Median:     &MedianMetadata{Mode: "threshold", RainDayThreshold: "1.0"},
+		Imputation: &ImputationMetadata{Methods: []string{"neighbour", "climatology"}, NeighbourScale: "1.0500", NeighbourDays: "12", ClimatologyDays: "3"},
+	}
+	v2, err := NewWeatherDataV2(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	meta := v2.Metadata
+	if meta.Filter.From != "2019-01-01" || meta.Filter.Years[0] != 2019 || meta.Filter.Months[0] != 2 {
+		t.Errorf("Unexpected filter %+v", meta.Filter)
+	}
+	if meta.Median.RainDayThreshold == nil || *meta.Median.RainDayThreshold != "1.0" {
+		t.Errorf("Unexpected median %+v", meta.Median)
+	}
+	if i := meta.Imputation; *i.NeighbourScale != "1.0500" || *i.NeighbourDays != 12 || *i.ClimatologyDays != 3 {
+		t.Errorf("Unexpected imputation %+v", i)
+	}
+
+	data.Metadata.Imputation.NeighbourDays = "many"
+	if _, err := NewWeatherDataV2(data); err == nil || !strings.Contains(err.Error(), "NeighbourDays") {
+		t.Errorf("Expected an invalid NeighbourDays error, got %v", err)
+	}
+}
@@ -0,0 +1,317 @@
+package bom
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// maxSPI bounds SPI values, following common practice of limiting the index
+// to the range supported by the length of typical records
+const maxSPI = 3.09
+
+// SPIOptions controls the calculation of the Standardised Precipitation Index
+type SPIOptions struct {
+	// Scale is the number of months accumulated, such as 1, 3, 6 or 12
+	Scale int
+	// BaselineYears are the years whose accumulations are used to fit the
+	// distributions, identified by the year of the final month. Nil uses
+	// the whole record.
+	BaselineYears []int
+	// MinCompleteness is the minimum percentage of days in a month that
+	// must have a reading for the month to be used. An accumulation is
+	// missing if any of its months is.
+	MinCompleteness float64
+	// MinSamples is the minimum number of baseline accumulations required
+	// to fit a calendar month
+	MinSamples int
+	// Clock determines which months have finished. A nil Clock uses the
+	// system time.
+	Clock Clock
+}
+
+// DefaultSPIOptions returns the options used by the CLI by default
+func DefaultSPIOptions() SPIOptions {
+	return SPIOptions{
+		Scale:           3,
+		MinCompleteness: 90,
+		MinSamples:      10,
+		Clock:           SystemClock{},
+	}
+}
+
+// GammaDistribution is a two-parameter gamma distribution
+type GammaDistribution struct {
+	Shape float64
+	Scale float64
+}
+
+// FitGamma fits a gamma distribution to positive values by maximum
+// likelihood using Thom's approximation for the shape
+func FitGamma(values []float64) (GammaDistribution, error) {
+	if len(values) < 2 {
+		return GammaDistribution{}, fmt.Errorf("at least 2 positive values are required to fit a gamma distribution, got %d", len(values))
+	}
+	var sum, sumLog float64
+	for _, v := range values {
+		if v <= 0 {
+			return GammaDistribution{}, fmt.Errorf("gamma fit requires positive values, got %g", v)
+		}
+		sum += v
+		sumLog += math.Log(v)
+	}
+	n := float64(len(values))
+	mean := sum / n
+	a := math.Log(mean) - sumLog/n
+	if a <= 0 {
+		return GammaDistribution{}, fmt.Errorf("gamma fit requires values that are not all equal")
+	}
+	shape := (1 + math.Sqrt(1+4*a/3)) / (4 * a)
+	return GammaDistribution{Shape: shape, Scale: mean / shape}, nil
+}
+
+// CDF returns the probability of a value at or below x
+func (g GammaDistribution) CDF(x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	return regularisedGammaP(g.Shape, x/g.Scale)
+}
+
+// regularisedGammaP returns the regularised lower incomplete gamma function
+// P(a, x), by series expansion below a+1 and by continued fraction above
+func regularisedGammaP(a, x float64) float64 {
+	const (
+		maxIterations = 500
+		epsilon       = 1e-14
+		tiny          = 1e-300
+	)
+	lgamma, _ := math.Lgamma(a)
+	prefix := math.Exp(a*math.Log(x) - x - lgamma)
+
+	if x < a+1 {
+		term := 1 / a
+		sum := term
+		for n := 1; n < maxIterations; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*epsilon {
+				break
+			}
+		}
+		return sum * prefix
+	}
+
+	// Modified Lentz's method for the continued fraction of Q(a, x)
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for n := 1; n < maxIterations; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return 1 - prefix*h
+}
+
+// standardNormalQuantile returns the inverse of the standard normal CDF
+func standardNormalQuantile(p float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*p-1)
+}
+
+// SPIFit is the distribution fitted to one calendar month's accumulations
+type SPIFit struct {
+	// Month is the final month of the accumulations
+	Month time.Month
+	// Samples is the number of baseline accumulations, including zeros
+	Samples int
+	// ZeroProbability is the fraction of baseline accumulations with no rain
+	ZeroProbability float64
+	Gamma           GammaDistribution
+	// Fitted is false when there were too few samples or too few wet
+	// accumulations to fit a distribution
+	Fitted bool
+}
+
+// probability returns the cumulative probability of a total under the
+// mixed distribution. Following Stagge et al. (2015), a zero total is placed
+// at the centre of the probability mass at zero rather than at its top, so
+// that frequently dry months do not report a normal SPI when dry.
+func (f SPIFit) probability(total float64) float64 {
+	if total <= 0 {
+		return f.ZeroProbability / 2
+	}
+	return f.ZeroProbability + (1-f.ZeroProbability)*f.Gamma.CDF(total)
+}
+
+// SPIValue is the SPI of the accumulation ending in one month
+type SPIValue struct {
+	Year  int
+	Month time.Month
+	// Total is the rainfall accumulated over the scale ending in this month
+	Total Amount
+	// Complete is false when a month of the accumulation is missing, in
+	// which case Total is not set
+	Complete bool
+	// Valid is false when the accumulation is incomplete or the calendar
+	// month could not be fitted, in which case SPI is not set
+	Valid bool
+	SPI   float64
+}
+
+// SPIAnalysis holds the fitted distributions and the resulting SPI series
+type SPIAnalysis struct {
+	Fits   []SPIFit
+	Values []SPIValue
+}
+
+// AnalyseSPI computes the SPI at the configured scale for every month of the
+// record. Accumulations of each calendar month over the baseline are fitted
+// with a gamma distribution, with the probability of no rain handled
+// separately, and each accumulation is mapped through the fitted
+// distribution to a standard normal deviate.
+func AnalyseSPI(records []DailyRecord, opts SPIOptions) (SPIAnalysis, error) {
+	if opts.Scale < 1 {
+		return SPIAnalysis{}, fmt.Errorf("SPI scale must be at least 1 month, got %d", opts.Scale)
+	}
+	clock := opts.Clock
+	if clock == nil {
+		clock = SystemClock{}
+	}
+
+	accumulations := AccumulateMonths(MonthlyTotals(records, clock.Now()), opts.Scale, opts.MinCompleteness)
+	values := make([]SPIValue, len(accumulations))
+	for i, a := range accumulations {
+		values[i] = SPIValue{Year: a.Year, Month: a.Month, Total: a.Total, Complete: a.Complete}
+	}
+
+	baseline := make(map[int]bool, len(opts.BaselineYears))
+	for _, y := range opts.BaselineYears {
+		baseline[y] = true
+	}
+
+	var samples [13][]float64
+	for _, v := range values {
+		if v.Complete && (opts.BaselineYears == nil || baseline[v.Year]) {
+			samples[v.Month] = append(samples[v.Month], v.Total.Float64())
+		}
+	}
+
+	var analysis SPIAnalysis
+	var fits [13]SPIFit
+	for m := time.January; m <= time.December; m++ {
+		fits[m] = fitSPIMonth(m, samples[m], opts.MinSamples)
+		analysis.Fits = append(analysis.Fits, fits[m])
+	}
+
+	for i, v := range values {
+		fit := fits[v.Month]
+		if !v.Complete || !fit.Fitted {
+			continue
+		}
+		spi := standardNormalQuantile(fit.probability(v.Total.Float64()))
+		values[i].SPI = math.Max(-maxSPI, math.Min(maxSPI, spi))
+		values[i].Valid = true
+	}
+	analysis.Values = values
+	return analysis, nil
+}
+
+// fitSPIMonth fits the mixed zero and gamma distribution for one calendar month
+func fitSPIMonth(month time.Month, samples []float64, minSamples int) SPIFit {
+	fit := SPIFit{Month: month, Samples: len(samples)}
+	if len(samples) == 0 {
+		return fit
+	}
+	var wet []float64
+	for _, s := range samples {
+		if s > 0 {
+			wet = append(wet, s)
+		}
+	}
+	fit.ZeroProbability = float64(len(samples)-len(wet)) / float64(len(samples))
+	if len(samples) < minSamples {
+		return fit
+	}
+	gamma, err := FitGamma(wet)
+	if err != nil {
+		return fit
+	}
+	fit.Gamma = gamma
+	fit.Fitted = true
+	return fit
+}
+
+// SPICategory returns the drought or wetness category of an SPI value
+// using the classes of McKee et al. (1993)
+func SPICategory(spi float64) string {
+	switch {
+	case spi >= 2:
+		return "Extremely wet"
+	case spi >= 1.5:
+		return "Very wet"
+	case spi >= 1:
+		return "Moderately wet"
+	case spi > -1:
+		return "Near normal"
+	case spi > -1.5:
+		return "Moderately dry"
+	case spi > -2:
+		return "Severely dry"
+	default:
+		return "Extremely dry"
+	}
+}
+
+// NewSPIData converts an SPI analysis into its JSON output structure
+func NewSPIData(analysis SPIAnalysis, opts SPIOptions) SPIData {
+	data := SPIData{
+		Scale:           strconv.Itoa(opts.Scale),
+		MinCompleteness: formatFloat(opts.MinCompleteness, 1),
+	}
+	for _, y := range opts.BaselineYears {
+		data.BaselineYears = append(data.BaselineYears, strconv.Itoa(y))
+	}
+	for _, f := range analysis.Fits {
+		out := SPIFitData{
+			Month:           f.Month.String(),
+			Samples:         strconv.Itoa(f.Samples),
+			ZeroProbability: formatFloat(f.ZeroProbability, 4),
+		}
+		if f.Fitted {
+			out.Shape = formatFloat(f.Gamma.Shape, 4)
+			out.Scale = formatFloat(f.Gamma.Scale, 4)
+		}
+		data.Fits = append(data.Fits, out)
+	}
+	for _, v := range analysis.Values {
+		out := SPIForMonth{
+			Year:  strconv.Itoa(v.Year),
+			Month: v.Month.String(),
+		}
+		if v.Complete {
+			out.TotalRainfall = v.Total.Format(1)
+		}
+		if v.Valid {
+			out.SPI = formatFloat(v.SPI, 2)
+			out.Category = SPICategory(v.SPI)
+		}
+		data.SPI = append(data.SPI, out)
+	}
+	return data
+}
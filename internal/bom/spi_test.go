@@ -0,0 +1,180 @@
+package bom
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// monthlyRecords returns one record per month holding the month's total on
+// its first day, with every other day of the month recorded as dry
+func monthlyRecords(startYear int, totals []float64) []DailyRecord {
+	var records []DailyRecord
+	for i, total := range totals {
+		start := time.Date(startYear, time.January, 1, 0, 0, 0, 0, time.UTC).AddDate(0, i, 0)
+		for d := 1; d <= daysIn(start.Year(), start.Month()); d++ {
+			rainfall := 0.0
+			if d == 1 {
+				rainfall = total
+			}
+			records = append(records, DailyRecord{Date: day(start.Year(), start.Month(), d), Rainfall: mm(rainfall), HasData: true})
+		}
+	}
+	return records
+}
+
+func TestFitGamma(t *testing.T) {
+	// Values 1, 2, 3, 4: mean 2.5, A = ln(2.5) - ln(24)/4
+	fit, err := FitGamma([]float64{1, 2, 3, 4})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	a := math.Log(2.5) - math.Log(24)/4
+	shape := (1 + math.Sqrt(1+4*a/3)) / (4 * a)
+	if !approxEqual(fit.Shape, shape, 1e-12) || !approxEqual(fit.Scale, 2.5/shape, 1e-12) {
+		t.Errorf("Unexpected gamma fit %+v", fit)
+	}
+
+	for _, values := range [][]float64{{1}, {1, 0}, {2, 2, 2}} {
+		if _, err := FitGamma(values); err == nil {
+			t.Errorf("Expected error for %v", values)
+		}
+	}
+}
+
+func TestGammaDistribution_CDF(t *testing.T) {
+	// Shape 1 is the exponential distribution
+	exp := GammaDistribution{Shape: 1, Scale: 2}
+	for _, x := range []float64{0.5, 2, 10} {
+		if got, want := exp.CDF(x), 1-math.Exp(-x/2); !approxEqual(got, want, 1e-12) {
+			t.Errorf("Exponential CDF(%v) = %v, want %v", x, got, want)
+		}
+	}
+	// Shape 2, scale 1: P(2, x) = 1 - (1 + x)e^-x, on both sides of a+1
+	g := GammaDistribution{Shape: 2, Scale: 1}
+	for _, x := range []float64{1, 5} {
+		if got, want := g.CDF(x), 1-(1+x)*math.Exp(-x); !approxEqual(got, want, 1e-12) {
+			t.Errorf("Gamma CDF(%v) = %v, want %v", x, got, want)
+		}
+	}
+	if g.CDF(0) != 0 {
+		t.Error("Expected zero CDF at zero")
+	}
+}
+
+func TestStandardNormalQuantile(t *testing.T) {
+	if got := standardNormalQuantile(0.5); !approxEqual(got, 0, 1e-12) {
+		t.Errorf("Expected 0 at the median, got %v", got)
+	}
+	if got := standardNormalQuantile(0.975); !approxEqual(got, 1.959963985, 1e-8) {
+		t.Errorf("Expected 1.959963985, got %v", got)
+	}
+}
+
+func TestSPICategory(t *testing.T) {
+	tests := map[float64]string{
+		2.5:  "Extremely wet",
+		1.7:  "Very wet",
+		1.0:  "Moderately wet",
+		0:    "Near normal",
+		-1.0: "Moderately dry",
+		-1.6: "Severely dry",
+		-2.0: "Extremely dry",
+	}
+	for spi, want := range tests {
+		if got := SPICategory(spi); got != want {
+			t.Errorf("SPICategory(%v) = %s, want %s", spi, got, want)
+		}
+	}
+}
+
+func TestAnalyseSPI(t *testing.T) {
+	// Twenty years with varying totals in every month
+	var totals []float64
+	for y := 0; y < 20; y++ {
+		for m := 0; m < 12; m++ {
+			totals = append(totals, float64(20+(y*7+m*3)%50))
+		}
+	}
+	opts := DefaultSPIOptions()
+	opts.Scale = 1
+	opts.Clock = FixedClock{Time: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	analysis, err := AnalyseSPI(monthlyRecords(2000, totals), opts)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(analysis.Fits) != 12 || len(analysis.Values) != 240 {
+		t.Fatalf("Expected 12 fits and 240 values, got %d and %d", len(analysis.Fits), len(analysis.Values))
+	}
+
+	var moments RunningMoments
+	for _, v := range analysis.Values {
+		if !v.Valid {
+			t.Fatalf("Expected every month to have an SPI, got %+v", v)
+		}
+		moments.Add(v.SPI)
+	}
+	// SPI over the fitting period is approximately standard normal
+	sd, _ := moments.StdDev()
+	if math.Abs(moments.Mean()) > 0.1 || math.Abs(sd-1) > 0.1 {
+		t.Errorf("Expected SPI mean near 0 and deviation near 1, got %v and %v", moments.Mean(), sd)
+	}
+	// January 2006 is among the wettest Januaries
+	if jan := analysis.Values[12*6]; jan.Total != 6200 || jan.SPI < 1 {
+		t.Errorf("Expected a wet January 2006, got %+v", jan)
+	}
+}
+
+func TestAnalyseSPI_ScaleAndMissingMonths(t *testing.T) {
+	records := monthlyRecords(2000, []float64{10, 20, 30, 40})
+	// Remove most of February so that it fails the completeness check
+	var filtered []DailyRecord
+	for _, rec := range records {
+		if rec.Date.Month() != time.February || rec.Date.Day() < 5 {
+			filtered = append(filtered, rec)
+		}
+	}
+
+	opts := DefaultSPIOptions()
+	opts.Clock = FixedClock{Time: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)}
+	analysis, err := AnalyseSPI(filtered, opts)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	// Every 3-month accumulation before May includes January or February
+	for _, v := range analysis.Values {
+		if v.Complete {
+			t.Errorf("Expected %s %d to be incomplete", v.Month, v.Year)
+		}
+	}
+
+	opts.MinCompleteness = 0
+	analysis, _ = AnalyseSPI(filtered, opts)
+	if v := analysis.Values[2]; !v.Complete || v.Total != 6000 || v.Valid {
+		t.Errorf("Expected a complete but unfitted March accumulation of 60 mm, got %+v", v)
+	}
+
+	if _, err := AnalyseSPI(filtered, SPIOptions{Scale: 0}); err == nil {
+		t.Error("Expected error for a zero scale")
+	}
+}
+
+func TestSPIFit_ZeroProbability(t *testing.T) {
+	fit := fitSPIMonth(time.January, []float64{0, 0, 5, 10, 15, 20, 25, 30, 35, 40}, 10)
+	if !fit.Fitted || !approxEqual(fit.ZeroProbability, 0.2, 1e-12) {
+		t.Fatalf("Unexpected fit %+v", fit)
+	}
+	// A dry month sits at the centre of the probability mass at zero
+	if got := fit.probability(0); !approxEqual(got, 0.1, 1e-12) {
+		t.Errorf("Expected probability 0.1 for a dry month, got %v", got)
+	}
+	if got := fit.probability(1e6); !approxEqual(got, 1, 1e-9) {
+		t.Errorf("Expected probability near 1 for a very wet month, got %v", got)
+	}
+
+	if fit := fitSPIMonth(time.January, []float64{5, 10}, 10); fit.Fitted {
+		t.Error("Expected too few samples to prevent a fit")
+	}
+}
@@ -0,0 +1,357 @@
+package bom
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Level identifies the kind of period a Statistic can summarise.
+// Levels are bit flags so a Statistic may apply to both years and months.
+type Level int
+
+const (
+	// LevelMonth is a calendar month within a year
+	LevelMonth Level = 1 << iota
+	// LevelYear is a calendar year
+	LevelYear
+)
+
+// Period identifies the year or month a Statistic is computed over
+type Period struct {
+	Level Level
+	Year  int
+	// Month is zero for yearly periods
+	Month time.Month
+}
+
+// Statistic accumulates the daily records of one period and reports a
+// single result. Accumulate is called in date order for each day in the
+// period that has a rainfall reading, including imputed days, which have
+// Imputed set.
+type Statistic interface {
+	Name() string
+	Level() Level
+	Accumulate(rec DailyRecord)
+	// Result returns the value of the statistic formatted with the
+	// configured output precision
+	Result(p Precision) string
+}
+
+// StatisticFactory creates a fresh Statistic for a period
+type StatisticFactory func(period Period) Statistic
+
+// StatisticsRegistry is an ordered set of statistics that the Aggregator
+// computes for every year and month
+type StatisticsRegistry struct {
+	names     []string
+	factories map[string]StatisticFactory
+	// custom is set once a statistic is registered from outside the
+	// package. Custom results cannot be identified by name alone, so
+	// aggregates computed with them are not kept between runs.
+	custom bool
+}
+
+// NewStatisticsRegistry creates an empty registry
+func NewStatisticsRegistry() *StatisticsRegistry {
+	return &StatisticsRegistry{factories: make(map[string]StatisticFactory)}
+}
+
+// DefaultStatistics returns a registry with the built-in statistics that
+// make up the standard output
+func DefaultStatistics() *StatisticsRegistry {
+	r := NewStatisticsRegistry()
+	for _, s := range builtinStatistics {
+		if !s.optional {
+			r.mustRegister(s.name, s.factory)
+		}
+	}
+	return r
+}
+
+// AllStatistics returns a registry with every built-in statistic, including
+// the dispersion statistics that the standard output leaves out
+func AllStatistics() *StatisticsRegistry {
+	r := NewStatisticsRegistry()
+	for _, s := range builtinStatistics {
+		r.mustRegister(s.name, s.factory)
+	}
+	return r
+}
+
+// Register adds a statistic under the given name. Its results are reported
+// under that name, whatever the Statistic's own Name returns. Results of
+// statistics that do not correspond to a standard output field are
+// reported under AdditionalStatistics.
+func (r *StatisticsRegistry) Register(name string, factory StatisticFactory) error {
+	if err := r.register(name, factory); err != nil {
+		return err
+	}
+	r.custom = true
+	return nil
+}
+
+func (r *StatisticsRegistry) register(name string, factory StatisticFactory) error {
+	if name == "" {
+		return fmt.Errorf("statistic name must not be empty")
+	}
+	if _, exists := r.factories[name]; exists {
+		return fmt.Errorf("statistic '%s' is already registered", name)
+	}
+	r.names = append(r.names, name)
+	r.factories[name] = factory
+	return nil
+}
+
+func (r *StatisticsRegistry) mustRegister(name string, factory StatisticFactory) {
+	if err := r.register(name, factory); err != nil {
+		panic(err)
+	}
+}
+
+// Names returns the registered statistic names in registration order
+func (r *StatisticsRegistry) Names() []string {
+	names := make([]string, len(r.names))
+	copy(names, r.names)
+	return names
+}
+
+// Select returns a registry containing only the named statistics
+func (r *StatisticsRegistry) Select(names []string) (*StatisticsRegistry, error) {
+	selected := NewStatisticsRegistry()
+	selected.custom = r.custom
+	for _, name := range names {
+		factory, ok := r.factories[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown statistic '%s' (available: %s)", name, strings.Join(r.names, ", "))
+		}
+		if err := selected.register(name, factory); err != nil {
+			return nil, err
+		}
+	}
+	return selected, nil
+}
+
+// withFactory returns a copy of the registry in which the named statistic,
+// if registered, is created by factory instead
+func (r *StatisticsRegistry) withFactory(name string, factory StatisticFactory) *StatisticsRegistry {
+	replaced := NewStatisticsRegistry()
+	replaced.custom = r.custom
+	for _, n := range r.names {
+		f := r.factories[n]
+		if n == name {
+			f = factory
+		}
+		replaced.mustRegister(n, f)
+	}
+	return replaced
+}
+
+// registeredStatistic is a Statistic together with the name it was
+// registered under, which identifies its result in the output
+type registeredStatistic struct {
+	Statistic
+	name string
+}
+
+// newStatistics creates the registered statistics that apply to a period
+func (r *StatisticsRegistry) newStatistics(period Period) []registeredStatistic {
+	var stats []registeredStatistic
+	for _, name := range r.names {
+		s := r.factories[name](period)
+		if s.Level()&period.Level != 0 {
+			stats = append(stats, registeredStatistic{Statistic: s, name: name})
+		}
+	}
+	return stats
+}
+
+// statisticResults collects results into the output fields of a year or
+// month, falling back to the additional statistics map for custom names.
+// Results are keyed on the registered name, not the Statistic's own Name,
+// so a result can be found under the name it was selected by.
+func statisticResults(stats []registeredStatistic, fields map[string]*string, p Precision) map[string]string {
+	var additional map[string]string
+	for _, s := range stats {
+		if field, ok := fields[s.name]; ok {
+			*field = s.Result(p)
+			continue
+		}
+		if additional == nil {
+			additional = make(map[string]string)
+		}
+		additional[s.name] = s.Result(p)
+	}
+	return additional
+}
+
+// yearFields maps statistic names to the fields of WeatherDataForYear
+func yearFields(data *WeatherDataForYear) map[string]*string {
+	return map[string]*string{
+		"TotalRainfall":          &data.TotalRainfall,
+		"AverageDailyRainfall":   &data.AverageDailyRainfall,
+		"MedianDailyRainfall":    &data.MedianDailyRainfall,
+		"DaysWithNoRainfall":     &data.DaysWithNoRainfall,
+		"DaysWithRainfall":       &data.DaysWithRainfall,
+		"LongestDaysRaining":     &data.LongestDaysRaining,
+		"VarianceDailyRainfall":  &data.VarianceDailyRainfall,
+		"StdDevDailyRainfall":    &data.StdDevDailyRainfall,
+		"CoefficientOfVariation": &data.CoefficientOfVariation,
+	}
+}
+
+// monthFields maps statistic names to the fields of WeatherDataForMonth
+func monthFields(data *WeatherDataForMonth) map[string]*string {
+	return map[string]*string{
+		"TotalRainfall":          &data.TotalRainfall,
+		"AverageDailyRainfall":   &data.AverageDailyRainfall,
+		"MedianDailyRainfall":    &data.MedianDailyRainfall,
+		"DaysWithNoRainfall":     &data.DaysWithNoRainfall,
+		"DaysWithRainfall":       &data.DaysWithRainfall,
+		"VarianceDailyRainfall":  &data.VarianceDailyRainfall,
+		"StdDevDailyRainfall":    &data.StdDevDailyRainfall,
+		"CoefficientOfVariation": &data.CoefficientOfVariation,
+	}
+}
+
+var builtinStatistics = []struct {
+	name    string
+	factory StatisticFactory
+	// optional statistics are left out of the standard output and are only
+	// computed when selected
+	optional bool
+}{
+	{"TotalRainfall", func(Period) Statistic { return &totalRainfall{} }, false},
+	{"AverageDailyRainfall", func(Period) Statistic { return &averageDailyRainfall{} }, false},
+	{"MedianDailyRainfall", MedianDailyRainfall(MedianOptions{}), false},
+	{"DaysWithNoRainfall", func(Period) Statistic { return &daysWithNoRainfall{} }, false},
+	{"DaysWithRainfall", func(Period) Statistic { return &daysWithRainfall{} }, false},
+	{"LongestDaysRaining", func(Period) Statistic { return &longestDaysRaining{} }, false},
+	{"VarianceDailyRainfall", newDispersionStatistic("VarianceDailyRainfall", (*RunningMoments).Variance), true},
+	{"StdDevDailyRainfall", newDispersionStatistic("StdDevDailyRainfall", (*RunningMoments).StdDev), true},
+	{"CoefficientOfVariation", newDispersionStatistic("CoefficientOfVariation", (*RunningMoments).CoefficientOfVariation), true},
+}
+
+type totalRainfall struct {
+	total Amount
+}
+
+func (s *totalRainfall) Name() string               { return "TotalRainfall" }
+func (s *totalRainfall) Level() Level               { return LevelYear | LevelMonth }
+func (s *totalRainfall) Accumulate(rec DailyRecord) { s.total += rec.Rainfall }
+func (s *totalRainfall) Result(p Precision) string  { return p.FormatAmount(s.total) }
+
+type averageDailyRainfall struct {
+	total Amount
+	days  int
+}
+
+func (s *averageDailyRainfall) Name() string { return "AverageDailyRainfall" }
+func (s *averageDailyRainfall) Level() Level { return LevelYear | LevelMonth }
+
+func (s *averageDailyRainfall) Accumulate(rec DailyRecord) {
+	s.total += rec.Rainfall
+	s.days++
+}
+
+func (s *averageDailyRainfall) Result(p Precision) string {
+	return p.FormatMean(s.total, s.days)
+}
+
+// formatMedian sorts the values and formats their median exactly, or zero
+// when there are no values
+func formatMedian(values []Amount, p Precision) string {
+	if len(values) == 0 {
+		return p.FormatAmount(0)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	if len(values)%2 == 0 {
+		// Even number of values: average of two middle values
+		mid := len(values) / 2
+		return p.FormatAmountRatio(values[mid-1]+values[mid], 2)
+	}
+	// Odd number of values: middle value
+	return p.FormatAmount(values[len(values)/2])
+}
+
+type daysWithNoRainfall struct {
+	days int
+}
+
+func (s *daysWithNoRainfall) Name() string { return "DaysWithNoRainfall" }
+func (s *daysWithNoRainfall) Level() Level { return LevelYear | LevelMonth }
+
+// Imputed days are estimates, so they are not counted as dry or wet days or
+// as part of a run
+func (s *daysWithNoRainfall) Accumulate(rec DailyRecord) {
+	if rec.observed() && rec.Rainfall <= 0 {
+		s.days++
+	}
+}
+
+func (s *daysWithNoRainfall) Result(Precision) string { return strconv.Itoa(s.days) }
+
+type daysWithRainfall struct {
+	days int
+}
+
+func (s *daysWithRainfall) Name() string { return "DaysWithRainfall" }
+func (s *daysWithRainfall) Level() Level { return LevelYear | LevelMonth }
+
+func (s *daysWithRainfall) Accumulate(rec DailyRecord) {
+	if rec.observed() && rec.Rainfall > 0 {
+		s.days++
+	}
+}
+
+func (s *daysWithRainfall) Result(Precision) string { return strconv.Itoa(s.days) }
+
+// longestDaysRaining is the longest run of consecutive recorded days with
+// rainfall. Like missing days, imputed days neither extend nor end a run.
+type longestDaysRaining struct {
+	longestStreak int
+	currentStreak int
+}
+
+func (s *longestDaysRaining) Name() string { return "LongestDaysRaining" }
+func (s *longestDaysRaining) Level() Level { return LevelYear }
+
+func (s *longestDaysRaining) Accumulate(rec DailyRecord) {
+	if !rec.observed() {
+		return
+	}
+	if rec.Rainfall <= 0 {
+		s.currentStreak = 0
+		return
+	}
+	s.currentStreak++
+	if s.currentStreak > s.longestStreak {
+		s.longestStreak = s.currentStreak
+	}
+}
+
+func (s *longestDaysRaining) Result(Precision) string { return strconv.Itoa(s.longestStreak) }
+
+// MaximumDailyRainfall returns a factory for the largest daily fall in a
+// period. It is not part of the default statistics and, when registered,
+// is reported under AdditionalStatistics.
+func MaximumDailyRainfall() StatisticFactory {
+	return func(Period) Statistic { return &maximumDailyRainfall{} }
+}
+
+type maximumDailyRainfall struct {
+	max Amount
+}
+
+func (s *maximumDailyRainfall) Name() string { return "MaximumDailyRainfall" }
+func (s *maximumDailyRainfall) Level() Level { return LevelYear | LevelMonth }
+
+func (s *maximumDailyRainfall) Accumulate(rec DailyRecord) {
+	if rec.Rainfall > s.max {
+		s.max = rec.Rainfall
+	}
+}
+
+func (s *maximumDailyRainfall) Result(p Precision) string { return p.FormatAmount(s.max) }
@@ -0,0 +1,188 @@
+package bom
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// wettestDay is a custom statistic used to exercise the registry
+type wettestDay struct {
+	date     time.Time
+	rainfall Amount
+}
+
+func (s *wettestDay) Name() string { return "WettestDay" }
+func (s *wettestDay) Level() Level { return LevelYear }
+
+func (s *wettestDay) Accumulate(rec DailyRecord) {
+	if rec.Rainfall > s.rainfall {
+		s.date, s.rainfall = rec.Date, rec.Rainfall
+	}
+}
+
+func (s *wettestDay) Result(Precision) string { return s.date.Format("2006-01-02") }
+
+func TestDefaultStatistics(t *testing.T) {
+	expected := []string{
+		"TotalRainfall",
+		"AverageDailyRainfall",
+		"MedianDailyRainfall",
+		"DaysWithNoRainfall",
+		"DaysWithRainfall",
+		"LongestDaysRaining",
+	}
+	if names := DefaultStatistics().Names(); !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+
+	// The dispersion statistics are only available on request
+	expected = append(expected, "VarianceDailyRainfall", "StdDevDailyRainfall", "CoefficientOfVariation")
+	if names := AllStatistics().Names(); !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+}
+
+func TestStatisticsRegistry_Register(t *testing.T) {
+	r := NewStatisticsRegistry()
+	factory := func(Period) Statistic { return &wettestDay{} }
+
+	if err := r.Register("WettestDay", factory); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := r.Register("WettestDay", factory); err == nil {
+		t.Error("Expected error for duplicate registration")
+	}
+	if err := r.Register("", factory); err == nil {
+		t.Error("Expected error for empty name")
+	}
+}
+
+func TestStatisticsRegistry_Select(t *testing.T) {
+	selected, err := DefaultStatistics().Select([]string{"DaysWithRainfall", "TotalRainfall"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if names := selected.Names(); !reflect.DeepEqual(names, []string{"DaysWithRainfall", "TotalRainfall"}) {
+		t.Errorf("Unexpected selection %v", names)
+	}
+
+	if _, err := DefaultStatistics().Select([]string{"Humidity"}); err == nil {
+		t.Error("Expected error for unknown statistic")
+	}
+}
+
+func TestStatisticsRegistry_LevelFiltering(t *testing.T) {
+	r := DefaultStatistics()
+
+	yearStats := r.newStatistics(Period{Level: LevelYear, Year: 2020})
+	monthStats := r.newStatistics(Period{Level: LevelMonth, Year: 2020, Month: time.January})
+
+	has := func(stats []registeredStatistic, name string) bool {
+		for _, s := range stats {
+			if s.name == name {
+				return true
+			}
+		}
+		return false
+	}
+	if !has(yearStats, "MedianDailyRainfall") || !has(yearStats, "LongestDaysRaining") {
+		t.Error("Unexpected year-level statistics")
+	}
+	if !has(monthStats, "MedianDailyRainfall") || has(monthStats, "LongestDaysRaining") {
+		t.Error("Unexpected month-level statistics")
+	}
+}
+
+func TestAggregate_SelectedStatistics(t *testing.T) {
+	selected, err := DefaultStatistics().Select([]string{"TotalRainfall"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	options := DefaultAggregatorOptions()
+	options.Statistics = selected
+	agg := NewAggregatorWithOptions(options)
+
+	records := []DailyRecord{
+		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(10.0), HasData: true},
+		{Date: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Rainfall: mm(0.0), HasData: true},
+	}
+	yearData := agg.Aggregate(records).WeatherDataForYear[0]
+	jan := yearData.MonthlyAggregates.WeatherDataForMonth[0]
+
+	if yearData.TotalRainfall != "10.000000000000" || jan.TotalRainfall != "10.000000000000" {
+		t.Errorf("Expected selected total to be computed, got %q and %q", yearData.TotalRainfall, jan.TotalRainfall)
+	}
+	if yearData.DaysWithRainfall != "" || yearData.LongestDaysRaining != "" || jan.MedianDailyRainfall != "" {
+		t.Error("Expected unselected statistics to be left empty")
+	}
+	if yearData.RecordedDays != "2" {
+		t.Errorf("Expected completeness to be reported regardless of selection, got %q", yearData.RecordedDays)
+	}
+}
+
+func TestAggregate_CustomStatistic(t *testing.T) {
+	registry := DefaultStatistics()
+	if err := registry.Register("WettestDay", func(Period) Statistic { return &wettestDay{} }); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	registry.Register("MonthNumber", func(p Period) Statistic {
+		return &periodStatistic{result: strconv.Itoa(int(p.Month))}
+	})
+	options := DefaultAggregatorOptions()
+	options.Statistics = registry
+	agg := NewAggregatorWithOptions(options)
+
+	records := []DailyRecord{
+		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(10.0), HasData: true},
+		{Date: time.Date(2020, 3, 2, 0, 0, 0, 0, time.UTC), Rainfall: mm(25.0), HasData: true},
+	}
+	yearData := agg.Aggregate(records).WeatherDataForYear[0]
+
+	if yearData.AdditionalStatistics["WettestDay"] != "2020-03-02" {
+		t.Errorf("Expected custom year statistic, got %v", yearData.AdditionalStatistics)
+	}
+	if yearData.TotalRainfall != "35.000000000000" {
+		t.Errorf("Expected built-in statistics alongside custom ones, got %q", yearData.TotalRainfall)
+	}
+	march := yearData.MonthlyAggregates.WeatherDataForMonth[1]
+	if march.AdditionalStatistics["MonthNumber"] != "3" || march.AdditionalStatistics["WettestDay"] != "" {
+		t.Errorf("Expected only month-level custom statistics on months, got %v", march.AdditionalStatistics)
+	}
+}
+
+func TestAggregate_CustomStatisticKeyedOnRegisteredName(t *testing.T) {
+	registry := DefaultStatistics()
+	// wettestDay names itself WettestDay
+	if err := registry.Register("PeakDay", func(Period) Statistic { return &wettestDay{} }); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	selected, err := registry.Select([]string{"PeakDay"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	options := DefaultAggregatorOptions()
+	options.Statistics = selected
+	agg := NewAggregatorWithOptions(options)
+
+	records := []DailyRecord{
+		{Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Rainfall: mm(10.0), HasData: true},
+	}
+	yearData := agg.Aggregate(records).WeatherDataForYear[0]
+
+	expected := map[string]string{"PeakDay": "2020-01-01"}
+	if !reflect.DeepEqual(yearData.AdditionalStatistics, expected) {
+		t.Errorf("Expected %v, got %v", expected, yearData.AdditionalStatistics)
+	}
+}
+
+// periodStatistic reports a value derived from its period
+type periodStatistic struct {
+	result string
+}
+
+func (s *periodStatistic) Name() string            { return "MonthNumber" }
+func (s *periodStatistic) Level() Level            { return LevelMonth }
+func (s *periodStatistic) Accumulate(DailyRecord)  {}
+func (s *periodStatistic) Result(Precision) string { return s.result }
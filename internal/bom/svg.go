@@ -0,0 +1,586 @@
+package bom
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChartStyle sets the size, colours and font of an SVG chart. Colours are
+// any SVG paint value, such as "#1f77b4" or "steelblue".
+type ChartStyle struct {
+	Width           int
+	Height          int
+	BarColour       string
+	MutedColour     string
+	ReferenceColour string
+	AxisColour      string
+	GridColour      string
+	TextColour      string
+	FontFamily      string
+	FontSize        int
+}
+
+// DefaultChartStyle returns the style used by reports
+func DefaultChartStyle() ChartStyle {
+	return ChartStyle{
+		Width:           800,
+		Height:          320,
+		BarColour:       "#2b6cb0",
+		MutedColour:     "#a0aec0",
+		ReferenceColour: "#c05621",
+		AxisColour:      "#4a5568",
+		GridColour:      "#e2e8f0",
+		TextColour:      "#1a202c",
+		FontFamily:      "sans-serif",
+		FontSize:        12,
+	}
+}
+
+// Bar is one bar of a BarChart
+type Bar struct {
+	Label string
+	Value float64
+	// Muted draws the bar in the muted colour, e.g. for an incomplete year
+	Muted bool
+}
+
+// ChartLabels are the title and axis labels of a chart. Empty labels are
+// not drawn.
+type ChartLabels struct {
+	Title  string
+	XLabel string
+	YLabel string
+}
+
+// override replaces each label that is set in other
+func (l *ChartLabels) override(other ChartLabels) {
+	if other.Title != "" {
+		l.Title = other.Title
+	}
+	if other.XLabel != "" {
+		l.XLabel = other.XLabel
+	}
+	if other.YLabel != "" {
+		l.YLabel = other.YLabel
+	}
+}
+
+// BarChart is a vertical bar chart rendered as SVG. Negative values are
+// drawn as empty bars.
+type BarChart struct {
+	ChartLabels
+	Bars []Bar
+	// ReferenceLabel, when set, draws a dashed horizontal line at Reference,
+	// such as a long-term mean
+	Reference      float64
+	ReferenceLabel string
+	// YMax sets the top of the y axis. Zero fits the axis to the bars.
+	YMax float64
+}
+
+// chartTicks is the approximate number of y axis intervals
+const chartTicks = 5
+
+// WriteSVG writes the chart as a standalone SVG element
+func (c BarChart) WriteSVG(w io.Writer, style ChartStyle) error {
+	var buf bytes.Buffer
+	plot := newChartFrame(c.ChartLabels, style)
+
+	highest := c.YMax
+	if highest <= 0 {
+		for _, bar := range c.Bars {
+			highest = math.Max(highest, bar.Value)
+		}
+		if c.ReferenceLabel != "" {
+			highest = math.Max(highest, c.Reference)
+		}
+	}
+	axis := newLinearAxis(highest, chartTicks)
+
+	plot.open(&buf)
+	plot.yAxis(&buf, axis)
+
+	slot := plot.width() / float64(max(len(c.Bars), 1))
+	every := labelSpacing(c.Bars, slot, style.FontSize)
+	for i, bar := range c.Bars {
+		x := plot.left + float64(i)*slot
+		height := plot.height() * math.Min(math.Max(bar.Value, 0), axis.top) / axis.top
+		colour := style.BarColour
+		if bar.Muted {
+			colour = style.MutedColour
+		}
+		fmt.Fprintf(&buf, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"><title>%s: %s</title></rect>`+"\n",
+			svgNumber(x+slot*0.1), svgNumber(plot.bottom()-height), svgNumber(slot*0.8), svgNumber(height),
+			escapeXML(colour), escapeXML(bar.Label), chartValue(bar.Value))
+		if i%every == 0 {
+			fmt.Fprintf(&buf, `<text x="%s" y="%s" text-anchor="middle">%s</text>`+"\n",
+				svgNumber(x+slot/2), svgNumber(plot.bottom()+float64(style.FontSize)+4), escapeXML(bar.Label))
+		}
+	}
+
+	if c.ReferenceLabel != "" && c.Reference <= axis.top {
+		y := plot.bottom() - plot.height()*c.Reference/axis.top
+		fmt.Fprintf(&buf, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s" stroke-width="2" stroke-dasharray="6 4"/>`+"\n",
+			svgNumber(plot.left), svgNumber(y), svgNumber(plot.right()), svgNumber(y), escapeXML(style.ReferenceColour))
+		fmt.Fprintf(&buf, `<text x="%s" y="%s" text-anchor="end" fill="%s">%s</text>`+"\n",
+			svgNumber(plot.right()), svgNumber(y-4), escapeXML(style.ReferenceColour), escapeXML(c.ReferenceLabel))
+	}
+
+	plot.close(&buf)
+	return writeSVG(w, buf.Bytes())
+}
+
+// SVG returns the chart as a standalone SVG element
+func (c BarChart) SVG(style ChartStyle) string {
+	var sb strings.Builder
+	c.WriteSVG(&sb, style) // a strings.Builder does not fail
+	return sb.String()
+}
+
+// labelSpacing returns how often bars are labelled so that x axis labels
+// do not overlap
+func labelSpacing(bars []Bar, slot float64, fontSize int) int {
+	longest := 0
+	for _, bar := range bars {
+		longest = max(longest, len(bar.Label))
+	}
+	// Assume an average glyph is 0.6 em wide, plus some padding
+	width := float64(longest)*float64(fontSize)*0.6 + 6
+	return max(int(math.Ceil(width/slot)), 1)
+}
+
+// chartFrame lays out the plot area of a chart inside its margins
+type chartFrame struct {
+	ChartLabels
+	style                  ChartStyle
+	left, top, marginRight float64
+	marginBottom           float64
+}
+
+func newChartFrame(labels ChartLabels, style ChartStyle) chartFrame {
+	font := float64(style.FontSize)
+	f := chartFrame{ChartLabels: labels, style: style}
+	f.left = font * 4.5
+	if labels.YLabel != "" {
+		f.left += font * 1.5
+	}
+	f.top = font
+	if labels.Title != "" {
+		f.top += font * 2
+	}
+	f.marginRight = font
+	f.marginBottom = font * 2.5
+	if labels.XLabel != "" {
+		f.marginBottom += font * 1.5
+	}
+	return f
+}
+
+func (f chartFrame) right() float64  { return float64(f.style.Width) - f.marginRight }
+func (f chartFrame) bottom() float64 { return float64(f.style.Height) - f.marginBottom }
+func (f chartFrame) width() float64  { return math.Max(f.right()-f.left, 1) }
+func (f chartFrame) height() float64 { return math.Max(f.bottom()-f.top, 1) }
+
+// open writes the svg element, title and axis labels
+func (f chartFrame) open(buf *bytes.Buffer) {
+	s := f.style
+	fmt.Fprintf(buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" role="img" aria-label="%s" font-family="%s" font-size="%d" fill="%s">`+"\n",
+		s.Width, s.Height, s.Width, s.Height, escapeXML(f.Title), escapeXML(s.FontFamily), s.FontSize, escapeXML(s.TextColour))
+	if f.Title != "" {
+		fmt.Fprintf(buf, `<text x="%s" y="%s" text-anchor="middle" font-size="%s" font-weight="bold">%s</text>`+"\n",
+			svgNumber(float64(s.Width)/2), svgNumber(float64(s.FontSize)*1.75), svgNumber(float64(s.FontSize)*1.25), escapeXML(f.Title))
+	}
+	if f.XLabel != "" {
+		fmt.Fprintf(buf, `<text x="%s" y="%s" text-anchor="middle">%s</text>`+"\n",
+			svgNumber(f.left+f.width()/2), svgNumber(float64(s.Height)-float64(s.FontSize)*0.5), escapeXML(f.XLabel))
+	}
+	if f.YLabel != "" {
+		x, y := float64(s.FontSize)*1.25, f.top+f.height()/2
+		fmt.Fprintf(buf, `<text x="%s" y="%s" text-anchor="middle" transform="rotate(-90 %s %s)">%s</text>`+"\n",
+			svgNumber(x), svgNumber(y), svgNumber(x), svgNumber(y), escapeXML(f.YLabel))
+	}
+}
+
+// yAxis writes the gridlines and labels of a linear y axis
+func (f chartFrame) yAxis(buf *bytes.Buffer, axis linearAxis) {
+	for i := 0; i <= axis.intervals(); i++ {
+		value := float64(i) * axis.step
+		y := f.bottom() - f.height()*value/axis.top
+		fmt.Fprintf(buf, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s"/>`+"\n",
+			svgNumber(f.left), svgNumber(y), svgNumber(f.right()), svgNumber(y), escapeXML(f.style.GridColour))
+		fmt.Fprintf(buf, `<text x="%s" y="%s" text-anchor="end">%s</text>`+"\n",
+			svgNumber(f.left-6), svgNumber(y+float64(f.style.FontSize)/3), axis.format(value))
+	}
+}
+
+// close writes the axis lines and ends the svg element
+func (f chartFrame) close(buf *bytes.Buffer) {
+	fmt.Fprintf(buf, `<polyline points="%s,%s %s,%s %s,%s" fill="none" stroke="%s"/>`+"\n",
+		svgNumber(f.left), svgNumber(f.top), svgNumber(f.left), svgNumber(f.bottom()),
+		svgNumber(f.right()), svgNumber(f.bottom()), escapeXML(f.style.AxisColour))
+	f.end(buf)
+}
+
+// end ends the svg element
+func (f chartFrame) end(buf *bytes.Buffer) {
+	buf.WriteString("</svg>\n")
+}
+
+// linearAxis is a y axis from zero with evenly spaced round ticks
+type linearAxis struct {
+	step     float64
+	top      float64
+	decimals int
+}
+
+// newLinearAxis picks a step of 1, 2 or 5 times a power of ten that divides
+// zero to highest into about the given number of intervals
+func newLinearAxis(highest float64, intervals int) linearAxis {
+	if highest <= 0 || math.IsNaN(highest) || math.IsInf(highest, 0) {
+		return linearAxis{step: 1, top: 1}
+	}
+	rough := highest / float64(intervals)
+	magnitude := math.Pow(10, math.Floor(math.Log10(rough)))
+	step := magnitude
+	for _, m := range []float64{2, 5, 10} {
+		if step >= rough {
+			break
+		}
+		step = magnitude * m
+	}
+	return linearAxis{
+		step:     step,
+		top:      math.Ceil(highest/step) * step,
+		decimals: max(0, -int(math.Floor(math.Log10(step)))),
+	}
+}
+
+func (a linearAxis) intervals() int {
+	return int(math.Round(a.top / a.step))
+}
+
+// format formats a value with the decimal places of the step
+func (a linearAxis) format(value float64) string {
+	return strconv.FormatFloat(value, 'f', a.decimals, 64)
+}
+
+// svgNumber formats a coordinate to one decimal place
+func svgNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', 1, 64)
+}
+
+// escapeXML escapes text for use in SVG content and attribute values
+func escapeXML(s string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(s)) // a strings.Builder does not fail
+	return sb.String()
+}
+
+// Box summarises the distribution of a set of values
+type Box struct {
+	Label  string
+	Count  int
+	Min    float64
+	Q1     float64
+	Median float64
+	Q3     float64
+	Max    float64
+}
+
+// NewBox summarises values by their quartiles and extremes. No values give
+// a box with a Count of zero, which is not drawn.
+func NewBox(label string, values []float64) Box {
+	box := Box{Label: label, Count: len(values)}
+	if len(values) == 0 {
+		return box
+	}
+	box.Min = percentile(values, 0)
+	box.Q1 = percentile(values, 0.25)
+	box.Median = percentile(values, 0.5)
+	box.Q3 = percentile(values, 0.75)
+	box.Max = percentile(values, 1)
+	return box
+}
+
+// BoxPlot draws a box from the lower to the upper quartile of each set of
+// values, with a line at the median and whiskers to the minimum and maximum
+type BoxPlot struct {
+	ChartLabels
+	Boxes []Box
+	// YMax sets the top of the y axis. Zero fits the axis to the boxes.
+	YMax float64
+}
+
+// WriteSVG writes the chart as a standalone SVG element
+func (c BoxPlot) WriteSVG(w io.Writer, style ChartStyle) error {
+	var buf bytes.Buffer
+	plot := newChartFrame(c.ChartLabels, style)
+
+	highest := c.YMax
+	if highest <= 0 {
+		for _, box := range c.Boxes {
+			highest = math.Max(highest, box.Max)
+		}
+	}
+	axis := newLinearAxis(highest, chartTicks)
+	y := func(v float64) float64 {
+		return plot.bottom() - plot.height()*math.Min(math.Max(v, 0), axis.top)/axis.top
+	}
+
+	plot.open(&buf)
+	plot.yAxis(&buf, axis)
+
+	slot := plot.width() / float64(max(len(c.Boxes), 1))
+	for i, box := range c.Boxes {
+		centre := plot.left + (float64(i)+0.5)*slot
+		fmt.Fprintf(&buf, `<text x="%s" y="%s" text-anchor="middle">%s</text>`+"\n",
+			svgNumber(centre), svgNumber(plot.bottom()+float64(style.FontSize)+4), escapeXML(box.Label))
+		if box.Count == 0 {
+			continue
+		}
+		half, whisker := slot*0.3, slot*0.15
+		fmt.Fprintf(&buf, `<g><title>%s: median %s, quartiles %s to %s, range %s to %s (%d values)</title>`+"\n",
+			escapeXML(box.Label), chartValue(box.Median), chartValue(box.Q1), chartValue(box.Q3),
+			chartValue(box.Min), chartValue(box.Max), box.Count)
+		fmt.Fprintf(&buf, `<path d="M%s %sV%sM%s %sV%sM%s %sH%sM%s %sH%s" stroke="%s" fill="none"/>`+"\n",
+			svgNumber(centre), svgNumber(y(box.Max)), svgNumber(y(box.Q3)),
+			svgNumber(centre), svgNumber(y(box.Q1)), svgNumber(y(box.Min)),
+			svgNumber(centre-whisker), svgNumber(y(box.Max)), svgNumber(centre+whisker),
+			svgNumber(centre-whisker), svgNumber(y(box.Min)), svgNumber(centre+whisker),
+			escapeXML(style.AxisColour))
+		fmt.Fprintf(&buf, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s" fill-opacity="0.35" stroke="%s"/>`+"\n",
+			svgNumber(centre-half), svgNumber(y(box.Q3)), svgNumber(2*half), svgNumber(y(box.Q1)-y(box.Q3)),
+			escapeXML(style.BarColour), escapeXML(style.BarColour))
+		fmt.Fprintf(&buf, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s" stroke-width="2"/>`+"\n</g>\n",
+			svgNumber(centre-half), svgNumber(y(box.Median)), svgNumber(centre+half), svgNumber(y(box.Median)),
+			escapeXML(style.ReferenceColour))
+	}
+
+	plot.close(&buf)
+	return writeSVG(w, buf.Bytes())
+}
+
+// Point is one point of a line series
+type Point struct {
+	X float64
+	Y float64
+}
+
+// Series is one line of a LineChart
+type Series struct {
+	Label  string
+	Points []Point
+	// Reference draws the line dashed in the reference colour, such as a
+	// long-term average
+	Reference bool
+}
+
+// Tick is a labelled position on an axis
+type Tick struct {
+	Value float64
+	Label string
+}
+
+// LineChart draws series of points against a linear x axis from XMin to
+// XMax, with a legend naming each series
+type LineChart struct {
+	ChartLabels
+	Series []Series
+	XMin   float64
+	XMax   float64
+	XTicks []Tick
+	// YMax sets the top of the y axis. Zero fits the axis to the points.
+	YMax float64
+}
+
+// WriteSVG writes the chart as a standalone SVG element
+func (c LineChart) WriteSVG(w io.Writer, style ChartStyle) error {
+	var buf bytes.Buffer
+	plot := newChartFrame(c.ChartLabels, style)
+
+	highest := c.YMax
+	if highest <= 0 {
+		for _, series := range c.Series {
+			for _, p := range series.Points {
+				highest = math.Max(highest, p.Y)
+			}
+		}
+	}
+	axis := newLinearAxis(highest, chartTicks)
+	span := c.XMax - c.XMin
+	if span <= 0 {
+		span = 1
+	}
+	x := func(v float64) float64 { return plot.left + plot.width()*(v-c.XMin)/span }
+	y := func(v float64) float64 {
+		return plot.bottom() - plot.height()*math.Min(math.Max(v, 0), axis.top)/axis.top
+	}
+
+	plot.open(&buf)
+	plot.yAxis(&buf, axis)
+	for _, tick := range c.XTicks {
+		fmt.Fprintf(&buf, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s"/>`+"\n",
+			svgNumber(x(tick.Value)), svgNumber(plot.bottom()), svgNumber(x(tick.Value)), svgNumber(plot.bottom()+4),
+			escapeXML(style.AxisColour))
+		fmt.Fprintf(&buf, `<text x="%s" y="%s" text-anchor="start">%s</text>`+"\n",
+			svgNumber(x(tick.Value)+2), svgNumber(plot.bottom()+float64(style.FontSize)+4), escapeXML(tick.Label))
+	}
+
+	font := float64(style.FontSize)
+	for i, series := range c.Series {
+		colour, dash := style.BarColour, ""
+		if series.Reference {
+			colour, dash = style.ReferenceColour, ` stroke-dasharray="6 4"`
+		}
+		var points []string
+		for _, p := range series.Points {
+			points = append(points, svgNumber(x(p.X))+","+svgNumber(y(p.Y)))
+		}
+		fmt.Fprintf(&buf, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"%s><title>%s</title></polyline>`+"\n",
+			strings.Join(points, " "), escapeXML(colour), dash, escapeXML(series.Label))
+
+		legendY := plot.top + font*(1.5*float64(i)+1)
+		fmt.Fprintf(&buf, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s" stroke-width="2"%s/>`+"\n",
+			svgNumber(plot.left+font), svgNumber(legendY), svgNumber(plot.left+font*3), svgNumber(legendY), escapeXML(colour), dash)
+		fmt.Fprintf(&buf, `<text x="%s" y="%s">%s</text>`+"\n",
+			svgNumber(plot.left+font*3.5), svgNumber(legendY+font/3), escapeXML(series.Label))
+	}
+
+	plot.close(&buf)
+	return writeSVG(w, buf.Bytes())
+}
+
+// HeatmapDay is one day of a CalendarHeatmap. Valid is false for a day
+// without a reading.
+type HeatmapDay struct {
+	Date  time.Time
+	Value float64
+	Valid bool
+}
+
+// CalendarHeatmap draws a year of daily values as a grid with a column per
+// week and a row per weekday, starting on Monday. Cells are shaded by
+// value; days without a reading are drawn in the muted colour and dry days
+// in the grid colour.
+type CalendarHeatmap struct {
+	ChartLabels
+	Year int
+	Days []HeatmapDay
+	// Max is the value drawn at full colour, and higher values are drawn
+	// the same. Zero uses the highest value.
+	Max float64
+	// Unit follows values in cell titles and the legend
+	Unit string
+}
+
+// heatmapLegendSteps is the number of shaded swatches in the legend
+const heatmapLegendSteps = 4
+
+// WriteSVG writes the chart as a standalone SVG element
+func (c CalendarHeatmap) WriteSVG(w io.Writer, style ChartStyle) error {
+	var buf bytes.Buffer
+	plot := newChartFrame(c.ChartLabels, style)
+	font := float64(style.FontSize)
+
+	highest := c.Max
+	days := make(map[int]HeatmapDay, len(c.Days))
+	for _, d := range c.Days {
+		if d.Date.Year() != c.Year {
+			continue
+		}
+		days[d.Date.YearDay()] = d
+		if c.Max <= 0 && d.Valid {
+			highest = math.Max(highest, d.Value)
+		}
+	}
+	shade := func(v float64) string {
+		if highest <= 0 {
+			return "1.00"
+		}
+		return strconv.FormatFloat(0.15+0.85*math.Sqrt(math.Min(v/highest, 1)), 'f', 2, 64)
+	}
+
+	jan1 := time.Date(c.Year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(jan1.Weekday()) + 6) % 7
+	n := daysInYear(c.Year)
+	weeks := (n + offset + 6) / 7
+
+	left := plot.left - font*2
+	top := plot.top + font*1.5
+	legend := font * 2.5
+	cell := math.Min((plot.right()-left)/float64(weeks), (plot.bottom()-top-legend)/7)
+	cell = math.Max(cell, 1)
+
+	plot.open(&buf)
+	for i, name := range []string{"Mon", "Wed", "Fri"} {
+		fmt.Fprintf(&buf, `<text x="%s" y="%s" text-anchor="end">%s</text>`+"\n",
+			svgNumber(left-4), svgNumber(top+cell*float64(2*i)+cell*0.75), name)
+	}
+	for m := time.January; m <= time.December; m++ {
+		week := (time.Date(c.Year, m, 1, 0, 0, 0, 0, time.UTC).YearDay() - 1 + offset) / 7
+		fmt.Fprintf(&buf, `<text x="%s" y="%s">%s</text>`+"\n",
+			svgNumber(left+cell*float64(week)), svgNumber(top-4), m.String()[:3])
+	}
+
+	for i := 0; i < n; i++ {
+		date := jan1.AddDate(0, 0, i)
+		col, row := (i+offset)/7, (i+offset)%7
+		fill, opacity, label := style.MutedColour, "1.00", "no reading"
+		if d, ok := days[i+1]; ok && d.Valid {
+			label = chartValue(d.Value) + c.unit()
+			fill = style.GridColour
+			if d.Value > 0 {
+				fill, opacity = style.BarColour, shade(d.Value)
+			}
+		}
+		fmt.Fprintf(&buf, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s" fill-opacity="%s"><title>%s: %s</title></rect>`+"\n",
+			svgNumber(left+cell*float64(col)+1), svgNumber(top+cell*float64(row)+1), svgNumber(cell-2), svgNumber(cell-2),
+			escapeXML(fill), opacity, date.Format("2006-01-02"), escapeXML(label))
+	}
+
+	// Legend: no reading, dry, then shades up to the maximum
+	legendY := top + cell*7 + font
+	x := left
+	swatch := func(fill, opacity, text string) {
+		fmt.Fprintf(&buf, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s" fill-opacity="%s"/>`+"\n",
+			svgNumber(x), svgNumber(legendY), svgNumber(font), svgNumber(font), escapeXML(fill), opacity)
+		fmt.Fprintf(&buf, `<text x="%s" y="%s">%s</text>`+"\n", svgNumber(x+font*1.25), svgNumber(legendY+font*0.85), escapeXML(text))
+		x += font*2 + float64(len(text))*font*0.6
+	}
+	swatch(style.MutedColour, "1.00", "No reading")
+	swatch(style.GridColour, "1.00", "0"+c.unit())
+	for i := 1; i <= heatmapLegendSteps; i++ {
+		v := highest * float64(i) / heatmapLegendSteps
+		swatch(style.BarColour, shade(v), chartValue(math.Round(v*10)/10)+c.unit())
+	}
+
+	plot.end(&buf)
+	return writeSVG(w, buf.Bytes())
+}
+
+// unit returns the unit to follow a value, with a separating space
+func (c CalendarHeatmap) unit() string {
+	if c.Unit == "" {
+		return ""
+	}
+	return " " + c.Unit
+}
+
+// chartValue formats a value for a title or legend
+func chartValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// writeSVG writes a rendered chart
+func writeSVG(w io.Writer, svg []byte) error {
+	if _, err := w.Write(svg); err != nil {
+		return fmt.Errorf("failed to write SVG: %w", err)
+	}
+	return nil
+}
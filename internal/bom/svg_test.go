@@ -0,0 +1,267 @@
+package bom
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestNewLinearAxis(t *testing.T) {
+	tests := []struct {
+		highest  float64
+		step     float64
+		top      float64
+		decimals int
+	}{
+		{2194.0, 500, 2500, 0},
+		{119.3, 50, 150, 0},
+		{4.2, 1, 5, 0},
+		{0.9, 0.2, 1.0, 1},
+		{0, 1, 1, 0},
+	}
+	for _, tt := range tests {
+		axis := newLinearAxis(tt.highest, chartTicks)
+		if !approxEqual(axis.step, tt.step, 1e-9) || !approxEqual(axis.top, tt.top, 1e-9) || axis.decimals != tt.decimals {
+			t.Errorf("newLinearAxis(%g): expected step %g, top %g and %d decimals, got %+v", tt.highest, tt.step, tt.top, tt.decimals, axis)
+		}
+	}
+}
+
+func TestBarChart_SVG(t *testing.T) {
+	chart := BarChart{
+		ChartLabels: ChartLabels{Title: `Rain & "storms"`, YLabel: "Rainfall (mm)"},
+		Bars: []Bar{
+			{Label: "2018", Value: 100},
+			{Label: "2019", Value: 374.2},
+			{Label: "<2020>", Value: 50, Muted: true},
+		},
+		Reference:      200,
+		ReferenceLabel: "Mean 200.0 mm",
+	}
+	style := DefaultChartStyle()
+	svg := chart.SVG(style)
+
+	var doc struct {
+		XMLName xml.Name `xml:"svg"`
+		Rects   []struct {
+			Fill   string `xml:"fill,attr"`
+			Height string `xml:"height,attr"`
+			Title  string `xml:"title"`
+		} `xml:"rect"`
+		Texts []string `xml:"text"`
+	}
+	if err := xml.Unmarshal([]byte(svg), &doc); err != nil {
+		t.Fatalf("Chart is not valid XML: %v\n%s", err, svg)
+	}
+	if len(doc.Rects) != 3 {
+		t.Fatalf("Expected 3 bars, got %d", len(doc.Rects))
+	}
+	if doc.Rects[1].Title != "2019: 374.2" || doc.Rects[2].Title != "<2020>: 50" {
+		t.Errorf("Unexpected bar titles %q and %q", doc.Rects[1].Title, doc.Rects[2].Title)
+	}
+	if doc.Rects[0].Fill != style.BarColour || doc.Rects[2].Fill != style.MutedColour {
+		t.Errorf("Expected bar and muted colours, got %q and %q", doc.Rects[0].Fill, doc.Rects[2].Fill)
+	}
+	texts := strings.Join(doc.Texts, "|")
+	for _, want := range []string{`Rain & "storms"`, "Rainfall (mm)", "Mean 200.0 mm", "400", "<2020>"} {
+		if !strings.Contains(texts, want) {
+			t.Errorf("Expected text %q in %v", want, doc.Texts)
+		}
+	}
+	if !strings.Contains(svg, `width="800" height="320"`) {
+		t.Errorf("Expected the style's size, got %.120s", svg)
+	}
+}
+
+func TestLabelSpacing(t *testing.T) {
+	bars := make([]Bar, 160)
+	for i := range bars {
+		bars[i] = Bar{Label: "1900"}
+	}
+	// 4 digits at 12px need about 35px, so 4px slots label every 9th bar
+	if every := labelSpacing(bars, 4, 12); every != 9 {
+		t.Errorf("Expected every 9th bar to be labelled, got %d", every)
+	}
+	if every := labelSpacing(bars[:12], 60, 12); every != 1 {
+		t.Errorf("Expected every bar to be labelled, got %d", every)
+	}
+}
+
+func TestBarChart_YMax(t *testing.T) {
+	chart := BarChart{
+		Bars:           []Bar{{Label: "2019", Value: 600}},
+		Reference:      450,
+		ReferenceLabel: "Mean",
+		YMax:           400,
+	}
+	svg := chart.SVG(DefaultChartStyle())
+	if !strings.Contains(svg, ">400</text>") || strings.Contains(svg, ">500</text>") {
+		t.Errorf("Expected the y axis to end at 400, got %s", svg)
+	}
+	if strings.Contains(svg, "stroke-dasharray") {
+		t.Errorf("Expected a reference above the axis not to be drawn, got %s", svg)
+	}
+}
+
+func TestNewBox(t *testing.T) {
+	box := NewBox("Jan", []float64{40, 10, 30, 20, 50})
+	want := Box{Label: "Jan", Count: 5, Min: 10, Q1: 20, Median: 30, Q3: 40, Max: 50}
+	if box != want {
+		t.Errorf("Expected %+v, got %+v", want, box)
+	}
+	if empty := NewBox("Feb", nil); empty.Count != 0 {
+		t.Errorf("Expected an empty box, got %+v", empty)
+	}
+}
+
+func TestBoxPlot_SVG(t *testing.T) {
+	chart := BoxPlot{
+		ChartLabels: ChartLabels{Title: "Monthly rainfall"},
+		Boxes: []Box{
+			NewBox("Jan", []float64{10, 20, 30, 40, 50}),
+			NewBox("Feb", nil),
+		},
+	}
+	var sb strings.Builder
+	if err := chart.WriteSVG(&sb, DefaultChartStyle()); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	svg := sb.String()
+
+	var doc struct {
+		XMLName xml.Name `xml:"svg"`
+		Groups  []struct {
+			Title string `xml:"title"`
+		} `xml:"g"`
+		Texts []string `xml:"text"`
+	}
+	if err := xml.Unmarshal([]byte(svg), &doc); err != nil {
+		t.Fatalf("Chart is not valid XML: %v\n%s", err, svg)
+	}
+	if len(doc.Groups) != 1 || doc.Groups[0].Title != "Jan: median 30, quartiles 20 to 40, range 10 to 50 (5 values)" {
+		t.Errorf("Expected one box for January, got %+v", doc.Groups)
+	}
+	texts := strings.Join(doc.Texts, "|")
+	for _, want := range []string{"Monthly rainfall", "Jan", "Feb", "50"} {
+		if !strings.Contains(texts, want) {
+			t.Errorf("Expected text %q in %v", want, doc.Texts)
+		}
+	}
+}
+
+func TestLineChart_SVG(t *testing.T) {
+	chart := LineChart{
+		ChartLabels: ChartLabels{YLabel: "Rainfall (mm)", XLabel: "Day"},
+		Series: []Series{
+			{Label: "2020", Points: []Point{{0, 0}, {1, 10}, {2, 30}}},
+			{Label: "Mean", Points: []Point{{0, 0}, {2, 20}, {4, 40}}, Reference: true},
+		},
+		XMax:   4,
+		XTicks: []Tick{{Value: 0, Label: "Jan"}, {Value: 2, Label: "Feb"}},
+	}
+	style := DefaultChartStyle()
+	var sb strings.Builder
+	if err := chart.WriteSVG(&sb, style); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	svg := sb.String()
+
+	var doc struct {
+		XMLName   xml.Name `xml:"svg"`
+		Polylines []struct {
+			Points string `xml:"points,attr"`
+			Stroke string `xml:"stroke,attr"`
+			Dash   string `xml:"stroke-dasharray,attr"`
+			Title  string `xml:"title"`
+		} `xml:"polyline"`
+		Texts []string `xml:"text"`
+	}
+	if err := xml.Unmarshal([]byte(svg), &doc); err != nil {
+		t.Fatalf("Chart is not valid XML: %v\n%s", err, svg)
+	}
+	// Two series and the axis lines
+	if len(doc.Polylines) != 3 {
+		t.Fatalf("Expected 3 polylines, got %d", len(doc.Polylines))
+	}
+	series, mean := doc.Polylines[0], doc.Polylines[1]
+	if series.Title != "2020" || series.Stroke != style.BarColour || series.Dash != "" {
+		t.Errorf("Unexpected series %+v", series)
+	}
+	if mean.Title != "Mean" || mean.Stroke != style.ReferenceColour || mean.Dash == "" {
+		t.Errorf("Expected a dashed reference series, got %+v", mean)
+	}
+	if n := len(strings.Fields(series.Points)); n != 3 {
+		t.Errorf("Expected 3 points, got %q", series.Points)
+	}
+	texts := strings.Join(doc.Texts, "|")
+	for _, want := range []string{"Rainfall (mm)", "Day", "Jan", "Feb", "2020", "Mean", "40"} {
+		if !strings.Contains(texts, want) {
+			t.Errorf("Expected text %q in %v", want, doc.Texts)
+		}
+	}
+}
+
+func TestCalendarHeatmap_SVG(t *testing.T) {
+	chart := CalendarHeatmap{
+		ChartLabels: ChartLabels{Title: "Daily rainfall"},
+		Year:        2020,
+		Days: []HeatmapDay{
+			{Date: day(2020, 1, 1), Value: 0, Valid: true},
+			{Date: day(2020, 1, 2), Value: 8, Valid: true},
+			{Date: day(2020, 1, 3), Value: 2, Valid: true},
+			{Date: day(2019, 12, 31), Value: 100, Valid: true},
+		},
+		Unit: "mm",
+	}
+	style := DefaultChartStyle()
+	var sb strings.Builder
+	if err := chart.WriteSVG(&sb, style); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	svg := sb.String()
+
+	var doc struct {
+		XMLName xml.Name `xml:"svg"`
+		Rects   []struct {
+			X       string `xml:"x,attr"`
+			Y       string `xml:"y,attr"`
+			Fill    string `xml:"fill,attr"`
+			Opacity string `xml:"fill-opacity,attr"`
+			Title   string `xml:"title"`
+		} `xml:"rect"`
+		Texts []string `xml:"text"`
+	}
+	if err := xml.Unmarshal([]byte(svg), &doc); err != nil {
+		t.Fatalf("Chart is not valid XML: %v\n%s", err, svg)
+	}
+	// 366 days, then the no reading, dry and shaded legend swatches
+	if len(doc.Rects) != 366+2+heatmapLegendSteps {
+		t.Fatalf("Expected %d cells, got %d", 366+2+heatmapLegendSteps, len(doc.Rects))
+	}
+	dry, wettest, wet, missing := doc.Rects[0], doc.Rects[1], doc.Rects[2], doc.Rects[3]
+	if dry.Title != "2020-01-01: 0 mm" || dry.Fill != style.GridColour {
+		t.Errorf("Unexpected dry day %+v", dry)
+	}
+	// The highest value of the year is drawn at full colour, and 2019 is
+	// left out
+	if wettest.Title != "2020-01-02: 8 mm" || wettest.Fill != style.BarColour || wettest.Opacity != "1.00" {
+		t.Errorf("Unexpected wettest day %+v", wettest)
+	}
+	if wet.Opacity != "0.57" {
+		t.Errorf("Expected 2 mm of 8 mm at opacity 0.57, got %+v", wet)
+	}
+	if missing.Title != "2020-01-04: no reading" || missing.Fill != style.MutedColour {
+		t.Errorf("Unexpected missing day %+v", missing)
+	}
+	// 1 January 2020 was a Wednesday, so it is the third row of the first
+	// week and 1 January and 2 January share a column
+	if dry.X != wettest.X || dry.Y == wettest.Y {
+		t.Errorf("Expected consecutive days in one column, got %+v and %+v", dry, wettest)
+	}
+	texts := strings.Join(doc.Texts, "|")
+	for _, want := range []string{"Daily rainfall", "Mon", "Dec", "No reading", "0 mm", "8 mm"} {
+		if !strings.Contains(texts, want) {
+			t.Errorf("Expected text %q in %v", want, doc.Texts)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package bom
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultTableWidth is the line width of table output when none is given
+const DefaultTableWidth = 80
+
+// tableColumn is one column of a text table
+type tableColumn struct {
+	header string
+	// left aligns the column to the left. Other columns hold numbers and
+	// are aligned to the right.
+	left bool
+	// drop orders the columns removed when a table is wider than its line,
+	// highest first. Columns with a drop of zero are always kept.
+	drop int
+}
+
+// textTable is a table of preformatted cells
+type textTable struct {
+	columns []tableColumn
+	rows    [][]string
+}
+
+func (t *textTable) add(cells ...string) {
+	t.rows = append(t.rows, cells)
+}
+
+// widths returns the display width of each column, and whether any row has
+// a value in it
+func (t *textTable) widths() ([]int, []bool) {
+	widths := make([]int, len(t.columns))
+	filled := make([]bool, len(t.columns))
+	for i, c := range t.columns {
+		widths[i] = utf8.RuneCountInString(c.header)
+	}
+	for _, row := range t.rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+			filled[i] = filled[i] || cell != ""
+		}
+	}
+	return widths, filled
+}
+
+// visible returns the indexes of the columns to draw. Columns without a
+// value in any row are left out, then columns are dropped until the table
+// fits within width. A width of zero keeps every column.
+func (t *textTable) visible(width int) []int {
+	widths, filled := t.widths()
+	var keep []int
+	for i := range t.columns {
+		if filled[i] {
+			keep = append(keep, i)
+		}
+	}
+	for width > 0 {
+		total, next := 0, -1
+		for k, i := range keep {
+			total += widths[i]
+			if k > 0 {
+				total += len(tableGap)
+			}
+			if t.columns[i].drop > 0 && (next < 0 || t.columns[i].drop > t.columns[keep[next]].drop) {
+				next = k
+			}
+		}
+		if total <= width || next < 0 {
+			break
+		}
+		keep = append(keep[:next], keep[next+1:]...)
+	}
+	return keep
+}
+
+// tableGap separates the columns of text tables
+const tableGap = "  "
+
+// writeText writes the table with space-padded columns and a rule under the
+// headers
+func (t *textTable) writeText(w *bufio.Writer, width int) {
+	widths, _ := t.widths()
+	columns := t.visible(width)
+	line := func(cell func(i int) string) {
+		cells := make([]string, len(columns))
+		for k, i := range columns {
+			cells[k] = padCell(cell(i), widths[i], t.columns[i].left)
+		}
+		w.WriteString(strings.TrimRight(strings.Join(cells, tableGap), " ") + "\n")
+	}
+
+	line(func(i int) string { return t.columns[i].header })
+	line(func(i int) string { return strings.Repeat("─", widths[i]) })
+	for _, row := range t.rows {
+		line(func(i int) string { return row[i] })
+	}
+}
+
+// writeMarkdown writes the table as a GitHub Flavored Markdown pipe table
+// with every column that has a value
+func (t *textTable) writeMarkdown(w *bufio.Writer) {
+	// Cells are escaped before they are measured so that rows stay aligned
+	escaped := textTable{columns: make([]tableColumn, len(t.columns))}
+	for i, c := range t.columns {
+		c.header = markdownEscape(c.header)
+		escaped.columns[i] = c
+	}
+	for _, row := range t.rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = markdownEscape(cell)
+		}
+		escaped.add(cells...)
+	}
+	t = &escaped
+
+	widths, _ := t.widths()
+	columns := t.visible(0)
+	line := func(cell func(i int) string) {
+		cells := make([]string, len(columns))
+		for k, i := range columns {
+			cells[k] = padCell(cell(i), max(widths[i], 3), t.columns[i].left)
+		}
+		w.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+
+	line(func(i int) string { return t.columns[i].header })
+	line(func(i int) string {
+		rule := strings.Repeat("-", max(widths[i], 3)-1)
+		if t.columns[i].left {
+			return ":" + rule
+		}
+		return rule + ":"
+	})
+	for _, row := range t.rows {
+		line(func(i int) string { return row[i] })
+	}
+}
+
+// padCell pads s with spaces to width runes
+func padCell(s string, width int, left bool) string {
+	padding := strings.Repeat(" ", max(width-utf8.RuneCountInString(s), 0))
+	if left {
+		return s + padding
+	}
+	return padding + s
+}
+
+// markdownEscaper escapes the characters that would end a table cell or
+// start emphasis
+var markdownEscaper = strings.NewReplacer("|", `\|`, "*", `\*`)
+
+func markdownEscape(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// sparkBlocks are the eighth-height blocks of a sparkline, lowest first
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws one block per value, scaled so that highest is a full
+// block. Values that are not available are drawn as spaces.
+func sparkline(values []float64, valid []bool, highest float64) string {
+	var sb strings.Builder
+	for i, v := range values {
+		if !valid[i] {
+			sb.WriteRune(' ')
+			continue
+		}
+		level := 0
+		if highest > 0 {
+			level = int(math.Round(math.Min(math.Max(v/highest, 0), 1) * float64(len(sparkBlocks)-1)))
+		}
+		sb.WriteRune(sparkBlocks[level])
+	}
+	return sb.String()
+}
+
+// tableSection is a titled table of a table or markdown document
+type tableSection struct {
+	title string
+	table textTable
+	// note follows the table, such as the meaning of a marker
+	note string
+}
+
+// unreliableMarker follows the year or month of an unreliable period
+const unreliableMarker = "*"
+
+const unreliableNote = unreliableMarker + " below the minimum completeness"
+
+// newTableSections lays the weather data out as a summary of the years, a
+// breakdown of each year's months and the interannual variability
+func newTableSections(data WeatherData) []tableSection {
+	// Sparklines share one scale, the wettest month of the record, so that
+	// years can be compared
+	highest := 0.0
+	for _, y := range data.WeatherDataForYear {
+		for _, m := range y.MonthlyAggregates.WeatherDataForMonth {
+			if total, err := strconv.ParseFloat(m.TotalRainfall, 64); err == nil {
+				highest = math.Max(highest, total)
+			}
+		}
+	}
+
+	sections := []tableSection{{title: "Years", table: textTable{columns: []tableColumn{
+		{header: "Year", left: true},
+		{header: "Total mm"},
+		{header: "Wet days", drop: 2},
+		{header: "Dry days", drop: 5},
+		{header: "Longest run", drop: 4},
+		{header: "Mean mm/day", drop: 3},
+		{header: "Median mm/day", drop: 7},
+		{header: "SD", drop: 8},
+		{header: "CV", drop: 9},
+		{header: "Complete %", drop: 1},
+		{header: "Imputed days", drop: 6},
+		{header: "Jan–Dec", left: true},
+	}}}}
+	for _, y := range data.WeatherDataForYear {
+		values, valid := make([]float64, 12), make([]bool, 12)
+		months := tableSection{title: y.Year, table: textTable{columns: []tableColumn{
+			{header: "Month", left: true},
+			{header: "Total mm"},
+			{header: "Wet days", drop: 2},
+			{header: "Dry days", drop: 5},
+			{header: "Mean mm/day", drop: 3},
+			{header: "Median mm/day", drop: 7},
+			{header: "SD", drop: 8},
+			{header: "CV", drop: 9},
+			{header: "Complete %", drop: 1},
+			{header: "Imputed days", drop: 6},
+		}}}
+		for _, m := range y.MonthlyAggregates.WeatherDataForMonth {
+			if month, err := parseMonth(m.Month); err == nil {
+				values[month-1], err = strconv.ParseFloat(m.TotalRainfall, 64)
+				valid[month-1] = err == nil
+			}
+			months.table.add(markUnreliable(m.Month, m.Unreliable), m.TotalRainfall, m.DaysWithRainfall,
+				m.DaysWithNoRainfall, m.AverageDailyRainfall, m.MedianDailyRainfall, m.StdDevDailyRainfall,
+				m.CoefficientOfVariation, m.PercentComplete, m.ImputedDays)
+			if m.Unreliable == "true" {
+				months.note = unreliableNote
+			}
+		}
+		sections[0].table.add(markUnreliable(y.Year, y.Unreliable), y.TotalRainfall, y.DaysWithRainfall,
+			y.DaysWithNoRainfall, y.LongestDaysRaining, y.AverageDailyRainfall, y.MedianDailyRainfall,
+			y.StdDevDailyRainfall, y.CoefficientOfVariation, y.PercentComplete, y.ImputedDays,
+			sparkline(values, valid, highest))
+		if y.Unreliable == "true" {
+			sections[0].note = unreliableNote
+		}
+		if len(months.table.rows) > 0 {
+			sections = append(sections, months)
+		}
+	}
+
+	if len(data.InterannualVariability) > 0 {
+		variability := tableSection{title: "Interannual variability", table: textTable{columns: []tableColumn{
+			{header: "Month", left: true},
+			{header: "Years", drop: 1},
+			{header: "Period", left: true, drop: 3},
+			{header: "Mean total mm"},
+			{header: "SD", drop: 2},
+			{header: "CV", drop: 2},
+		}}}
+		for _, v := range data.InterannualVariability {
+			variability.table.add(v.Month, v.Years, v.FirstYear+"–"+v.LastYear, v.MeanTotalRainfall,
+				v.StdDevTotalRainfall, v.CoefficientOfVariation)
+		}
+		sections = append(sections, variability)
+	}
+	return sections
+}
+
+// markUnreliable appends the unreliable marker to the label of an
+// unreliable period
+func markUnreliable(label, unreliable string) string {
+	if unreliable == "true" {
+		return label + unreliableMarker
+	}
+	return label
+}
+
+// tableTitle heads table and markdown output
+func tableTitle(data WeatherData) string {
+	if data.Station != "" {
+		return "Rainfall at station " + data.Station
+	}
+	return "Rainfall"
+}
+
+// writeTable writes the weather data as aligned text tables: a row per year
+// with a sparkline of its monthly totals, a breakdown of each year's months
+// and the interannual variability. Columns are dropped, least important
+// first, until each table fits within width.
+func writeTable(w io.Writer, data WeatherData, width int) error {
+	buffered := bufio.NewWriter(w)
+	title := tableTitle(data)
+	buffered.WriteString(title + "\n" + strings.Repeat("═", utf8.RuneCountInString(title)) + "\n")
+	for _, s := range newTableSections(data) {
+		buffered.WriteString("\n" + s.title + "\n\n")
+		s.table.writeText(buffered, width)
+		if s.note != "" {
+			buffered.WriteString(s.note + "\n")
+		}
+	}
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("failed to write table: %w", err)
+	}
+	return nil
+}
+
+// writeMarkdown writes the same tables as writeTable as a Markdown
+// document, with every column and a heading per table
+func writeMarkdown(w io.Writer, data WeatherData) error {
+	buffered := bufio.NewWriter(w)
+	buffered.WriteString("# " + tableTitle(data) + "\n")
+	for _, s := range newTableSections(data) {
+		buffered.WriteString("\n## " + s.title + "\n\n")
+		s.table.writeMarkdown(buffered)
+		if s.note != "" {
+			buffered.WriteString("\n" + markdownEscape(s.note) + "\n")
+		}
+	}
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("failed to write Markdown: %w", err)
+	}
+	return nil
+}
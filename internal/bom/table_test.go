@@ -0,0 +1,156 @@
+package bom
+
+import (
+	"bufio"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSparkline(t *testing.T) {
+	values := []float64{0, 10, 20, 40, 80, 0}
+	valid := []bool{true, true, true, true, true, false}
+	if got := sparkline(values, valid, 80); got != "▁▂▃▅█ " {
+		t.Errorf("Expected ▁▂▃▅█ with a space for the missing value, got %q", got)
+	}
+	if got := sparkline([]float64{0, 0}, []bool{true, true}, 0); got != "▁▁" {
+		t.Errorf("Expected the lowest blocks for a dry record, got %q", got)
+	}
+}
+
+func tableTestTable() textTable {
+	table := textTable{columns: []tableColumn{
+		{header: "Name", left: true},
+		{header: "Total"},
+		{header: "Detail", left: true, drop: 1},
+		{header: "Extra", drop: 2},
+		{header: "Empty", drop: 3},
+	}}
+	table.add("a|b", "1.5", "longer detail", "x", "")
+	table.add("c", "10.25", "", "yy", "")
+	return table
+}
+
+func TestTextTable_Visible(t *testing.T) {
+	table := tableTestTable()
+	tests := []struct {
+		width int
+		want  []int
+	}{
+		// Empty columns are always left out
+		{0, []int{0, 1, 2, 3}},
+		{80, []int{0, 1, 2, 3}},
+		// Extra is dropped before Detail
+		{30, []int{0, 1, 2}},
+		{20, []int{0, 1}},
+		// Columns without a drop order are kept however narrow the line
+		{5, []int{0, 1}},
+	}
+	for _, tt := range tests {
+		if got := table.visible(tt.width); !slices.Equal(got, tt.want) {
+			t.Errorf("visible(%d): expected columns %v, got %v", tt.width, tt.want, got)
+		}
+	}
+}
+
+func TestTextTable_Write(t *testing.T) {
+	table := tableTestTable()
+
+	var sb strings.Builder
+	w := bufio.NewWriter(&sb)
+	table.writeText(w, 30)
+	w.Flush()
+	want := "Name  Total  Detail\n" +
+		"────  ─────  ─────────────\n" +
+		"a|b     1.5  longer detail\n" +
+		"c     10.25\n"
+	if sb.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, sb.String())
+	}
+
+	sb.Reset()
+	table.writeMarkdown(w)
+	w.Flush()
+	want = "| Name | Total | Detail        | Extra |\n" +
+		"| :--- | ----: | :------------ | ----: |\n" +
+		"| a\\|b |   1.5 | longer detail |     x |\n" +
+		"| c    | 10.25 |               |    yy |\n"
+	if sb.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, sb.String())
+	}
+}
+
+func tableTestData() WeatherData {
+	data := encoderTestData()
+	data.Station = "066062"
+	data.WeatherDataForYear[0].Unreliable = "true"
+	data.WeatherDataForYear[0].MonthlyAggregates.WeatherDataForMonth[1].TotalRainfall = "97.6"
+	data.InterannualVariability = []CalendarMonthVariability{
+		{Month: "January", Years: "1", FirstYear: "2019", LastYear: "2019", MeanTotalRainfall: "48.8"},
+	}
+	return data
+}
+
+func TestWriteTable(t *testing.T) {
+	var sb strings.Builder
+	if err := writeTable(&sb, tableTestData(), 40); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	out := sb.String()
+
+	for _, want := range []string{
+		"Rainfall at station 066062\n══════════════════════════\n",
+		"\nYears\n\nYear   Total mm  Wet days  Jan–Dec\n",
+		// January is half of the wettest month; 2020 has no months
+		"2019*     374.2        44  ▅█\n2020       12.0\n",
+		"* below the minimum completeness\n",
+		"\n2019\n\nMonth      Total mm\n",
+		"February*      97.6\n",
+		"\nInterannual variability\n\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if utf8.RuneCountInString(line) > 40 {
+			t.Errorf("Expected lines to fit 40 columns, got %q", line)
+		}
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var sb strings.Builder
+	if err := writeMarkdown(&sb, tableTestData()); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	out := sb.String()
+
+	for _, want := range []string{
+		"# Rainfall at station 066062\n\n## Years\n\n",
+		"| Year   | Total mm | Wet days | Jan–Dec      |\n| :----- | -------: | -------: | :----------- |\n",
+		"| 2019\\* |    374.2 |       44 | ▅█           |\n",
+		"| February\\* |     97.6 |\n",
+		"\n\\* below the minimum completeness\n",
+		"\n## 2019\n\n",
+		"\n## Interannual variability\n\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestConverter_EncodeTable(t *testing.T) {
+	for _, format := range []string{FormatTable, FormatMarkdown} {
+		var sb strings.Builder
+		converter := NewConverterWithOptions(ConverterOptions{Format: format, Width: 200})
+		if err := converter.Encode(&sb, tableTestData()); err != nil {
+			t.Fatalf("%s: expected no error, got: %v", format, err)
+		}
+		if !strings.Contains(sb.String(), "Rainfall at station 066062") {
+			t.Errorf("%s: unexpected output:\n%s", format, sb.String())
+		}
+	}
+}
@@ -0,0 +1,431 @@
+package bom
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// TrendOptions controls trend analysis of yearly series
+type TrendOptions struct {
+	// From and To restrict the records analysed. A zero time leaves that
+	// end of the range open. Years only partly inside the range fail the
+	// completeness check and are excluded.
+	From time.Time
+	To   time.Time
+	// MinCompleteness is the minimum percentage of days in a year, or in a
+	// month for monthly totals, that must have a reading to be used
+	MinCompleteness float64
+	// Significance is the p-value below which a trend is significant
+	Significance float64
+	// MinYears is the minimum number of years needed to test a series
+	MinYears int
+	// Clock determines which days have occurred. A nil Clock uses the
+	// system time.
+	Clock Clock
+}
+
+// DefaultTrendOptions returns the options used by the CLI by default
+func DefaultTrendOptions() TrendOptions {
+	return TrendOptions{
+		MinCompleteness: 90,
+		Significance:    0.05,
+		MinYears:        10,
+		Clock:           SystemClock{},
+	}
+}
+
+// TrendPoint is one year's value of a series
+type TrendPoint struct {
+	Year  int
+	Value float64
+}
+
+// LinearTrend is an ordinary least-squares fit of value against year
+type LinearTrend struct {
+	// Slope is the change per year
+	Slope     float64
+	Intercept float64
+	// PValue is the two-sided p-value of the slope from Student's t test
+	PValue float64
+}
+
+// FitLinearTrend fits an ordinary least-squares line. At least three points
+// are required.
+func FitLinearTrend(points []TrendPoint) (LinearTrend, error) {
+	n := len(points)
+	if n < 3 {
+		return LinearTrend{}, fmt.Errorf("at least 3 points are required for a linear trend, got %d", n)
+	}
+	var xMean, yMean float64
+	for _, p := range points {
+		xMean += float64(p.Year)
+		yMean += p.Value
+	}
+	xMean /= float64(n)
+	yMean /= float64(n)
+
+	var sxx, sxy float64
+	for _, p := range points {
+		dx := float64(p.Year) - xMean
+		sxx += dx * dx
+		sxy += dx * (p.Value - yMean)
+	}
+	if sxx == 0 {
+		return LinearTrend{}, fmt.Errorf("a linear trend requires more than one distinct year")
+	}
+	slope := sxy / sxx
+	intercept := yMean - slope*xMean
+
+	var sse float64
+	for _, p := range points {
+		r := p.Value - (intercept + slope*float64(p.Year))
+		sse += r * r
+	}
+	df := float64(n - 2)
+	stdErr := math.Sqrt(sse / df / sxx)
+
+	pValue := 1.0
+	if stdErr == 0 {
+		if slope != 0 {
+			pValue = 0
+		}
+	} else {
+		t := slope / stdErr
+		pValue = regularisedBeta(df/(df+t*t), df/2, 0.5)
+	}
+	return LinearTrend{Slope: slope, Intercept: intercept, PValue: pValue}, nil
+}
+
+// MannKendall is the result of the Mann-Kendall test for a monotonic trend
+type MannKendall struct {
+	S int
+	// Tau is Kendall's rank correlation between value and year
+	Tau float64
+	// Z is the normal approximation of S with continuity correction
+	Z float64
+	// PValue is the two-sided p-value
+	PValue float64
+}
+
+// MannKendallTest tests a series for a monotonic trend, correcting the
+// variance for tied values. At least three points are required.
+func MannKendallTest(points []TrendPoint) (MannKendall, error) {
+	n := len(points)
+	if n < 3 {
+		return MannKendall{}, fmt.Errorf("at least 3 points are required for the Mann-Kendall test, got %d", n)
+	}
+	sorted := sortedByYear(points)
+
+	s := 0
+	for i := 0; i < n-1; i++ {
+		for j := i + 1; j < n; j++ {
+			switch {
+			case sorted[j].Value > sorted[i].Value:
+				s++
+			case sorted[j].Value < sorted[i].Value:
+				s--
+			}
+		}
+	}
+
+	counts := make(map[float64]int)
+	for _, p := range sorted {
+		counts[p.Value]++
+	}
+	fn := float64(n)
+	variance := fn * (fn - 1) * (2*fn + 5)
+	for _, t := range counts {
+		ft := float64(t)
+		variance -= ft * (ft - 1) * (2*ft + 5)
+	}
+	variance /= 18
+
+	var z float64
+	switch {
+	case variance == 0:
+		z = 0
+	case s > 0:
+		z = float64(s-1) / math.Sqrt(variance)
+	case s < 0:
+		z = float64(s+1) / math.Sqrt(variance)
+	}
+	return MannKendall{
+		S:      s,
+		Tau:    float64(s) / (fn * (fn - 1) / 2),
+		Z:      z,
+		PValue: math.Erfc(math.Abs(z) / math.Sqrt2),
+	}, nil
+}
+
+// SensSlope returns the Theil-Sen estimate of the change per year: the
+// median of the slopes between every pair of years. At least two points
+// are required.
+func SensSlope(points []TrendPoint) (float64, error) {
+	if len(points) < 2 {
+		return 0, fmt.Errorf("at least 2 points are required for Sen's slope, got %d", len(points))
+	}
+	var slopes []float64
+	for i := 0; i < len(points)-1; i++ {
+		for j := i + 1; j < len(points); j++ {
+			if dx := points[j].Year - points[i].Year; dx != 0 {
+				slopes = append(slopes, (points[j].Value-points[i].Value)/float64(dx))
+			}
+		}
+	}
+	if len(slopes) == 0 {
+		return 0, fmt.Errorf("Sen's slope requires more than one distinct year")
+	}
+	return percentile(slopes, 0.5), nil
+}
+
+// sortedByYear returns a copy of points in year order
+func sortedByYear(points []TrendPoint) []TrendPoint {
+	sorted := make([]TrendPoint, len(points))
+	copy(sorted, points)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Year < sorted[j].Year })
+	return sorted
+}
+
+// regularisedBeta returns the regularised incomplete beta function
+// I_x(a, b), evaluated by continued fraction
+func regularisedBeta(x, a, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	front := math.Exp(lab - la - lb + a*math.Log(x) + b*math.Log(1-x))
+	// The continued fraction converges quickly only below the mean
+	if x > (a+1)/(a+b+2) {
+		return 1 - front*betaContinuedFraction(1-x, b, a)/b
+	}
+	return front * betaContinuedFraction(x, a, b) / a
+}
+
+// betaContinuedFraction evaluates the continued fraction of the incomplete
+// beta function by the modified Lentz method
+func betaContinuedFraction(x, a, b float64) float64 {
+	const (
+		maxIterations = 500
+		epsilon       = 1e-14
+		tiny          = 1e-300
+	)
+	c := 1.0
+	d := 1 - (a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m <= maxIterations; m++ {
+		fm := float64(m)
+		for _, aa := range []float64{
+			fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm)),
+			-(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1)),
+		} {
+			d = 1 + aa*d
+			if math.Abs(d) < tiny {
+				d = tiny
+			}
+			c = 1 + aa/c
+			if math.Abs(c) < tiny {
+				c = tiny
+			}
+			d = 1 / d
+			h *= d * c
+		}
+		if math.Abs(d*c-1) < epsilon {
+			break
+		}
+	}
+	return h
+}
+
+// TrendSeries is a yearly series and the results of its trend tests
+type TrendSeries struct {
+	// Name identifies the statistic, such as TotalRainfall
+	Name string
+	// Month is set for the totals of a single calendar month
+	Month         time.Month
+	Points        []TrendPoint
+	ExcludedYears []int
+	// Tested is false when there were fewer than MinYears points, in which
+	// case the results are not set
+	Tested      bool
+	Linear      LinearTrend
+	MannKendall MannKendall
+	SensSlope   float64
+}
+
+// yearlySeries names the yearly statistics analysed for trends
+var yearlySeries = []struct {
+	name  string
+	value func(WeatherDataForYear) string
+}{
+	{"TotalRainfall", func(y WeatherDataForYear) string { return y.TotalRainfall }},
+	{"DaysWithRainfall", func(y WeatherDataForYear) string { return y.DaysWithRainfall }},
+	{"MaximumDailyRainfall", func(y WeatherDataForYear) string { return y.AdditionalStatistics["MaximumDailyRainfall"] }},
+}
+
+// TrendAggregatorOptions returns the Aggregator options whose yearly series
+// AnalyseTrends expects: the default statistics plus MaximumDailyRainfall,
+// with incomplete periods flagged
+func TrendAggregatorOptions(opts TrendOptions) AggregatorOptions {
+	statistics := DefaultStatistics()
+	statistics.mustRegister("MaximumDailyRainfall", MaximumDailyRainfall())
+	aggregatorOptions := DefaultAggregatorOptions()
+	aggregatorOptions.MinCompleteness = opts.MinCompleteness
+	aggregatorOptions.IncompleteAction = IncompleteFlag
+	aggregatorOptions.Statistics = statistics
+	if opts.Clock != nil {
+		aggregatorOptions.Clock = opts.Clock
+	}
+	return aggregatorOptions
+}
+
+// AnalyseTrends tests the annual total, rain-day count and maximum daily
+// fall of aggregated weather data for trends, followed by the total of each
+// calendar month. Years and months flagged as unreliable are excluded.
+// Years and months that have not finished as of the clock's date are left
+// out of the series, since their totals are only partial.
+func AnalyseTrends(data WeatherData, opts TrendOptions) ([]TrendSeries, error) {
+	clock := opts.Clock
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	today := truncateToDay(clock.Now())
+
+	var series []TrendSeries
+	for _, s := range yearlySeries {
+		trend := TrendSeries{Name: s.name}
+		for _, y := range data.WeatherDataForYear {
+			year, err := strconv.Atoi(y.Year)
+			if err != nil {
+				return nil, fmt.Errorf("invalid year '%s': %w", y.Year, err)
+			}
+			if !monthFinished(year, time.December, today) {
+				continue
+			}
+			if err := trend.add(year, s.value(y), y.Unreliable == "true"); err != nil {
+				return nil, err
+			}
+		}
+		series = append(series, trend.test(opts))
+	}
+
+	for m := time.January; m <= time.December; m++ {
+		trend := TrendSeries{Name: "TotalRainfall", Month: m}
+		for _, y := range data.WeatherDataForYear {
+			year, err := strconv.Atoi(y.Year)
+			if err != nil {
+				return nil, fmt.Errorf("invalid year '%s': %w", y.Year, err)
+			}
+			if !monthFinished(year, m, today) {
+				continue
+			}
+			for _, month := range y.MonthlyAggregates.WeatherDataForMonth {
+				if month.Month != m.String() {
+					continue
+				}
+				if err := trend.add(year, month.TotalRainfall, month.Unreliable == "true"); err != nil {
+					return nil, err
+				}
+			}
+		}
+		series = append(series, trend.test(opts))
+	}
+	return series, nil
+}
+
+// add appends a year's value, or records the year as excluded
+func (t *TrendSeries) add(year int, value string, unreliable bool) error {
+	if unreliable || value == "" {
+		t.ExcludedYears = append(t.ExcludedYears, year)
+		return nil
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s '%s' for %d: %w", t.Name, value, year, err)
+	}
+	t.Points = append(t.Points, TrendPoint{Year: year, Value: v})
+	return nil
+}
+
+// test runs the trend tests when there are enough points
+func (t TrendSeries) test(opts TrendOptions) TrendSeries {
+	if len(t.Points) < opts.MinYears || len(t.Points) < 3 {
+		return t
+	}
+	linear, err := FitLinearTrend(t.Points)
+	if err != nil {
+		return t
+	}
+	mk, err := MannKendallTest(t.Points)
+	if err != nil {
+		return t
+	}
+	sen, err := SensSlope(t.Points)
+	if err != nil {
+		return t
+	}
+	t.Linear = linear
+	t.MannKendall = mk
+	t.SensSlope = sen
+	t.Tested = true
+	return t
+}
+
+// NewTrendData converts trend analyses into their JSON output structure
+func NewTrendData(series []TrendSeries, opts TrendOptions) TrendData {
+	data := TrendData{
+		MinCompleteness: formatFloat(opts.MinCompleteness, 1),
+		Significance:    formatFloat(opts.Significance, 2),
+	}
+	if !opts.From.IsZero() {
+		data.From = opts.From.Format("2006-01-02")
+	}
+	if !opts.To.IsZero() {
+		data.To = opts.To.Format("2006-01-02")
+	}
+	for _, s := range series {
+		out := TrendSeriesData{
+			Series: s.Name,
+			Years:  strconv.Itoa(len(s.Points)),
+		}
+		if s.Month != 0 {
+			out.Month = s.Month.String()
+		}
+		if len(s.Points) > 0 {
+			sorted := sortedByYear(s.Points)
+			out.FirstYear = strconv.Itoa(sorted[0].Year)
+			out.LastYear = strconv.Itoa(sorted[len(sorted)-1].Year)
+		}
+		for _, y := range s.ExcludedYears {
+			out.ExcludedYears = append(out.ExcludedYears, strconv.Itoa(y))
+		}
+		if s.Tested {
+			out.LinearRegression = &LinearRegressionData{
+				SlopePerDecade: formatFloat(10*s.Linear.Slope, 3),
+				PValue:         formatFloat(s.Linear.PValue, 4),
+				Significant:    strconv.FormatBool(s.Linear.PValue < opts.Significance),
+			}
+			out.MannKendall = &MannKendallData{
+				S:           strconv.Itoa(s.MannKendall.S),
+				Tau:         formatFloat(s.MannKendall.Tau, 4),
+				Z:           formatFloat(s.MannKendall.Z, 4),
+				PValue:      formatFloat(s.MannKendall.PValue, 4),
+				Significant: strconv.FormatBool(s.MannKendall.PValue < opts.Significance),
+			}
+			out.SensSlopePerDecade = formatFloat(10*s.SensSlope, 3)
+		}
+		data.Trends = append(data.Trends, out)
+	}
+	return data
+}
@@ -0,0 +1,192 @@
+package bom
+
+import (
+	"math"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func trendPoints(values ...float64) []TrendPoint {
+	points := make([]TrendPoint, len(values))
+	for i, v := range values {
+		points[i] = TrendPoint{Year: 2000 + i, Value: v}
+	}
+	return points
+}
+
+func TestRegularisedBeta(t *testing.T) {
+	// I_x(1, 1) is the uniform CDF
+	if got := regularisedBeta(0.3, 1, 1); !approxEqual(got, 0.3, 1e-12) {
+		t.Errorf("Expected 0.3, got %v", got)
+	}
+	// Two-sided Student's t p-values: t=1 with 1 df is 0.5, and t=2.228
+	// with 10 df is the 5% critical value
+	if got := regularisedBeta(1.0/2, 0.5, 0.5); !approxEqual(got, 0.5, 1e-10) {
+		t.Errorf("Expected 0.5, got %v", got)
+	}
+	tt := 2.228138852
+	if got := regularisedBeta(10/(10+tt*tt), 5, 0.5); !approxEqual(got, 0.05, 1e-6) {
+		t.Errorf("Expected 0.05, got %v", got)
+	}
+}
+
+func TestFitLinearTrend(t *testing.T) {
+	fit, err := FitLinearTrend(trendPoints(1, 3, 2, 5, 4))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	// Slope 0.8 per year, t = 0.8/sqrt(0.1) with 3 df
+	if !approxEqual(fit.Slope, 0.8, 1e-12) || !approxEqual(fit.Intercept, 3-0.8*2002, 1e-9) {
+		t.Errorf("Unexpected fit %+v", fit)
+	}
+	if !approxEqual(fit.PValue, 0.1040880302, 1e-6) {
+		t.Errorf("Expected p-value 0.1041, got %v", fit.PValue)
+	}
+
+	exact, _ := FitLinearTrend(trendPoints(1, 2, 3))
+	if exact.PValue != 0 {
+		t.Errorf("Expected zero p-value for an exact fit, got %v", exact.PValue)
+	}
+	if _, err := FitLinearTrend(trendPoints(1, 2)); err == nil {
+		t.Error("Expected error for too few points")
+	}
+}
+
+func TestMannKendallTest(t *testing.T) {
+	mk, err := MannKendallTest(trendPoints(1, 3, 2, 5, 4))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	// 8 increasing and 2 decreasing pairs; Var(S) = 5*4*15/18
+	if mk.S != 6 || !approxEqual(mk.Tau, 0.6, 1e-12) {
+		t.Errorf("Unexpected S %d and tau %v", mk.S, mk.Tau)
+	}
+	z := 5 / math.Sqrt(50.0/3)
+	if !approxEqual(mk.Z, z, 1e-12) || !approxEqual(mk.PValue, math.Erfc(z/math.Sqrt2), 1e-12) {
+		t.Errorf("Unexpected Z %v and p-value %v", mk.Z, mk.PValue)
+	}
+
+	// Ties reduce the variance: values 1, 1, 2 have Var(S) = (66 - 2*1*9)/18
+	tied, _ := MannKendallTest(trendPoints(1, 1, 2))
+	if tied.S != 2 || !approxEqual(tied.Z, 1/math.Sqrt(48.0/18), 1e-12) {
+		t.Errorf("Unexpected tied result %+v", tied)
+	}
+
+	flat, _ := MannKendallTest(trendPoints(4, 4, 4))
+	if flat.S != 0 || flat.Z != 0 || flat.PValue != 1 {
+		t.Errorf("Expected no trend for a constant series, got %+v", flat)
+	}
+}
+
+func TestSensSlope(t *testing.T) {
+	// The ten pairwise slopes of 1, 3, 2, 5, 4 have middle values 0.75 and 1
+	slope, err := SensSlope(trendPoints(1, 3, 2, 5, 4))
+	if err != nil || slope != 0.875 {
+		t.Errorf("Expected slope 0.875, got %v (%v)", slope, err)
+	}
+	// Gaps between years are respected
+	slope, _ = SensSlope([]TrendPoint{{Year: 2000, Value: 0}, {Year: 2010, Value: 10}})
+	if slope != 1 {
+		t.Errorf("Expected slope 1 across a gap, got %v", slope)
+	}
+	if _, err := SensSlope(trendPoints(1)); err == nil {
+		t.Error("Expected error for a single point")
+	}
+}
+
+func TestAnalyseTrends(t *testing.T) {
+	var data WeatherData
+	for i := 0; i < 12; i++ {
+		year := WeatherDataForYear{
+			Year:                 strconv.Itoa(2000 + i),
+			TotalRainfall:        strconv.Itoa(500 + 10*i),
+			DaysWithRainfall:     strconv.Itoa(100),
+			AdditionalStatistics: map[string]string{"MaximumDailyRainfall": strconv.Itoa(50 - i)},
+			MonthlyAggregates: MonthlyAggregates{WeatherDataForMonth: []WeatherDataForMonth{
+				{Month: "January", TotalRainfall: strconv.Itoa(40 + i)},
+			}},
+		}
+		if i == 5 {
+			year.Unreliable = "true"
+		}
+		data.WeatherDataForYear = append(data.WeatherDataForYear, year)
+	}
+
+	opts := DefaultTrendOptions()
+	opts.MinYears = 10
+	series, err := AnalyseTrends(data, opts)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(series) != 15 {
+		t.Fatalf("Expected 3 yearly and 12 monthly series, got %d", len(series))
+	}
+
+	total := series[0]
+	if total.Name != "TotalRainfall" || len(total.Points) != 11 || len(total.ExcludedYears) != 1 || total.ExcludedYears[0] != 2005 {
+		t.Errorf("Unexpected total series %+v", total)
+	}
+	if !total.Tested || !approxEqual(total.Linear.Slope, 10, 1e-9) || total.SensSlope != 10 || total.MannKendall.S != 55 {
+		t.Errorf("Expected an increasing trend of 10 per year, got %+v", total)
+	}
+	if max := series[2]; max.Name != "MaximumDailyRainfall" || max.SensSlope != -1 {
+		t.Errorf("Expected a decreasing maximum daily fall, got %+v", max)
+	}
+	if jan := series[3]; jan.Month != time.January || !jan.Tested || jan.SensSlope != 1 {
+		t.Errorf("Unexpected January series %+v", jan)
+	}
+	if feb := series[4]; feb.Month != time.February || feb.Tested {
+		t.Errorf("Expected February to have too few years, got %+v", feb)
+	}
+
+	// As of mid-January 2011, neither 2011 nor its January has finished
+	opts.Clock = FixedClock{Time: time.Date(2011, 1, 15, 0, 0, 0, 0, time.UTC)}
+	series, err = AnalyseTrends(data, opts)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if total := series[0]; len(total.Points) != 10 || total.Points[len(total.Points)-1].Year != 2010 || len(total.ExcludedYears) != 1 {
+		t.Errorf("Expected 2011 left out of the total series, got %+v", total)
+	}
+	if jan := series[3]; len(jan.Points) != 11 || jan.Points[len(jan.Points)-1].Year != 2010 {
+		t.Errorf("Expected January 2011 left out of the January series, got %+v", jan)
+	}
+	opts.Clock = nil
+
+	data.WeatherDataForYear[0].TotalRainfall = "lots"
+	if _, err := AnalyseTrends(data, opts); err == nil {
+		t.Error("Expected error for an invalid value")
+	}
+}
+
+func TestNewTrendData(t *testing.T) {
+	opts := DefaultTrendOptions()
+	opts.From = time.Date(1910, 1, 1, 0, 0, 0, 0, time.UTC)
+	series := []TrendSeries{{
+		Name:        "TotalRainfall",
+		Points:      trendPoints(1, 3, 2, 5, 4),
+		Tested:      true,
+		Linear:      LinearTrend{Slope: 0.8, PValue: 0.104},
+		MannKendall: MannKendall{S: 6, Tau: 0.6, Z: 1.22, PValue: 0.22},
+		SensSlope:   1,
+	}, {
+		Name:  "TotalRainfall",
+		Month: time.March,
+	}}
+	data := NewTrendData(series, opts)
+
+	if data.From != "1910-01-01" || data.To != "" || len(data.Trends) != 2 {
+		t.Fatalf("Unexpected trend data %+v", data)
+	}
+	total := data.Trends[0]
+	if total.FirstYear != "2000" || total.LastYear != "2004" || total.SensSlopePerDecade != "10.000" {
+		t.Errorf("Unexpected series %+v", total)
+	}
+	if total.LinearRegression.SlopePerDecade != "8.000" || total.LinearRegression.Significant != "false" {
+		t.Errorf("Unexpected linear regression %+v", total.LinearRegression)
+	}
+	if march := data.Trends[1]; march.Month != "March" || march.LinearRegression != nil || march.MannKendall != nil {
+		t.Errorf("Expected untested March series, got %+v", march)
+	}
+}
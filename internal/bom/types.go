@@ -1,25 +1,91 @@
 package bom
 
 import (
+	"encoding/json"
+	"encoding/xml"
 	"time"
 )
 
 // WeatherData represents the root structure of the JSON output
 type WeatherData struct {
 	WeatherDataForYear []WeatherDataForYear `json:"WeatherData"`
+	// InterannualVariability is the spread of each calendar month's total
+	// across years
+	InterannualVariability []CalendarMonthVariability `json:"InterannualVariability,omitempty"`
+	Metadata               *Metadata                  `json:"Metadata,omitempty"`
+	// Station is the BOM station number of the records, or empty when they
+	// name none or several. It is used by line-oriented encoders and is not
+	// part of the JSON document.
+	Station string `json:"-"`
+}
+
+// CalendarMonthVariability describes how a calendar month's total rainfall
+// varies from year to year
+type CalendarMonthVariability struct {
+	Month                  string `json:"Month"`
+	Years                  string `json:"Years"`
+	FirstYear              string `json:"FirstYear"`
+	LastYear               string `json:"LastYear"`
+	MeanTotalRainfall      string `json:"MeanTotalRainfall"`
+	StdDevTotalRainfall    string `json:"StdDevTotalRainfall,omitempty" xml:"StdDevTotalRainfall,omitempty"`
+	CoefficientOfVariation string `json:"CoefficientOfVariation,omitempty" xml:"CoefficientOfVariation,omitempty"`
+}
+
+// Metadata describes options that changed which data went into the output
+type Metadata struct {
+	Filter *FilterMetadata `json:"Filter,omitempty" xml:"Filter,omitempty"`
+	Median *MedianMetadata `json:"Median,omitempty" xml:"Median,omitempty"`
+	// Imputation records how missing days were filled
+	Imputation *ImputationMetadata `json:"Imputation,omitempty" xml:"Imputation,omitempty"`
+	// Statistics lists the statistics computed, when they are not the
+	// standard ones
+	Statistics []string `json:"Statistics,omitempty"`
+}
+
+// FilterMetadata records the date filter applied before aggregation
+type FilterMetadata struct {
+	From   string   `json:"From,omitempty"`
+	To     string   `json:"To,omitempty"`
+	Years  []string `json:"Years,omitempty"`
+	Months []string `json:"Months,omitempty"`
+}
+
+// MedianMetadata records the definition of MedianDailyRainfall
+type MedianMetadata struct {
+	Mode             string `json:"Mode"`
+	RainDayThreshold string `json:"RainDayThreshold,omitempty" xml:"RainDayThreshold,omitempty"`
+}
+
+// ImputationMetadata records the methods used to fill missing days
+type ImputationMetadata struct {
+	Methods         []string `json:"Methods" xml:"Methods>Method"`
+	NeighbourScale  string   `json:"NeighbourScale,omitempty" xml:"NeighbourScale,omitempty"`
+	NeighbourDays   string   `json:"NeighbourDays,omitempty" xml:"NeighbourDays,omitempty"`
+	ClimatologyDays string   `json:"ClimatologyDays,omitempty" xml:"ClimatologyDays,omitempty"`
 }
 
 // WeatherDataForYear represents yearly weather data
 type WeatherDataForYear struct {
-	Year                 string            `json:"Year"`
-	FirstRecordedDate    string            `json:"FirstRecordedDate"`
-	LastRecordedDate     string            `json:"LastRecordedDate"`
-	TotalRainfall        string            `json:"TotalRainfall"`
-	AverageDailyRainfall string            `json:"AverageDailyRainfall"`
-	DaysWithNoRainfall   string            `json:"DaysWithNoRainfall"`
-	DaysWithRainfall     string            `json:"DaysWithRainfall"`
-	LongestDaysRaining   string            `json:"LongestDaysRaining"`
-	MonthlyAggregates    MonthlyAggregates `json:"MonthlyAggregates"`
+	Year                   string            `json:"Year"`
+	FirstRecordedDate      string            `json:"FirstRecordedDate"`
+	LastRecordedDate       string            `json:"LastRecordedDate"`
+	TotalRainfall          string            `json:"TotalRainfall,omitempty"`
+	AverageDailyRainfall   string            `json:"AverageDailyRainfall,omitempty"`
+	MedianDailyRainfall    string            `json:"MedianDailyRainfall,omitempty"`
+	DaysWithNoRainfall     string            `json:"DaysWithNoRainfall,omitempty"`
+	DaysWithRainfall       string            `json:"DaysWithRainfall,omitempty"`
+	LongestDaysRaining     string            `json:"LongestDaysRaining,omitempty"`
+	VarianceDailyRainfall  string            `json:"VarianceDailyRainfall,omitempty"`
+	StdDevDailyRainfall    string            `json:"StdDevDailyRainfall,omitempty"`
+	CoefficientOfVariation string            `json:"CoefficientOfVariation,omitempty"`
+	ExpectedDays           string            `json:"ExpectedDays"`
+	RecordedDays           string            `json:"RecordedDays"`
+	PercentComplete        string            `json:"PercentComplete"`
+	ImputedDays            string            `json:"ImputedDays,omitempty"`
+	ImputedRainfall        string            `json:"ImputedRainfall,omitempty"`
+	Unreliable             string            `json:"Unreliable,omitempty"`
+	AdditionalStatistics   map[string]string `json:"AdditionalStatistics,omitempty"`
+	MonthlyAggregates      MonthlyAggregates `json:"MonthlyAggregates"`
 }
 
 // MonthlyAggregates contains monthly weather data
@@ -29,21 +95,317 @@ type MonthlyAggregates struct {
 
 // WeatherDataForMonth represents monthly weather data
 type WeatherDataForMonth struct {
-	Month                string `json:"Month"`
-	FirstRecordedDate    string `json:"FirstRecordedDate"`
-	LastRecordedDate     string `json:"LastRecordedDate"`
-	TotalRainfall        string `json:"TotalRainfall"`
-	AverageDailyRainfall string `json:"AverageDailyRainfall"`
-	MedianDailyRainfall  string `json:"MedianDailyRainfall"`
-	DaysWithNoRainfall   string `json:"DaysWithNoRainfall"`
-	DaysWithRainfall     string `json:"DaysWithRainfall"`
+	Month                  string            `json:"Month"`
+	FirstRecordedDate      string            `json:"FirstRecordedDate"`
+	LastRecordedDate       string            `json:"LastRecordedDate"`
+	TotalRainfall          string            `json:"TotalRainfall,omitempty"`
+	AverageDailyRainfall   string            `json:"AverageDailyRainfall,omitempty"`
+	MedianDailyRainfall    string            `json:"MedianDailyRainfall,omitempty"`
+	DaysWithNoRainfall     string            `json:"DaysWithNoRainfall,omitempty"`
+	DaysWithRainfall       string            `json:"DaysWithRainfall,omitempty"`
+	VarianceDailyRainfall  string            `json:"VarianceDailyRainfall,omitempty"`
+	StdDevDailyRainfall    string            `json:"StdDevDailyRainfall,omitempty"`
+	CoefficientOfVariation string            `json:"CoefficientOfVariation,omitempty"`
+	ExpectedDays           string            `json:"ExpectedDays"`
+	RecordedDays           string            `json:"RecordedDays"`
+	PercentComplete        string            `json:"PercentComplete"`
+	ImputedDays            string            `json:"ImputedDays,omitempty"`
+	ImputedRainfall        string            `json:"ImputedRainfall,omitempty"`
+	Unreliable             string            `json:"Unreliable,omitempty"`
+	AdditionalStatistics   map[string]string `json:"AdditionalStatistics,omitempty"`
+	DailyRecords           []DailyValue      `json:"DailyRecords,omitempty"`
+}
+
+// DailyValue represents one day of a month's daily records. Rainfall is
+// null for a day without a reading.
+type DailyValue struct {
+	Date     string  `json:"Date"`
+	Rainfall *string `json:"Rainfall"`
+	Quality  string  `json:"Quality,omitempty"`
+	Period   string  `json:"Period,omitempty"`
+	Imputed  string  `json:"Imputed,omitempty"`
+}
+
+// WeatherDataV2 represents the root structure of the v2 JSON output. Values
+// are typed, statistics that are unavailable are null and statistics that
+// were not computed are omitted.
+type WeatherDataV2 struct {
+	SchemaVersion          int                          `json:"schemaVersion"`
+	WeatherDataForYear     []WeatherDataForYearV2       `json:"WeatherData"`
+	InterannualVariability []CalendarMonthVariabilityV2 `json:"InterannualVariability,omitempty"`
+	Metadata               *MetadataV2                  `json:"Metadata,omitempty"`
+}
+
+// MetadataV2 is Metadata in the v2 schema, with typed values
+type MetadataV2 struct {
+	Filter     *FilterMetadataV2     `json:"Filter,omitempty"`
+	Median     *MedianMetadataV2     `json:"Median,omitempty"`
+	Imputation *ImputationMetadataV2 `json:"Imputation,omitempty"`
+	Statistics []string              `json:"Statistics,omitempty"`
+}
+
+// FilterMetadataV2 is FilterMetadata in the v2 schema. Months are month
+// numbers, 1 to 12.
+type FilterMetadataV2 struct {
+	From   string `json:"From,omitempty"`
+	To     string `json:"To,omitempty"`
+	Years  []int  `json:"Years,omitempty"`
+	Months []int  `json:"Months,omitempty"`
+}
+
+// MedianMetadataV2 is MedianMetadata in the v2 schema
+type MedianMetadataV2 struct {
+	Mode             string       `json:"Mode"`
+	RainDayThreshold *json.Number `json:"RainDayThreshold,omitempty"`
+}
+
+// ImputationMetadataV2 is ImputationMetadata in the v2 schema
+type ImputationMetadataV2 struct {
+	Methods         []string     `json:"Methods"`
+	NeighbourScale  *json.Number `json:"NeighbourScale,omitempty"`
+	NeighbourDays   *int         `json:"NeighbourDays,omitempty"`
+	ClimatologyDays *int         `json:"ClimatologyDays,omitempty"`
+}
+
+// NullNumber is a computed statistic in the v2 schema: a number, or null
+// when the statistic is unavailable for the period. encoding/json decodes
+// null into a nil *NullNumber, so decoded statistics that were null or
+// omitted are both nil.
+type NullNumber struct {
+	Number json.Number
+	Valid  bool
+}
+
+// NullInt is a computed count in the v2 schema: an integer, or null when
+// the count is unavailable for the period
+type NullInt struct {
+	Int   int
+	Valid bool
+}
+
+// StatisticValueV2 is the result of an additional statistic in the v2
+// schema: a number when the result is numeric, otherwise its text, or null
+// when the result is unavailable
+type StatisticValueV2 struct {
+	Number json.Number
+	Text   string
+}
+
+// WeatherDataForYearV2 represents yearly weather data in the v2 schema
+type WeatherDataForYearV2 struct {
+	Year                   int                         `json:"Year"`
+	FirstRecordedDate      string                      `json:"FirstRecordedDate"`
+	LastRecordedDate       string                      `json:"LastRecordedDate"`
+	TotalRainfall          *NullNumber                 `json:"TotalRainfall,omitempty"`
+	AverageDailyRainfall   *NullNumber                 `json:"AverageDailyRainfall,omitempty"`
+	MedianDailyRainfall    *NullNumber                 `json:"MedianDailyRainfall,omitempty"`
+	DaysWithNoRainfall     *NullInt                    `json:"DaysWithNoRainfall,omitempty"`
+	DaysWithRainfall       *NullInt                    `json:"DaysWithRainfall,omitempty"`
+	LongestDaysRaining     *NullInt                    `json:"LongestDaysRaining,omitempty"`
+	VarianceDailyRainfall  *NullNumber                 `json:"VarianceDailyRainfall,omitempty"`
+	StdDevDailyRainfall    *NullNumber                 `json:"StdDevDailyRainfall,omitempty"`
+	CoefficientOfVariation *NullNumber                 `json:"CoefficientOfVariation,omitempty"`
+	ExpectedDays           int                         `json:"ExpectedDays"`
+	RecordedDays           int                         `json:"RecordedDays"`
+	PercentComplete        json.Number                 `json:"PercentComplete"`
+	ImputedDays            *int                        `json:"ImputedDays,omitempty"`
+	ImputedRainfall        *json.Number                `json:"ImputedRainfall,omitempty"`
+	Unreliable             bool                        `json:"Unreliable"`
+	AdditionalStatistics   map[string]StatisticValueV2 `json:"AdditionalStatistics,omitempty"`
+	MonthlyAggregates      []WeatherDataForMonthV2     `json:"MonthlyAggregates"`
+}
+
+// WeatherDataForMonthV2 represents monthly weather data in the v2 schema.
+// Month is an ISO 8601 year and month such as 2019-01.
+type WeatherDataForMonthV2 struct {
+	Month                  string                      `json:"Month"`
+	FirstRecordedDate      string                      `json:"FirstRecordedDate"`
+	LastRecordedDate       string                      `json:"LastRecordedDate"`
+	TotalRainfall          *NullNumber                 `json:"TotalRainfall,omitempty"`
+	AverageDailyRainfall   *NullNumber                 `json:"AverageDailyRainfall,omitempty"`
+	MedianDailyRainfall    *NullNumber                 `json:"MedianDailyRainfall,omitempty"`
+	DaysWithNoRainfall     *NullInt                    `json:"DaysWithNoRainfall,omitempty"`
+	DaysWithRainfall       *NullInt                    `json:"DaysWithRainfall,omitempty"`
+	VarianceDailyRainfall  *NullNumber                 `json:"VarianceDailyRainfall,omitempty"`
+	StdDevDailyRainfall    *NullNumber                 `json:"StdDevDailyRainfall,omitempty"`
+	CoefficientOfVariation *NullNumber                 `json:"CoefficientOfVariation,omitempty"`
+	ExpectedDays           int                         `json:"ExpectedDays"`
+	RecordedDays           int                         `json:"RecordedDays"`
+	PercentComplete        json.Number                 `json:"PercentComplete"`
+	ImputedDays            *int                        `json:"ImputedDays,omitempty"`
+	ImputedRainfall        *json.Number                `json:"ImputedRainfall,omitempty"`
+	Unreliable             bool                        `json:"Unreliable"`
+	AdditionalStatistics   map[string]StatisticValueV2 `json:"AdditionalStatistics,omitempty"`
+	DailyRecords           []DailyValueV2              `json:"DailyRecords,omitempty"`
+}
+
+// DailyValueV2 represents one day of a month's daily records in the v2
+// schema. Values that were not given are null.
+type DailyValueV2 struct {
+	Date     string       `json:"Date"`
+	Rainfall *json.Number `json:"Rainfall"`
+	Quality  *string      `json:"Quality"`
+	Period   *int         `json:"Period"`
+	Imputed  string       `json:"Imputed,omitempty"`
+}
+
+// CalendarMonthVariabilityV2 represents the variability of a calendar
+// month's total in the v2 schema. Month is the month number, 1 to 12.
+type CalendarMonthVariabilityV2 struct {
+	Month                  int          `json:"Month"`
+	Years                  int          `json:"Years"`
+	FirstYear              int          `json:"FirstYear"`
+	LastYear               int          `json:"LastYear"`
+	MeanTotalRainfall      json.Number  `json:"MeanTotalRainfall"`
+	StdDevTotalRainfall    *json.Number `json:"StdDevTotalRainfall"`
+	CoefficientOfVariation *json.Number `json:"CoefficientOfVariation"`
+}
+
+// NDJSONYear is the NDJSON line of a year. Its months are written as lines
+// of their own, so MonthlyAggregates is left out.
+type NDJSONYear struct {
+	Type    string `json:"Type"`
+	Station string `json:"Station,omitempty"`
+	*WeatherDataForYear
+	MonthlyAggregates *struct{} `json:"MonthlyAggregates,omitempty"`
+}
+
+// NDJSONMonth is the NDJSON line of a month, with its year denormalised
+type NDJSONMonth struct {
+	Type    string `json:"Type"`
+	Station string `json:"Station,omitempty"`
+	Year    string `json:"Year"`
+	*WeatherDataForMonth
+}
+
+// NDJSONYearV2 is the NDJSON line of a year in the v2 schema
+type NDJSONYearV2 struct {
+	Type    string `json:"Type"`
+	Station string `json:"Station,omitempty"`
+	*WeatherDataForYearV2
+	MonthlyAggregates *struct{} `json:"MonthlyAggregates,omitempty"`
+}
+
+// NDJSONMonthV2 is the NDJSON line of a month in the v2 schema
+type NDJSONMonthV2 struct {
+	Type    string `json:"Type"`
+	Station string `json:"Station,omitempty"`
+	Year    int    `json:"Year"`
+	*WeatherDataForMonthV2
+}
+
+// WeatherDataXML is the XML form of WeatherData, described by the schema
+// returned by WeatherDataXSD. Elements carry the names of the JSON keys and
+// statistics that are unavailable are omitted.
+type WeatherDataXML struct {
+	XMLName                xml.Name                   `xml:"WeatherData"`
+	Station                string                     `xml:"Station,attr,omitempty"`
+	WeatherDataForYear     []WeatherDataForYearXML    `xml:"WeatherDataForYear"`
+	InterannualVariability *InterannualVariabilityXML `xml:"InterannualVariability,omitempty"`
+	Metadata               *Metadata                  `xml:"Metadata,omitempty"`
+}
+
+// WeatherDataForYearXML represents yearly weather data in XML output
+type WeatherDataForYearXML struct {
+	Year                   string                   `xml:"Year"`
+	FirstRecordedDate      string                   `xml:"FirstRecordedDate"`
+	LastRecordedDate       string                   `xml:"LastRecordedDate"`
+	TotalRainfall          string                   `xml:"TotalRainfall,omitempty"`
+	AverageDailyRainfall   string                   `xml:"AverageDailyRainfall,omitempty"`
+	MedianDailyRainfall    string                   `xml:"MedianDailyRainfall,omitempty"`
+	DaysWithNoRainfall     string                   `xml:"DaysWithNoRainfall,omitempty"`
+	DaysWithRainfall       string                   `xml:"DaysWithRainfall,omitempty"`
+	LongestDaysRaining     string                   `xml:"LongestDaysRaining,omitempty"`
+	VarianceDailyRainfall  string                   `xml:"VarianceDailyRainfall,omitempty"`
+	StdDevDailyRainfall    string                   `xml:"StdDevDailyRainfall,omitempty"`
+	CoefficientOfVariation string                   `xml:"CoefficientOfVariation,omitempty"`
+	ExpectedDays           string                   `xml:"ExpectedDays"`
+	RecordedDays           string                   `xml:"RecordedDays"`
+	PercentComplete        string                   `xml:"PercentComplete"`
+	ImputedDays            string                   `xml:"ImputedDays,omitempty"`
+	ImputedRainfall        string                   `xml:"ImputedRainfall,omitempty"`
+	Unreliable             string                   `xml:"Unreliable,omitempty"`
+	AdditionalStatistics   *AdditionalStatisticsXML `xml:"AdditionalStatistics,omitempty"`
+	MonthlyAggregates      MonthlyAggregatesXML     `xml:"MonthlyAggregates"`
+}
+
+// MonthlyAggregatesXML contains monthly weather data in XML output
+type MonthlyAggregatesXML struct {
+	WeatherDataForMonth []WeatherDataForMonthXML `xml:"WeatherDataForMonth"`
+}
+
+// WeatherDataForMonthXML represents monthly weather data in XML output
+type WeatherDataForMonthXML struct {
+	Month                  string                   `xml:"Month"`
+	FirstRecordedDate      string                   `xml:"FirstRecordedDate"`
+	LastRecordedDate       string                   `xml:"LastRecordedDate"`
+	TotalRainfall          string                   `xml:"TotalRainfall,omitempty"`
+	AverageDailyRainfall   string                   `xml:"AverageDailyRainfall,omitempty"`
+	MedianDailyRainfall    string                   `xml:"MedianDailyRainfall,omitempty"`
+	DaysWithNoRainfall     string                   `xml:"DaysWithNoRainfall,omitempty"`
+	DaysWithRainfall       string                   `xml:"DaysWithRainfall,omitempty"`
+	VarianceDailyRainfall  string                   `xml:"VarianceDailyRainfall,omitempty"`
+	StdDevDailyRainfall    string                   `xml:"StdDevDailyRainfall,omitempty"`
+	CoefficientOfVariation string                   `xml:"CoefficientOfVariation,omitempty"`
+	ExpectedDays           string                   `xml:"ExpectedDays"`
+	RecordedDays           string                   `xml:"RecordedDays"`
+	PercentComplete        string                   `xml:"PercentComplete"`
+	ImputedDays            string                   `xml:"ImputedDays,omitempty"`
+	ImputedRainfall        string                   `xml:"ImputedRainfall,omitempty"`
+	Unreliable             string                   `xml:"Unreliable,omitempty"`
+	AdditionalStatistics   *AdditionalStatisticsXML `xml:"AdditionalStatistics,omitempty"`
+	DailyRecords           *DailyRecordsXML         `xml:"DailyRecords,omitempty"`
+}
+
+// InterannualVariabilityXML contains the variability of each calendar month
+type InterannualVariabilityXML struct {
+	CalendarMonthVariability []CalendarMonthVariability `xml:"CalendarMonthVariability"`
+}
+
+// AdditionalStatisticsXML contains a period's additional statistics
+type AdditionalStatisticsXML struct {
+	Statistic []StatisticXML `xml:"Statistic"`
+}
+
+// DailyRecordsXML contains a month's daily records
+type DailyRecordsXML struct {
+	Day []DailyValueXML `xml:"Day"`
+}
+
+// StatisticXML is one additional statistic. Value is omitted when the
+// statistic is unavailable.
+type StatisticXML struct {
+	Name  string `xml:"Name,attr"`
+	Value string `xml:"Value,attr,omitempty"`
+}
+
+// DailyValueXML represents one day of a month's daily records in XML output.
+// Rainfall is omitted for a day without a reading.
+type DailyValueXML struct {
+	Date     string `xml:"Date"`
+	Rainfall string `xml:"Rainfall,omitempty"`
+	Quality  string `xml:"Quality,omitempty"`
+	Period   string `xml:"Period,omitempty"`
+	Imputed  string `xml:"Imputed,omitempty"`
 }
 
 // DailyRecord represents a single day's weather record
 type DailyRecord struct {
-	Date     time.Time
-	Rainfall float64
+	Date time.Time
+	// Rainfall is the reading, held exactly in hundredths of a millimetre
+	Rainfall Amount
 	HasData  bool
+	// Imputed is the method used to estimate the reading, or empty for an
+	// observed day
+	Imputed ImputationMethod
+	// Period is the number of days the reading was accumulated over, or
+	// zero when not given
+	Period int
+	// Quality is BOM's quality flag: Y when quality controlled, N when not
+	// yet checked
+	Quality string
+	// Station is the BOM station number, or empty when not known
+	Station string
 }
 
 // MonthData represents aggregated data for a month
@@ -70,3 +432,195 @@ type YearData struct {
 	LastRecordedDate   time.Time
 	Months             map[time.Time]*MonthData
 }
+
+// ExtremesData represents the root structure of the extreme indices JSON output
+type ExtremesData struct {
+	ExtremesForYear []ExtremesForYear `json:"Extremes"`
+}
+
+// ExtremesForYear holds the maximum N-day accumulations for a year
+type ExtremesForYear struct {
+	Year   string        `json:"Year"`
+	Rx1day *Accumulation `json:"Rx1day"`
+	Rx3day *Accumulation `json:"Rx3day"`
+	Rx5day *Accumulation `json:"Rx5day"`
+	Rx7day *Accumulation `json:"Rx7day"`
+}
+
+// Accumulation represents rainfall accumulated over a run of days
+type Accumulation struct {
+	TotalRainfall string `json:"TotalRainfall"`
+	StartDate     string `json:"StartDate"`
+	EndDate       string `json:"EndDate"`
+}
+
+// SPIData represents the root structure of the SPI JSON output
+type SPIData struct {
+	Scale           string        `json:"Scale"`
+	BaselineYears   []string      `json:"BaselineYears,omitempty"`
+	MinCompleteness string        `json:"MinCompleteness"`
+	Fits            []SPIFitData  `json:"Fits"`
+	SPI             []SPIForMonth `json:"SPI"`
+}
+
+// SPIFitData represents the distribution fitted to one calendar month
+type SPIFitData struct {
+	Month           string `json:"Month"`
+	Samples         string `json:"Samples"`
+	ZeroProbability string `json:"ZeroProbability"`
+	Shape           string `json:"Shape,omitempty"`
+	Scale           string `json:"Scale,omitempty"`
+}
+
+// SPIForMonth represents the SPI of the accumulation ending in a month
+type SPIForMonth struct {
+	Year          string `json:"Year"`
+	Month         string `json:"Month"`
+	TotalRainfall string `json:"TotalRainfall,omitempty"`
+	SPI           string `json:"SPI,omitempty"`
+	Category      string `json:"Category,omitempty"`
+}
+
+// DeficiencyData represents the root structure of the rainfall deficiency JSON output
+type DeficiencyData struct {
+	Durations         []string               `json:"Durations"`
+	MinCompleteness   string                 `json:"MinCompleteness"`
+	SeriousPercentile string                 `json:"SeriousPercentile"`
+	SeverePercentile  string                 `json:"SeverePercentile"`
+	Periods           []DeficiencyPeriodData `json:"Periods"`
+}
+
+// DeficiencyPeriodData represents a serious or severe rainfall deficiency
+type DeficiencyPeriodData struct {
+	StartDate      string `json:"StartDate"`
+	EndDate        string `json:"EndDate"`
+	Months         string `json:"Months"`
+	TotalRainfall  string `json:"TotalRainfall"`
+	PercentileRank string `json:"PercentileRank"`
+	Comparisons    string `json:"Comparisons"`
+	Severity       string `json:"Severity"`
+}
+
+// TrendData represents the root structure of the trend analysis JSON output
+type TrendData struct {
+	From            string            `json:"From,omitempty"`
+	To              string            `json:"To,omitempty"`
+	MinCompleteness string            `json:"MinCompleteness"`
+	Significance    string            `json:"Significance"`
+	Trends          []TrendSeriesData `json:"Trends"`
+}
+
+// TrendSeriesData represents the trend tests of one yearly series
+type TrendSeriesData struct {
+	Series             string                `json:"Series"`
+	Month              string                `json:"Month,omitempty"`
+	Years              string                `json:"Years"`
+	FirstYear          string                `json:"FirstYear,omitempty"`
+	LastYear           string                `json:"LastYear,omitempty"`
+	ExcludedYears      []string              `json:"ExcludedYears,omitempty"`
+	LinearRegression   *LinearRegressionData `json:"LinearRegression,omitempty"`
+	MannKendall        *MannKendallData      `json:"MannKendall,omitempty"`
+	SensSlopePerDecade string                `json:"SensSlopePerDecade,omitempty"`
+}
+
+// LinearRegressionData represents an ordinary least-squares trend
+type LinearRegressionData struct {
+	SlopePerDecade string `json:"SlopePerDecade"`
+	PValue         string `json:"PValue"`
+	Significant    string `json:"Significant"`
+}
+
+// MannKendallData represents the result of a Mann-Kendall test
+type MannKendallData struct {
+	S           string `json:"S"`
+	Tau         string `json:"Tau"`
+	Z           string `json:"Z"`
+	PValue      string `json:"PValue"`
+	Significant string `json:"Significant"`
+}
+
+// ComparisonData represents the root structure of the station comparison JSON output
+type ComparisonData struct {
+	StationA           string                        `json:"StationA"`
+	StationB           string                        `json:"StationB"`
+	Overlap            OverlapData                   `json:"Overlap"`
+	DailyCorrelation   string                        `json:"DailyCorrelation,omitempty"`
+	MonthlyCorrelation string                        `json:"MonthlyCorrelation,omitempty"`
+	MeanDailyBias      string                        `json:"MeanDailyBias"`
+	TotalRainfallA     string                        `json:"TotalRainfallA"`
+	TotalRainfallB     string                        `json:"TotalRainfallB"`
+	RatioOfTotals      string                        `json:"RatioOfTotals,omitempty"`
+	CalendarMonths     []CalendarMonthComparisonData `json:"CalendarMonths"`
+	Monthly            []MonthComparisonData         `json:"Monthly"`
+}
+
+// OverlapData represents the period recorded at both stations
+type OverlapData struct {
+	FirstDate  string `json:"FirstDate"`
+	LastDate   string `json:"LastDate"`
+	CommonDays string `json:"CommonDays"`
+	OnlyA      string `json:"OnlyA"`
+	OnlyB      string `json:"OnlyB"`
+}
+
+// CalendarMonthComparisonData represents the differences between two
+// stations for one calendar month across years
+type CalendarMonthComparisonData struct {
+	Month          string `json:"Month"`
+	Months         string `json:"Months"`
+	TotalRainfallA string `json:"TotalRainfallA"`
+	TotalRainfallB string `json:"TotalRainfallB"`
+	MeanDifference string `json:"MeanDifference"`
+	RatioOfTotals  string `json:"RatioOfTotals,omitempty"`
+}
+
+// MonthComparisonData represents one row of the joint monthly table
+type MonthComparisonData struct {
+	Year           string `json:"Year"`
+	Month          string `json:"Month"`
+	CommonDays     string `json:"CommonDays"`
+	TotalRainfallA string `json:"TotalRainfallA"`
+	TotalRainfallB string `json:"TotalRainfallB"`
+	Difference     string `json:"Difference"`
+	Ratio          string `json:"Ratio,omitempty"`
+}
+
+// ReturnPeriodData represents the root structure of the return period JSON output
+type ReturnPeriodData struct {
+	Confidence               string                     `json:"Confidence"`
+	MinCompleteness          string                     `json:"MinCompleteness"`
+	ReturnPeriodsForDuration []ReturnPeriodsForDuration `json:"ReturnPeriods"`
+}
+
+// ReturnPeriodsForDuration holds the fitted distributions for one N-day duration
+type ReturnPeriodsForDuration struct {
+	Duration      string                `json:"Duration"`
+	YearsUsed     string                `json:"YearsUsed"`
+	ExcludedYears []string              `json:"ExcludedYears"`
+	AnnualMaxima  []AnnualMaximum       `json:"AnnualMaxima"`
+	Fits          []DistributionFitData `json:"Fits"`
+}
+
+// AnnualMaximum represents the largest N-day total in a year
+type AnnualMaximum struct {
+	Year          string `json:"Year"`
+	TotalRainfall string `json:"TotalRainfall"`
+	StartDate     string `json:"StartDate"`
+}
+
+// DistributionFitData represents a fitted distribution and its estimates
+type DistributionFitData struct {
+	Distribution string                     `json:"Distribution"`
+	Location     string                     `json:"Location"`
+	Scale        string                     `json:"Scale"`
+	Shape        string                     `json:"Shape,omitempty"`
+	Estimates    []ReturnPeriodEstimateData `json:"Estimates"`
+}
+
+// ReturnPeriodEstimateData represents the estimated depth for an average recurrence interval
+type ReturnPeriodEstimateData struct {
+	ARI   string `json:"ARI"`
+	Depth string `json:"Depth"`
+	Lower string `json:"Lower"`
+	Upper string `json:"Upper"`
+}
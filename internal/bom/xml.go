@@ -0,0 +1,179 @@
+package bom
+
+import (
+	_ "embed"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// weatherDataXSD is the XML Schema of the xml output format
+//
+//go:embed weatherdata.xsd
+var weatherDataXSD []byte
+
+// WeatherDataXSD returns the XML Schema that describes the xml output format
+func WeatherDataXSD() []byte {
+	return append([]byte(nil), weatherDataXSD...)
+}
+
+// NewWeatherDataXML converts weather data into its XML structure. Additional
+// statistics are sorted by name so that the output is deterministic.
+func NewWeatherDataXML(data WeatherData) WeatherDataXML {
+	out := WeatherDataXML{
+		Station:            data.Station,
+		WeatherDataForYear: make([]WeatherDataForYearXML, 0, len(data.WeatherDataForYear)),
+		Metadata:           data.Metadata,
+	}
+	if len(data.InterannualVariability) > 0 {
+		out.InterannualVariability = &InterannualVariabilityXML{CalendarMonthVariability: data.InterannualVariability}
+	}
+	for _, y := range data.WeatherDataForYear {
+		year := WeatherDataForYearXML{
+			Year:                   y.Year,
+			FirstRecordedDate:      y.FirstRecordedDate,
+			LastRecordedDate:       y.LastRecordedDate,
+			TotalRainfall:          y.TotalRainfall,
+			AverageDailyRainfall:   y.AverageDailyRainfall,
+			MedianDailyRainfall:    y.MedianDailyRainfall,
+			DaysWithNoRainfall:     y.DaysWithNoRainfall,
+			DaysWithRainfall:       y.DaysWithRainfall,
+			LongestDaysRaining:     y.LongestDaysRaining,
+			VarianceDailyRainfall:  y.VarianceDailyRainfall,
+			StdDevDailyRainfall:    y.StdDevDailyRainfall,
+			CoefficientOfVariation: y.CoefficientOfVariation,
+			ExpectedDays:           y.ExpectedDays,
+			RecordedDays:           y.RecordedDays,
+			PercentComplete:        y.PercentComplete,
+			ImputedDays:            y.ImputedDays,
+			ImputedRainfall:        y.ImputedRainfall,
+			Unreliable:             y.Unreliable,
+			AdditionalStatistics:   newStatisticsXML(y.AdditionalStatistics),
+		}
+		for _, m := range y.MonthlyAggregates.WeatherDataForMonth {
+			year.MonthlyAggregates.WeatherDataForMonth = append(year.MonthlyAggregates.WeatherDataForMonth, newWeatherDataForMonthXML(m))
+		}
+		out.WeatherDataForYear = append(out.WeatherDataForYear, year)
+	}
+	return out
+}
+
+func newWeatherDataForMonthXML(m WeatherDataForMonth) WeatherDataForMonthXML {
+	out := WeatherDataForMonthXML{
+		Month:                  m.Month,
+		FirstRecordedDate:      m.FirstRecordedDate,
+		LastRecordedDate:       m.LastRecordedDate,
+		TotalRainfall:          m.TotalRainfall,
+		AverageDailyRainfall:   m.AverageDailyRainfall,
+		MedianDailyRainfall:    m.MedianDailyRainfall,
+		DaysWithNoRainfall:     m.DaysWithNoRainfall,
+		DaysWithRainfall:       m.DaysWithRainfall,
+		VarianceDailyRainfall:  m.VarianceDailyRainfall,
+		StdDevDailyRainfall:    m.StdDevDailyRainfall,
+		CoefficientOfVariation: m.CoefficientOfVariation,
+		ExpectedDays:           m.ExpectedDays,
+		RecordedDays:           m.RecordedDays,
+		PercentComplete:        m.PercentComplete,
+		ImputedDays:            m.ImputedDays,
+		ImputedRainfall:        m.ImputedRainfall,
+		Unreliable:             m.Unreliable,
+		AdditionalStatistics:   newStatisticsXML(m.AdditionalStatistics),
+	}
+	if len(m.DailyRecords) == 0 {
+		return out
+	}
+	out.DailyRecords = &DailyRecordsXML{}
+	for _, d := range m.DailyRecords {
+		day := DailyValueXML{Date: d.Date, Quality: d.Quality, Period: d.Period, Imputed: d.Imputed}
+		if d.Rainfall != nil {
+			day.Rainfall = *d.Rainfall
+		}
+		out.DailyRecords.Day = append(out.DailyRecords.Day, day)
+	}
+	return out
+}
+
+// newStatisticsXML lists additional statistics sorted by name
+func newStatisticsXML(values map[string]string) *AdditionalStatisticsXML {
+	if len(values) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := &AdditionalStatisticsXML{Statistic: make([]StatisticXML, 0, len(names))}
+	for _, name := range names {
+		out.Statistic = append(out.Statistic, StatisticXML{Name: name, Value: values[name]})
+	}
+	return out
+}
+
+// writeXML writes weather data as an indented XML document
+func writeXML(w io.Writer, data WeatherData) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write XML: %w", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(NewWeatherDataXML(data)); err != nil {
+		return fmt.Errorf("failed to write XML: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write XML: %w", err)
+	}
+	return nil
+}
+
+// metadataXML is the XML form of Metadata. Statistics is a pointer so that
+// the element is omitted when the standard statistics were computed.
+type metadataXML struct {
+	Filter     *FilterMetadata     `xml:"Filter,omitempty"`
+	Median     *MedianMetadata     `xml:"Median,omitempty"`
+	Imputation *ImputationMetadata `xml:"Imputation,omitempty"`
+	Statistics *struct {
+		Statistic []string `xml:"Statistic"`
+	} `xml:"Statistics,omitempty"`
+}
+
+// MarshalXML writes the metadata with a Statistic element per statistic
+func (m Metadata) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	out := metadataXML{Filter: m.Filter, Median: m.Median, Imputation: m.Imputation}
+	if len(m.Statistics) > 0 {
+		out.Statistics = &struct {
+			Statistic []string `xml:"Statistic"`
+		}{Statistic: m.Statistics}
+	}
+	return e.EncodeElement(out, start)
+}
+
+// filterMetadataXML is the XML form of FilterMetadata. The lists are
+// pointers so that an empty list omits its parent element.
+type filterMetadataXML struct {
+	From  string `xml:"From,omitempty"`
+	To    string `xml:"To,omitempty"`
+	Years *struct {
+		Year []string `xml:"Year"`
+	} `xml:"Years,omitempty"`
+	Months *struct {
+		Month []string `xml:"Month"`
+	} `xml:"Months,omitempty"`
+}
+
+// MarshalXML writes the filter with a Year or Month element per value
+func (f FilterMetadata) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	out := filterMetadataXML{From: f.From, To: f.To}
+	if len(f.Years) > 0 {
+		out.Years = &struct {
+			Year []string `xml:"Year"`
+		}{Year: f.Years}
+	}
+	if len(f.Months) > 0 {
+		out.Months = &struct {
+			Month []string `xml:"Month"`
+		}{Month: f.Months}
+	}
+	return e.EncodeElement(out, start)
+}
@@ -0,0 +1,172 @@
+package bom
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func xmlTestData() WeatherData {
+	data := encoderTestData()
+	data.Station = `066062 "Sydney" & <Observatory Hill>`
+	year := &data.WeatherDataForYear[0]
+	year.AdditionalStatistics = map[string]string{"Wettest": "65.0", "Driest": "", "A&B": "1.0"}
+	rainfall := "8.8"
+	year.MonthlyAggregates.WeatherDataForMonth[0].DailyRecords = []DailyValue{
+		{Date: "2019-01-01", Rainfall: &rainfall, Quality: "Y", Period: "1"},
+		{Date: "2019-01-02", Imputed: "climatology"},
+	}
+	data.InterannualVariability = []CalendarMonthVariability{{Month: "January", Years: "1", FirstYear: "2019", LastYear: "2019", MeanTotalRainfall: "48.8"}}
+	data.Metadata = &Metadata{Filter: &FilterMetadata{Months: []string{"January"}}}
+	return data
+}
+
+func TestNewWeatherDataXML(t *testing.T) {
+	out := NewWeatherDataXML(xmlTestData())
+
+	year := out.WeatherDataForYear[0]
+	var names []string
+	for _, s := range year.AdditionalStatistics.Statistic {
+		names = append(names, s.Name)
+	}
+	if strings.Join(names, ",") != "A&B,Driest,Wettest" {
+		t.Errorf("Expected statistics sorted by name, got %v", names)
+	}
+	if out.WeatherDataForYear[1].AdditionalStatistics != nil {
+		t.Errorf("Expected no AdditionalStatistics element without statistics, got %+v", out.WeatherDataForYear[1].AdditionalStatistics)
+	}
+
+	days := year.MonthlyAggregates.WeatherDataForMonth[0].DailyRecords.Day
+	if len(days) != 2 || days[0].Rainfall != "8.8" || days[1].Rainfall != "" || days[1].Imputed != "climatology" {
+		t.Errorf("Unexpected daily records %+v", days)
+	}
+	if year.MonthlyAggregates.WeatherDataForMonth[1].DailyRecords != nil {
+		t.Error("Expected no DailyRecords element for a month without daily records")
+	}
+}
+
+func TestWriteXML(t *testing.T) {
+	var buf bytes.Buffer
+	conv := NewConverterWithOptions(ConverterOptions{Format: FormatXML})
+	if err := conv.Encode(&buf, xmlTestData()); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	output := buf.String()
+
+	if !strings.HasPrefix(output, xml.Header+"<WeatherData ") {
+		t.Errorf("Expected an XML declaration and a WeatherData root, got: %.80s", output)
+	}
+	for _, want := range []string{
+		`Station="066062 &#34;Sydney&#34; &amp; &lt;Observatory Hill&gt;"`,
+		"<WeatherDataForYear>\n    <Year>2019</Year>",
+		"<MonthlyAggregates>\n      <WeatherDataForMonth>\n        <Month>January</Month>",
+		`<Statistic Name="A&amp;B" Value="1.0"></Statistic>`,
+		`<Statistic Name="Driest"></Statistic>`,
+		"<InterannualVariability>\n    <CalendarMonthVariability>",
+		"<Filter>\n      <Months>\n        <Month>January</Month>",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "<Filter>\n      <Years>") || strings.Contains(output, "<TotalRainfall></TotalRainfall>") {
+		t.Errorf("Expected empty values to be omitted, got:\n%s", output)
+	}
+
+	var decoded WeatherDataXML
+	if err := xml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Output is not valid XML: %v", err)
+	}
+	if decoded.Station != xmlTestData().Station {
+		t.Errorf("Expected the station to round-trip, got %q", decoded.Station)
+	}
+	if !reflect.DeepEqual(decoded.WeatherDataForYear, NewWeatherDataXML(xmlTestData()).WeatherDataForYear) {
+		t.Errorf("Expected the years to round-trip, got %+v", decoded.WeatherDataForYear)
+	}
+
+	var again bytes.Buffer
+	if err := conv.Encode(&again, xmlTestData()); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if again.String() != output {
+		t.Error("Expected identical output for identical data")
+	}
+}
+
+func TestWeatherDataXSD(t *testing.T) {
+	xsd := WeatherDataXSD()
+	var schema struct {
+		XMLName  xml.Name `xml:"schema"`
+		Elements []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"element"`
+	}
+	if err := xml.Unmarshal(xsd, &schema); err != nil {
+		t.Fatalf("Schema is not valid XML: %v", err)
+	}
+	if len(schema.Elements) != 1 || schema.Elements[0].Name != "WeatherData" {
+		t.Errorf("Expected a WeatherData root element, got %+v", schema.Elements)
+	}
+	for _, name := range []string{"WeatherDataForYear", "MonthlyAggregates", "WeatherDataForMonth", "DailyRecords", "Statistic"} {
+		if !bytes.Contains(xsd, []byte(`name="`+name+`"`)) {
+			t.Errorf("Expected the schema to declare %s", name)
+		}
+	}
+
+	xsd[0] = 'x'
+	if WeatherDataXSD()[0] == 'x' {
+		t.Error("Expected WeatherDataXSD to return a copy")
+	}
+}
+
+func TestWriteXML_ValidatesAgainstXSD(t *testing.T) {
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		t.Skip("xmllint is not installed")
+	}
+	dir := t.TempDir()
+	xsd := filepath.Join(dir, "weatherdata.xsd")
+	if err := os.WriteFile(xsd, WeatherDataXSD(), 0o644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+
+	filtered := ProcessorOptions{
+		Aggregator: DefaultAggregatorOptions(),
+		Filter:     Filter{Years: []int{2019}},
+		Format:     FormatXML,
+	}
+	filtered.Aggregator.IncludeDaily = true
+	filtered.Aggregator.Statistics, err = AllStatistics().Select([]string{"TotalRainfall", "StdDevDailyRainfall"})
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	for name, options := range map[string]ProcessorOptions{
+		"default":  {Aggregator: DefaultAggregatorOptions(), Format: FormatXML},
+		"filtered": filtered,
+	} {
+		input, err := os.Open("../../test_data/IDCJAC0009_066062_1800_Data.csv")
+		if err != nil {
+			t.Fatalf("Failed to open test data: %v", err)
+		}
+		output := filepath.Join(dir, name+".xml")
+		out, err := os.Create(output)
+		if err != nil {
+			t.Fatalf("Failed to create output: %v", err)
+		}
+		err = NewProcessorWithOptions(options).ProcessWeatherData(input, out)
+		input.Close()
+		out.Close()
+		if err != nil {
+			t.Fatalf("%s: ProcessWeatherData failed: %v", name, err)
+		}
+
+		if result, err := exec.Command(xmllint, "--noout", "--schema", xsd, output).CombinedOutput(); err != nil {
+			t.Errorf("%s: expected the output to validate against the schema: %v\n%s", name, err, result)
+		}
+	}
+}